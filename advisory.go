@@ -0,0 +1,226 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Advisory is an operator notice such as a maintenance window. Advisories with
+// no Contexts are global and shown at startup; others are shown when entering
+// one of the listed contexts. Both repeat until acknowledged in the session.
+type Advisory struct {
+	ID       string
+	Message  string
+	Severity SeverityLevel
+	Contexts []string
+	Created  time.Time
+	Expires  time.Time
+}
+
+// Global reports whether the advisory applies everywhere.
+func (a Advisory) Global() bool { return len(a.Contexts) == 0 }
+
+// AppliesTo reports whether the advisory targets ctx.
+func (a Advisory) AppliesTo(ctx string) bool {
+	for _, c := range a.Contexts {
+		if c == ctx {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Advisory) expired(now time.Time) bool {
+	return !a.Expires.IsZero() && now.After(a.Expires)
+}
+
+// AdvisoryService stores advisories pushed by the host application.
+type AdvisoryService struct {
+	mu         sync.RWMutex
+	seq        int
+	advisories map[string]Advisory
+}
+
+// NewAdvisoryService constructs an empty service.
+func NewAdvisoryService() *AdvisoryService {
+	return &AdvisoryService{advisories: map[string]Advisory{}}
+}
+
+// Push publishes an advisory, assigning an ID when empty, and returns its ID.
+func (s *AdvisoryService) Push(a Advisory) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.ID == "" {
+		s.seq++
+		a.ID = fmt.Sprintf("adv-%d", s.seq)
+	}
+	if a.Severity == "" {
+		a.Severity = SeverityInfo
+	}
+	if a.Created.IsZero() {
+		a.Created = time.Now()
+	}
+	s.advisories[a.ID] = a
+	return a.ID
+}
+
+// Remove withdraws an advisory.
+func (s *AdvisoryService) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.advisories[id]
+	delete(s.advisories, id)
+	return ok
+}
+
+// Active lists unexpired advisories, oldest first.
+func (s *AdvisoryService) Active() []Advisory {
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Advisory, 0, len(s.advisories))
+	for _, a := range s.advisories {
+		if !a.expired(now) {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Created.Equal(list[j].Created) {
+			return list[i].Created.Before(list[j].Created)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// WithAdvisory publishes an advisory when the engine is created.
+func WithAdvisory(a Advisory) Option {
+	return func(e *Engine) { e.advisories.Push(a) }
+}
+
+// Advisories exposes the engine's advisory service.
+func (e *Engine) Advisories() *AdvisoryService { return e.advisories }
+
+// Acknowledge marks an advisory as read for this session.
+func (s *Session) Acknowledge(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acked[id] = time.Now()
+}
+
+// Acknowledged reports whether the session has acknowledged an advisory.
+func (s *Session) Acknowledged(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.acked[id]
+	return ok
+}
+
+// announceAdvisories prints unacknowledged advisories before a prompt: global
+// ones not yet shown this session, and context ones when the context changed.
+func (s *Session) announceAdvisories(w io.Writer) {
+	ctx := s.contexts.Current().Spec.Name
+	s.mu.Lock()
+	entered := !s.advisoryStarted || ctx != s.advisoryCtx
+	s.advisoryStarted = true
+	s.advisoryCtx = ctx
+	var due []Advisory
+	for _, a := range s.engine.advisories.Active() {
+		if _, ok := s.acked[a.ID]; ok {
+			continue
+		}
+		switch {
+		case a.Global() && !s.advisoryShown[a.ID]:
+			s.advisoryShown[a.ID] = true
+			due = append(due, a)
+		case entered && a.AppliesTo(ctx):
+			due = append(due, a)
+		}
+	}
+	s.mu.Unlock()
+	for _, a := range due {
+		fmt.Fprintf(w, "%s [%s] %s (acknowledge with `advisories ack %s`)\n", Colorize("ADVISORY", SeverityColor(a.Severity)), a.ID, a.Message, a.ID)
+	}
+}
+
+// advisories command ----------------------------------------------------------
+
+type advisoriesCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *advisoriesCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:    "advisories",
+			Summary: "List or acknowledge operator advisories",
+			Context: "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"list", "ack"}, Default: "list", Description: "Action to perform"},
+				{Name: "id", Type: ArgTypeString, Description: "Advisory ID, or all"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *advisoriesCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &advisoriesCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type advisoriesCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *advisoriesCommand) Spec() CommandSpec { return c.spec }
+
+func (c *advisoriesCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "advisories requires an engine session", Severity: SeverityError}}
+	}
+	active := c.engine.advisories.Active()
+	if input.Args.String("action") == "ack" {
+		id := input.Args.String("id")
+		if id == "" {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "advisories ack requires an ID or all", Severity: SeverityError}}
+		}
+		count := 0
+		for _, a := range active {
+			if id == "all" || a.ID == id {
+				session.Acknowledge(a.ID)
+				count++
+			}
+		}
+		if count == 0 {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("unknown advisory: %s", id), Severity: SeverityError}}
+		}
+		rt.Output().Info(fmt.Sprintf("Acknowledged %d advisory(ies)", count))
+		return CommandResult{Status: StatusSuccess}
+	}
+
+	if len(active) == 0 {
+		rt.Output().Info("No advisories.")
+		return CommandResult{Status: StatusSuccess}
+	}
+	rows := make([][]string, 0, len(active))
+	for _, a := range active {
+		scope := "global"
+		if !a.Global() {
+			scope = strings.Join(a.Contexts, ",")
+		}
+		acked := "no"
+		if session.Acknowledged(a.ID) {
+			acked = "yes"
+		}
+		rows = append(rows, []string{a.ID, string(a.Severity), scope, acked, a.Message})
+	}
+	rt.Output().WriteTable([]string{"ID", "Severity", "Scope", "Acked", "Message"}, rows)
+	return CommandResult{Status: StatusSuccess, Payload: active}
+}
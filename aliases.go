@@ -0,0 +1,297 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Alias is a user-defined name for a command line prefix.
+type Alias struct {
+	Name      string `json:"name"`
+	Expansion string `json:"expansion"`
+}
+
+// AliasManager stores the user-defined aliases shared by every session of an
+// engine. After Load, every change is written back to the file.
+type AliasManager struct {
+	mu      sync.RWMutex
+	path    string
+	aliases map[string]string
+}
+
+// NewAliasManager constructs an empty, in-memory manager.
+func NewAliasManager() *AliasManager {
+	return &AliasManager{aliases: map[string]string{}}
+}
+
+// Load reads aliases from path, a JSON object of name to expansion, and
+// persists later changes there. A missing file is not an error.
+func (m *AliasManager) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	loaded := map[string]string{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.path = path
+	for name, expansion := range loaded {
+		if validAliasName(name) == nil && strings.TrimSpace(expansion) != "" {
+			m.aliases[name] = expansion
+		}
+	}
+	return nil
+}
+
+// Set adds or replaces an alias.
+func (m *AliasManager) Set(name, expansion string) error {
+	if err := validAliasName(name); err != nil {
+		return err
+	}
+	expansion = strings.TrimSpace(expansion)
+	if expansion == "" {
+		return fmt.Errorf("alias %s has no expansion", name)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, existed := m.aliases[name]
+	m.aliases[name] = expansion
+	if err := m.saveLocked(); err != nil {
+		if existed {
+			m.aliases[name] = previous
+		} else {
+			delete(m.aliases, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove deletes an alias, reporting whether it existed.
+func (m *AliasManager) Remove(name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expansion, ok := m.aliases[name]
+	if !ok {
+		return false, nil
+	}
+	delete(m.aliases, name)
+	if err := m.saveLocked(); err != nil {
+		m.aliases[name] = expansion
+		return true, err
+	}
+	return true, nil
+}
+
+// Get returns the expansion of an alias.
+func (m *AliasManager) Get(name string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	expansion, ok := m.aliases[name]
+	return expansion, ok
+}
+
+// List returns aliases sorted by name.
+func (m *AliasManager) List() []Alias {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]Alias, 0, len(m.aliases))
+	for name, expansion := range m.aliases {
+		list = append(list, Alias{Name: name, Expansion: expansion})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Expand replaces a leading alias in tokens with its expansion, keeping the
+// remaining tokens as further arguments. Expansions that start with another
+// alias are expanded in turn; like shells, an alias is not expanded twice, so
+// `alias add ls "ls --long"` works.
+func (m *AliasManager) Expand(tokens []string) []string {
+	if len(tokens) == 0 {
+		return tokens
+	}
+	return words(m.expandTokens(literalTokens(tokens)))
+}
+
+// expandTokens is Expand for lexed tokens; operators in an expansion, as in
+// `alias add up "nodes | filter --status up"`, stay operators.
+func (m *AliasManager) expandTokens(tokens []token) []token {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	seen := map[string]bool{}
+	for len(tokens) > 0 && !tokens[0].op && !seen[tokens[0].text] {
+		expansion, ok := m.aliases[tokens[0].text]
+		if !ok {
+			break
+		}
+		seen[tokens[0].text] = true
+		lexed, _, _ := lexTokens(expansion)
+		tokens = append(lexed, tokens[1:]...)
+	}
+	return tokens
+}
+
+// saveLocked rewrites the alias file, if any, through a temporary file.
+func (m *AliasManager) saveLocked() error {
+	if m.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(m.aliases, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+// validAliasName rejects names that could not be typed as a command.
+func validAliasName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("alias name is required")
+	case strings.ContainsAny(name, " \t\r\n|&"):
+		return fmt.Errorf("invalid alias name %q", name)
+	case name == "alias":
+		return errors.New("the alias command cannot be aliased")
+	}
+	return nil
+}
+
+// WithAliasFile loads user aliases from path and saves changes made with the
+// alias command back to it.
+func WithAliasFile(path string) Option {
+	return func(e *Engine) {
+		if err := e.aliases.Load(path); err != nil {
+			fmt.Fprintf(e.outputWriter, "Error loading aliases: %v\n", err)
+		}
+	}
+}
+
+// WithAlias seeds an alias, e.g. from configuration.
+func WithAlias(name, expansion string) Option {
+	return func(e *Engine) {
+		if err := e.aliases.Set(name, expansion); err != nil {
+			fmt.Fprintf(e.outputWriter, "Error adding alias: %v\n", err)
+		}
+	}
+}
+
+// Aliases exposes the engine's user-defined aliases.
+func (e *Engine) Aliases() *AliasManager { return e.aliases }
+
+// alias command ---------------------------------------------------------------
+
+type aliasCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *aliasCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "alias",
+			Summary:     "Define shortcuts for command lines",
+			Description: "Aliases are expanded before a line is parsed, so an expansion may include arguments and flags; words typed after the alias are appended to it.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"list", "add", "rm"}, Default: "list", Description: "Action to perform"},
+				{Name: "name", Type: ArgTypeString, Description: "Alias name", Complete: completeAliasNames},
+				{Name: "expansion", Type: ArgTypeString, Repeatable: true, Passthrough: true, Description: "Command line the alias stands for"},
+			},
+			Examples: []Example{
+				{Description: "Define an alias", Command: `alias add st "node status --verbose"`},
+				{Description: "Remove it", Command: "alias rm st"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *aliasCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &aliasCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type aliasCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *aliasCommand) Spec() CommandSpec { return c.spec }
+
+func (c *aliasCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	aliases := c.engine.aliases
+	action := input.Args.String("action")
+	name := input.Args.String("name")
+	if action != "list" && name == "" {
+		return aliasFailure(fmt.Errorf("alias %s requires a name", action))
+	}
+
+	switch action {
+	case "add":
+		// A quoted expansion arrives as one word; several words are requoted
+		// so any quoting inside them survives.
+		words := input.Args.Strings("expansion")
+		expansion := QuoteCommandLine(words)
+		if len(words) == 1 {
+			expansion = words[0]
+		}
+		if err := aliases.Set(name, expansion); err != nil {
+			return aliasFailure(err)
+		}
+		rt.Output().Info(fmt.Sprintf("%s = %s", name, expansion))
+		return CommandResult{Status: StatusSuccess, Payload: Alias{Name: name, Expansion: expansion}}
+	case "rm":
+		ok, err := aliases.Remove(name)
+		if err != nil {
+			return aliasFailure(err)
+		}
+		if !ok {
+			return aliasFailure(fmt.Errorf("unknown alias: %s", name))
+		}
+		rt.Output().Info(fmt.Sprintf("Removed alias %s", name))
+		return CommandResult{Status: StatusSuccess}
+	}
+	list := aliases.List()
+	if len(list) == 0 {
+		rt.Output().Info("No aliases defined.")
+		return CommandResult{Status: StatusSuccess, Payload: list}
+	}
+	rows := make([][]string, 0, len(list))
+	for _, a := range list {
+		rows = append(rows, []string{a.Name, a.Expansion})
+	}
+	rt.Output().WriteTable([]string{"Alias", "Expansion"}, rows)
+	return CommandResult{Status: StatusSuccess, Payload: list}
+}
+
+func completeAliasNames(prefix string, rt CommandRuntime) []string {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, a := range session.engine.aliases.List() {
+		if strings.HasPrefix(a.Name, prefix) {
+			names = append(names, a.Name)
+		}
+	}
+	return names
+}
+
+func aliasFailure(err error) CommandResult {
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+}
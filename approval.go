@@ -0,0 +1,512 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TagRequiresApproval marks a command, through CommandSpec.Tags, that only
+// runs after a second operator approves it; see WithApprovals.
+const TagRequiresApproval = "requires-approval"
+
+// ErrCodeApprovalRejected is the error code of commands whose approval was
+// rejected or withdrawn.
+const ErrCodeApprovalRejected = "approval_rejected"
+
+// DefaultApprovalTimeout bounds the wait for an approval unless overridden.
+const DefaultApprovalTimeout = 15 * time.Minute
+
+// ErrUnknownApproval is returned for an approval ID no backend record matches.
+var ErrUnknownApproval = errors.New("unknown approval")
+
+// ApprovalStatus is the state of an approval request.
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"
+	ApprovalApproved ApprovalStatus = "approved"
+	ApprovalRejected ApprovalStatus = "rejected"
+	// ApprovalWithdrawn marks a request whose command was interrupted or timed
+	// out before a decision.
+	ApprovalWithdrawn ApprovalStatus = "withdrawn"
+)
+
+// ApprovalRequest is one pending or decided approval.
+type ApprovalRequest struct {
+	ID           string         `json:"id"`
+	Command      string         `json:"command"`
+	Line         string         `json:"line"`
+	Requester    string         `json:"requester"`
+	SessionID    string         `json:"session_id"`
+	InvocationID string         `json:"invocation_id"`
+	Created      time.Time      `json:"created"`
+	Status       ApprovalStatus `json:"status"`
+	Approver     string         `json:"approver,omitempty"`
+	Reason       string         `json:"reason,omitempty"`
+	Decided      time.Time      `json:"decided,omitempty"`
+}
+
+// ApprovalBackend stores approval requests where the second operator can
+// decide them: in memory for operators sharing an engine, in a file, or
+// behind a webhook.
+type ApprovalBackend interface {
+	Create(ctx context.Context, req ApprovalRequest) error
+	Get(ctx context.Context, id string) (ApprovalRequest, error)
+	List(ctx context.Context) ([]ApprovalRequest, error)
+	Decide(ctx context.Context, id string, status ApprovalStatus, approver, reason string) (ApprovalRequest, error)
+}
+
+// MemoryApprovals keeps approval requests in process, for sessions of one engine.
+type MemoryApprovals struct {
+	mu       sync.Mutex
+	requests map[string]ApprovalRequest
+}
+
+// NewMemoryApprovals constructs an empty in-process backend.
+func NewMemoryApprovals() *MemoryApprovals {
+	return &MemoryApprovals{requests: map[string]ApprovalRequest{}}
+}
+
+// Create implements ApprovalBackend.
+func (m *MemoryApprovals) Create(_ context.Context, req ApprovalRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[req.ID] = req
+	return nil
+}
+
+// Get implements ApprovalBackend.
+func (m *MemoryApprovals) Get(_ context.Context, id string) (ApprovalRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	req, ok := m.requests[id]
+	if !ok {
+		return ApprovalRequest{}, fmt.Errorf("%w: %s", ErrUnknownApproval, id)
+	}
+	return req, nil
+}
+
+// List implements ApprovalBackend, oldest first.
+func (m *MemoryApprovals) List(_ context.Context) ([]ApprovalRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedApprovals(m.requests), nil
+}
+
+// Decide implements ApprovalBackend. Only pending requests can be decided.
+func (m *MemoryApprovals) Decide(_ context.Context, id string, status ApprovalStatus, approver, reason string) (ApprovalRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return decideApproval(m.requests, id, status, approver, reason)
+}
+
+// FileApprovals keeps approval requests in a JSON file, so operators running
+// separate processes on one host can approve each other's commands.
+type FileApprovals struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileApprovals returns a backend storing requests at path.
+func NewFileApprovals(path string) *FileApprovals {
+	return &FileApprovals{Path: path}
+}
+
+// Create implements ApprovalBackend.
+func (f *FileApprovals) Create(_ context.Context, req ApprovalRequest) error {
+	return f.modify(func(requests map[string]ApprovalRequest) error {
+		requests[req.ID] = req
+		return nil
+	})
+}
+
+// Get implements ApprovalBackend.
+func (f *FileApprovals) Get(_ context.Context, id string) (ApprovalRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	requests, err := f.load()
+	if err != nil {
+		return ApprovalRequest{}, err
+	}
+	req, ok := requests[id]
+	if !ok {
+		return ApprovalRequest{}, fmt.Errorf("%w: %s", ErrUnknownApproval, id)
+	}
+	return req, nil
+}
+
+// List implements ApprovalBackend, oldest first.
+func (f *FileApprovals) List(_ context.Context) ([]ApprovalRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	requests, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return sortedApprovals(requests), nil
+}
+
+// Decide implements ApprovalBackend. Only pending requests can be decided.
+func (f *FileApprovals) Decide(_ context.Context, id string, status ApprovalStatus, approver, reason string) (ApprovalRequest, error) {
+	var decided ApprovalRequest
+	err := f.modify(func(requests map[string]ApprovalRequest) error {
+		var err error
+		decided, err = decideApproval(requests, id, status, approver, reason)
+		return err
+	})
+	return decided, err
+}
+
+func (f *FileApprovals) load() (map[string]ApprovalRequest, error) {
+	requests := map[string]ApprovalRequest{}
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return requests, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &requests); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Path, err)
+		}
+	}
+	return requests, nil
+}
+
+// modify loads the file, applies fn, and rewrites it through a temporary file
+// in the same directory. It holds a lock on Path+".lock" meanwhile, so that
+// processes sharing the file do not lose each other's changes.
+func (f *FileApprovals) modify(fn func(map[string]ApprovalRequest) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	unlock, err := lockFile(f.Path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	requests, err := f.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(requests); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// HTTPApprovals hands approval requests to a web service, which can notify
+// approvers through chat or a ticketing system:
+//
+//	POST URL                 body: ApprovalRequest
+//	GET  URL                 response: []ApprovalRequest
+//	GET  URL/{id}            response: ApprovalRequest
+//	POST URL/{id}/decision   body: {"status", "approver", "reason"}, response: ApprovalRequest
+type HTTPApprovals struct {
+	URL    string
+	Client *http.Client
+	// Header is added to every request, e.g. an Authorization token.
+	Header http.Header
+}
+
+// Create implements ApprovalBackend.
+func (h HTTPApprovals) Create(ctx context.Context, req ApprovalRequest) error {
+	return h.do(ctx, http.MethodPost, h.URL, req, nil)
+}
+
+// Get implements ApprovalBackend.
+func (h HTTPApprovals) Get(ctx context.Context, id string) (ApprovalRequest, error) {
+	var req ApprovalRequest
+	err := h.do(ctx, http.MethodGet, h.URL+"/"+url.PathEscape(id), nil, &req)
+	return req, err
+}
+
+// List implements ApprovalBackend.
+func (h HTTPApprovals) List(ctx context.Context) ([]ApprovalRequest, error) {
+	var list []ApprovalRequest
+	err := h.do(ctx, http.MethodGet, h.URL, nil, &list)
+	return list, err
+}
+
+// Decide implements ApprovalBackend.
+func (h HTTPApprovals) Decide(ctx context.Context, id string, status ApprovalStatus, approver, reason string) (ApprovalRequest, error) {
+	body := map[string]string{"status": string(status), "approver": approver, "reason": reason}
+	var req ApprovalRequest
+	err := h.do(ctx, http.MethodPost, h.URL+"/"+url.PathEscape(id)+"/decision", body, &req)
+	return req, err
+}
+
+func (h HTTPApprovals) do(ctx context.Context, method, target string, in, out any) error {
+	var body *bytes.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	// HTTPHistory speaks the same JSON conventions.
+	return HTTPHistory{URL: h.URL, Client: h.Client, Header: h.Header}.do(ctx, method, target, body, out)
+}
+
+func sortedApprovals(requests map[string]ApprovalRequest) []ApprovalRequest {
+	list := make([]ApprovalRequest, 0, len(requests))
+	for _, req := range requests {
+		list = append(list, req)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	return list
+}
+
+func decideApproval(requests map[string]ApprovalRequest, id string, status ApprovalStatus, approver, reason string) (ApprovalRequest, error) {
+	req, ok := requests[id]
+	if !ok {
+		return ApprovalRequest{}, fmt.Errorf("%w: %s", ErrUnknownApproval, id)
+	}
+	if req.Status != ApprovalPending {
+		return req, fmt.Errorf("approval %s is already %s", id, req.Status)
+	}
+	req.Status, req.Approver, req.Reason, req.Decided = status, approver, reason, time.Now()
+	requests[id] = req
+	return req, nil
+}
+
+// approvalIdentity names the operator of a session for approval records: the
+// principal when authenticated, otherwise the session.
+func approvalIdentity(store SessionStore, sessionID string) string {
+	if p, ok := PrincipalFromSession(store); ok {
+		return p.Name
+	}
+	return "session:" + sessionID
+}
+
+func newApprovalID() string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return "apr-" + hex.EncodeToString(b[:])
+}
+
+// approvalPoll is how often a waiting command checks its request.
+const approvalPoll = 500 * time.Millisecond
+
+// ApprovalMiddleware holds commands tagged TagRequiresApproval until a second
+// operator approves them through backend, failing when the request is
+// rejected or timeout passes. Dry runs go ahead without approval.
+func ApprovalMiddleware(backend ApprovalBackend, timeout time.Duration) Middleware {
+	return func(rt CommandRuntime, input CommandInput, entry CommandEntry, next NextFunc) CommandResult {
+		if !slices.Contains(entry.Spec.Tags, TagRequiresApproval) || input.DryRun {
+			return next(rt, input)
+		}
+		meta := rt.InvocationMeta()
+		req := ApprovalRequest{
+			ID:           newApprovalID(),
+			Command:      entry.Spec.Name,
+			Line:         QuoteCommandLine(append([]string{entry.Spec.Name}, redactArgs(entry.resolved(), input.Raw)...)),
+			Requester:    approvalIdentity(rt.Session(), meta.SessionID),
+			SessionID:    meta.SessionID,
+			InvocationID: meta.ID,
+			Created:      time.Now(),
+			Status:       ApprovalPending,
+		}
+		ctx := rt.Cancellation()
+		if err := backend.Create(ctx, req); err != nil {
+			return approvalFailure(fmt.Errorf("requesting approval: %w", err), "")
+		}
+		rt.Output().Info(fmt.Sprintf("%s requires approval; waiting for another operator to run: approvals approve %s", entry.Spec.Name, req.ID))
+		if timeout <= 0 {
+			timeout = DefaultApprovalTimeout
+		}
+		deadline := time.NewTimer(timeout)
+		defer deadline.Stop()
+		ticker := time.NewTicker(approvalPoll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				_, _ = backend.Decide(context.Background(), req.ID, ApprovalWithdrawn, req.Requester, "interrupted")
+				return approvalFailure(fmt.Errorf("approval %s withdrawn: %w", req.ID, ctx.Err()), ErrCodeCanceled)
+			case <-deadline.C:
+				_, _ = backend.Decide(context.Background(), req.ID, ApprovalWithdrawn, req.Requester, "timed out")
+				return approvalFailure(fmt.Errorf("approval %s not granted within %s: %w", req.ID, timeout, context.DeadlineExceeded), ErrCodeTimeout)
+			case <-ticker.C:
+			}
+			current, err := backend.Get(ctx, req.ID)
+			if err != nil {
+				continue
+			}
+			switch current.Status {
+			case ApprovalApproved:
+				rt.Output().Info(fmt.Sprintf("Approved by %s.", current.Approver))
+				return next(rt, input)
+			case ApprovalRejected, ApprovalWithdrawn:
+				msg := fmt.Sprintf("approval %s %s by %s", req.ID, current.Status, current.Approver)
+				if current.Reason != "" {
+					msg += ": " + current.Reason
+				}
+				return approvalFailure(errors.New(msg), ErrCodeApprovalRejected)
+			}
+		}
+	}
+}
+
+func approvalFailure(err error, code string) CommandResult {
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), ErrCode: code, Severity: SeverityError}}
+}
+
+// WithApprovals enforces TagRequiresApproval through backend with
+// ApprovalMiddleware and makes backend the one the approvals command uses.
+func WithApprovals(backend ApprovalBackend) Option {
+	return func(e *Engine) {
+		e.approvals = backend
+		e.errorCodes = append(e.errorCodes, ErrorCodeInfo{Code: ErrCodeApprovalRejected, Description: "A second operator rejected the command"})
+		e.middleware = append(e.middleware, func(rt CommandRuntime, input CommandInput, entry CommandEntry, next NextFunc) CommandResult {
+			return ApprovalMiddleware(backend, e.approvalTimeout)(rt, input, entry, next)
+		})
+	}
+}
+
+// WithApprovalTimeout bounds how long a command waits for approval; the
+// default is DefaultApprovalTimeout.
+func WithApprovalTimeout(d time.Duration) Option {
+	return func(e *Engine) { e.approvalTimeout = d }
+}
+
+// approvals command -----------------------------------------------------------
+
+type approvalsCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *approvalsCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "approvals",
+			Summary:     "Review commands awaiting approval",
+			Description: "Lists approval requests and approves or rejects them. Commands tagged requires-approval wait until a different operator approves them.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"list", "approve", "reject"}, Default: "list", Description: "Action to perform"},
+				{Name: "id", Type: ArgTypeString, Description: "Approval ID", Complete: f.completeIDs},
+			},
+			Flags: []FlagSpec{
+				{Name: "reason", Type: ArgTypeString, Description: "Reason recorded with the decision"},
+				{Name: "all", Shorthand: "a", Type: ArgTypeBool, Description: "Include decided requests"},
+			},
+			Examples: []Example{
+				{Description: "Show pending requests", Command: "approvals"},
+				{Description: "Approve one", Command: "approvals approve apr-1a2b3c4d"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *approvalsCommandFactory) completeIDs(prefix string, rt CommandRuntime) []string {
+	if f.engine.approvals == nil {
+		return nil
+	}
+	list, _ := f.engine.approvals.List(rt.Cancellation())
+	var ids []string
+	for _, req := range list {
+		if req.Status == ApprovalPending && strings.HasPrefix(req.ID, prefix) {
+			ids = append(ids, req.ID)
+		}
+	}
+	return ids
+}
+
+func (f *approvalsCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &approvalsCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type approvalsCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *approvalsCommand) Spec() CommandSpec { return c.spec }
+
+func (c *approvalsCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	backend := c.engine.approvals
+	if backend == nil {
+		return approvalFailure(errors.New("approvals are not enabled"), "")
+	}
+	ctx := rt.Cancellation()
+	action := input.Args.String("action")
+	if action == "list" {
+		list, err := backend.List(ctx)
+		if err != nil {
+			return approvalFailure(err, "")
+		}
+		rows := [][]string{}
+		shown := []ApprovalRequest{}
+		for _, req := range list {
+			if req.Status != ApprovalPending && !input.Flags.Bool("all") {
+				continue
+			}
+			shown = append(shown, req)
+			rows = append(rows, []string{req.ID, req.Line, req.Requester, req.Created.Format(time.TimeOnly), string(req.Status), req.Approver})
+		}
+		if len(rows) == 0 {
+			rt.Output().Info("No pending approvals.")
+			return CommandResult{Status: StatusSuccess, Payload: shown}
+		}
+		rt.Output().WriteTable([]string{"ID", "Command", "Requested by", "At", "Status", "Decided by"}, rows)
+		return CommandResult{Status: StatusSuccess, Payload: shown}
+	}
+
+	id := input.Args.String("id")
+	if id == "" {
+		return approvalFailure(fmt.Errorf("approvals %s requires an ID", action), ErrCodeUsage)
+	}
+	req, err := backend.Get(ctx, id)
+	if err != nil {
+		return approvalFailure(err, "")
+	}
+	meta := rt.InvocationMeta()
+	approver := approvalIdentity(rt.Session(), meta.SessionID)
+	if action == "approve" && approver == req.Requester {
+		return approvalFailure(fmt.Errorf("%w: %s cannot approve their own request", ErrPermissionDenied, approver), ErrCodePermissionDenied)
+	}
+	status := ApprovalApproved
+	if action == "reject" {
+		status = ApprovalRejected
+	}
+	decided, err := backend.Decide(ctx, id, status, approver, input.Flags.String("reason"))
+	if err != nil {
+		return approvalFailure(err, "")
+	}
+	rt.Output().Info(fmt.Sprintf("%s %s: %s", decided.Status, decided.ID, decided.Line))
+	return CommandResult{Status: StatusSuccess, Payload: decided}
+}
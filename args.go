@@ -4,14 +4,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/chzyer/readline"
 )
 
 // ValueSet provides typed accessors for parsed arguments or flags.
 type ValueSet struct {
 	values map[string]any
+	// files holds the contents read for @path and @- values.
+	files map[string][]byte
 }
 
 // newValueSet constructs a ValueSet from a map.
@@ -25,6 +32,41 @@ func (v ValueSet) Raw(name string) (any, bool) {
 	return res, ok
 }
 
+// With returns a copy of v with name set to value, leaving v unchanged. Middleware
+// uses it to inject or rewrite values before passing the input on.
+func (v ValueSet) With(name string, value any) ValueSet {
+	values := make(map[string]any, len(v.values)+1)
+	for k, val := range v.values {
+		values[k] = val
+	}
+	values[name] = value
+	return ValueSet{values: values, files: withoutFile(v.files, name)}
+}
+
+// Without returns a copy of v with name removed, leaving v unchanged.
+func (v ValueSet) Without(name string) ValueSet {
+	values := make(map[string]any, len(v.values))
+	for k, val := range v.values {
+		if k != name {
+			values[k] = val
+		}
+	}
+	return ValueSet{values: values, files: withoutFile(v.files, name)}
+}
+
+func withoutFile(files map[string][]byte, name string) map[string][]byte {
+	if _, ok := files[name]; !ok {
+		return files
+	}
+	kept := make(map[string][]byte, len(files))
+	for k, data := range files {
+		if k != name {
+			kept[k] = data
+		}
+	}
+	return kept
+}
+
 // String retrieves a string value.
 func (v ValueSet) String(name string) string {
 	if val, ok := v.values[name]; ok {
@@ -142,8 +184,100 @@ func (v ValueSet) Duration(name string) time.Duration {
 	return 0
 }
 
+// IP retrieves an ArgTypeIP value, or nil.
+func (v ValueSet) IP(name string) net.IP {
+	switch t := v.values[name].(type) {
+	case net.IP:
+		return t
+	case string:
+		return net.ParseIP(t)
+	}
+	return nil
+}
+
+// CIDR retrieves an ArgTypeCIDR value, or nil.
+func (v ValueSet) CIDR(name string) *net.IPNet {
+	switch t := v.values[name].(type) {
+	case *net.IPNet:
+		return t
+	case string:
+		_, n, _ := net.ParseCIDR(t)
+		return n
+	}
+	return nil
+}
+
+// MAC retrieves an ArgTypeMAC value, or nil.
+func (v ValueSet) MAC(name string) net.HardwareAddr {
+	switch t := v.values[name].(type) {
+	case net.HardwareAddr:
+		return t
+	case string:
+		mac, _ := net.ParseMAC(t)
+		return mac
+	}
+	return nil
+}
+
+// Time retrieves an ArgTypeTime value, or the zero time.
+func (v ValueSet) Time(name string) time.Time {
+	switch t := v.values[name].(type) {
+	case time.Time:
+		return t
+	case string:
+		parsed, _ := parseTime(t, time.Now())
+		return parsed
+	}
+	return time.Time{}
+}
+
+// Bytes returns the contents read for an @path or @- value, or the value's
+// text when it was given inline.
+func (v ValueSet) Bytes(name string) []byte {
+	if data, ok := v.files[name]; ok {
+		return data
+	}
+	switch t := v.values[name].(type) {
+	case nil:
+		return nil
+	case []byte:
+		return t
+	case string:
+		return []byte(t)
+	default:
+		return []byte(v.String(name))
+	}
+}
+
+// FromFile reports whether the value was read from a file or stdin.
+func (v ValueSet) FromFile(name string) bool {
+	_, ok := v.files[name]
+	return ok
+}
+
+// JSON returns the value decoded as JSON: ArgTypeJSON values as parsed, and
+// others, including file contents, decoded from their text.
+func (v ValueSet) JSON(name string) (any, error) {
+	val, ok := v.values[name]
+	if !ok {
+		return nil, fmt.Errorf("value %q not present", name)
+	}
+	switch val.(type) {
+	case string, []byte:
+		var decoded any
+		if err := json.Unmarshal(v.Bytes(name), &decoded); err != nil {
+			return nil, err
+		}
+		return decoded, nil
+	}
+	return val, nil
+}
+
 // DecodeJSON decodes the value into the provided destination.
 func (v ValueSet) DecodeJSON(name string, dest any) error {
+	if data, ok := v.files[name]; ok {
+		return json.Unmarshal(data, dest)
+	}
 	if val, ok := v.values[name]; ok {
 		switch t := val.(type) {
 		case string:
@@ -161,17 +295,80 @@ func (v ValueSet) DecodeJSON(name string, dest any) error {
 	return fmt.Errorf("value %q not present", name)
 }
 
+// ErrMissingArgument is wrapped by the ParseError returned when a required argument is absent.
+var ErrMissingArgument = errors.New("missing required argument")
+
+// ParseError reports an argument parsing failure and the argument or flag involved.
+type ParseError struct {
+	Err  error
+	Arg  string
+	Flag string
+}
+
+// Error implements error.
+func (e *ParseError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// JSONValueError reports invalid JSON and where in the value parsing failed.
+type JSONValueError struct {
+	Value  string
+	Offset int64
+	Line   int
+	Column int
+	Err    error
+}
+
+func newJSONValueError(raw string, err error) *JSONValueError {
+	e := &JSONValueError{Value: raw, Offset: int64(len(raw)), Err: err}
+	var syn *json.SyntaxError
+	if errors.As(err, &syn) {
+		e.Offset = syn.Offset
+	}
+	e.Line, e.Column = 1, 1
+	for i, r := range raw {
+		if int64(i) >= e.Offset-1 {
+			break
+		}
+		if r == '\n' {
+			e.Line++
+			e.Column = 1
+		} else {
+			e.Column++
+		}
+	}
+	return e
+}
+
+// Error implements error.
+func (e *JSONValueError) Error() string {
+	return fmt.Sprintf("invalid json at line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *JSONValueError) Unwrap() error { return e.Err }
+
 // ArgsParser parses raw args into typed value sets according to specs.
-type ArgsParser struct{}
+type ArgsParser struct {
+	// Stdin supplies @- values; nil means os.Stdin.
+	Stdin io.Reader
+}
 
 // NewArgsParser constructs an ArgsParser.
 func NewArgsParser() *ArgsParser { return &ArgsParser{} }
 
 // Parse parses raw arguments with provided spec metadata.
 func (p *ArgsParser) Parse(raw []string, spec CommandSpec) (ValueSet, ValueSet, error) {
-	flagDefs := buildFlagIndex(spec.Flags)
+	return p.parse(raw, spec, buildFlagIndex(spec.Flags))
+}
+
+// parse is Parse with the flag index of spec already built.
+func (p *ArgsParser) parse(raw []string, spec CommandSpec, flagDefs flagIndex) (ValueSet, ValueSet, error) {
 	argValues := map[string]any{}
 	flagValues := map[string]any{}
+	argFiles := map[string][]byte{}
+	flagFiles := map[string][]byte{}
 
 	posIndex := 0
 	var repeatableArg *ArgSpec
@@ -183,11 +380,29 @@ func (p *ArgsParser) Parse(raw []string, spec CommandSpec) (ValueSet, ValueSet,
 	}
 
 	i := 0
+	endOfFlags := false
 	for i < len(raw) {
 		token := raw[i]
-		if strings.HasPrefix(token, "--") {
+		if posIndex < len(spec.Args) && spec.Args[posIndex].Passthrough && !flagDefs.declared(token) {
+			rest := raw[i:]
+			if token == "--" {
+				rest = raw[i+1:]
+			}
+			argValues[spec.Args[posIndex].Name] = append([]string(nil), rest...)
+			break
+		}
+		if token == "--" && !endOfFlags {
+			// Everything after -- is positional, e.g. a value starting with a dash.
+			endOfFlags = true
+			i++
+			continue
+		}
+		if strings.HasPrefix(token, "--") && !endOfFlags {
 			name := strings.TrimPrefix(token, "--")
-			value, consumed, err := consumeFlagValue(name, raw, i, flagDefs)
+			if idx := strings.Index(name, "="); idx >= 0 {
+				name = name[:idx]
+			}
+			value, consumed, err := p.consumeFlagValue(name, raw, i, flagDefs, flagFiles)
 			if err != nil {
 				return ValueSet{}, ValueSet{}, err
 			}
@@ -204,13 +419,13 @@ func (p *ArgsParser) Parse(raw []string, spec CommandSpec) (ValueSet, ValueSet,
 			i++
 			continue
 		}
-		if strings.HasPrefix(token, "-") && token != "-" {
+		if strings.HasPrefix(token, "-") && token != "-" && !endOfFlags && (flagDefs.declared(token) || !dashValue(token, nextArg(spec, posIndex, repeatableArg))) {
 			alias := strings.TrimPrefix(token, "-")
-			name, ok := resolveShorthand(alias, spec.Flags)
+			name, ok := flagDefs.resolveShorthand(alias)
 			if !ok {
-				return ValueSet{}, ValueSet{}, fmt.Errorf("unknown flag: -%s", alias)
+				return ValueSet{}, ValueSet{}, &ParseError{Err: fmt.Errorf("unknown flag: -%s", alias)}
 			}
-			value, consumed, err := consumeFlagValue(name, raw, i, flagDefs)
+			value, consumed, err := p.consumeFlagValue(name, raw, i, flagDefs, flagFiles)
 			if err != nil {
 				return ValueSet{}, ValueSet{}, err
 			}
@@ -236,7 +451,7 @@ func (p *ArgsParser) Parse(raw []string, spec CommandSpec) (ValueSet, ValueSet,
 				i++
 				continue
 			}
-			return ValueSet{}, ValueSet{}, fmt.Errorf("unexpected argument: %s", token)
+			return ValueSet{}, ValueSet{}, &ParseError{Err: fmt.Errorf("unexpected argument: %s", token)}
 		}
 
 		arg := spec.Args[posIndex]
@@ -245,12 +460,23 @@ func (p *ArgsParser) Parse(raw []string, spec CommandSpec) (ValueSet, ValueSet,
 			repeatValues = append(repeatValues, token)
 			argValues[arg.Name] = repeatValues
 		} else {
-			argValues[arg.Name] = token
+			text, err := p.readFileRef(token, arg.FromFile, arg.Name, argFiles)
+			var value any
+			if err == nil {
+				value, err = castArgValue(arg, text)
+			}
+			if err != nil {
+				return ValueSet{}, ValueSet{}, &ParseError{Err: fmt.Errorf("invalid value for %s: %w", arg.Name, err), Arg: arg.Name}
+			}
+			argValues[arg.Name] = value
 			posIndex++
 		}
 		i++
 	}
 
+	if err := checkFlagGroups(spec.FlagGroups, flagValues); err != nil {
+		return ValueSet{}, ValueSet{}, err
+	}
 	if err := applyDefaultsAndValidate(argValues, spec.Args); err != nil {
 		return ValueSet{}, ValueSet{}, err
 	}
@@ -258,42 +484,25 @@ func (p *ArgsParser) Parse(raw []string, spec CommandSpec) (ValueSet, ValueSet,
 		return ValueSet{}, ValueSet{}, err
 	}
 
-	return newValueSet(argValues), newValueSet(flagValues), nil
-}
-
-func buildFlagIndex(flags []FlagSpec) map[string]FlagSpec {
-	index := make(map[string]FlagSpec, len(flags))
-	for _, flag := range flags {
-		index[flag.Name] = flag
-	}
-	return index
-}
-
-func resolveShorthand(alias string, flags []FlagSpec) (string, bool) {
-	for _, flag := range flags {
-		if flag.Shorthand == alias {
-			return flag.Name, true
-		}
-	}
-	return "", false
+	return ValueSet{values: argValues, files: argFiles}, ValueSet{values: flagValues, files: flagFiles}, nil
 }
 
-func consumeFlagValue(name string, raw []string, pos int, flags map[string]FlagSpec) (any, int, error) {
-	flag, ok := flags[name]
+func (p *ArgsParser) consumeFlagValue(name string, raw []string, pos int, flags flagIndex, files map[string][]byte) (any, int, error) {
+	flag, ok := flags.lookup(name)
 	if !ok {
-		return nil, 0, fmt.Errorf("unknown flag: --%s", name)
+		return nil, 0, &ParseError{Err: fmt.Errorf("unknown flag: --%s", name)}
 	}
 
 	if strings.Contains(name, "=") {
-		return nil, 0, fmt.Errorf("invalid flag name: %s", name)
+		return nil, 0, &ParseError{Err: fmt.Errorf("invalid flag name: %s", name), Flag: name}
 	}
 
 	token := raw[pos]
 	if strings.Contains(token, "=") {
 		parts := strings.SplitN(token, "=", 2)
-		value, err := castValue(flag.Type, parts[1], flag.EnumValues)
+		value, err := p.castFlagValue(flag, parts[1], files)
 		if err != nil {
-			return nil, 0, err
+			return nil, 0, &ParseError{Err: fmt.Errorf("invalid value for --%s: %w", name, err), Flag: name}
 		}
 		return value, 1, nil
 	}
@@ -303,20 +512,158 @@ func consumeFlagValue(name string, raw []string, pos int, flags map[string]FlagS
 	}
 
 	if pos+1 >= len(raw) {
-		return nil, 0, fmt.Errorf("flag --%s requires a value", name)
+		return nil, 0, &ParseError{Err: fmt.Errorf("flag --%s requires a value", name), Flag: name}
 	}
 
 	value := raw[pos+1]
-	casted, err := castValue(flag.Type, value, flag.EnumValues)
+	casted, err := p.castFlagValue(flag, value, files)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, &ParseError{Err: fmt.Errorf("invalid value for --%s: %w", name, err), Flag: name}
 	}
 	return casted, 2, nil
 }
 
+// checkFlagGroups enforces groups against the flags given on the command
+// line, before defaults apply.
+func checkFlagGroups(groups []FlagGroup, given map[string]any) error {
+	for _, g := range groups {
+		var set []string
+		for _, name := range g.Flags {
+			if _, ok := given[name]; ok {
+				set = append(set, "--"+name)
+			}
+		}
+		var err error
+		switch g.Kind {
+		case FlagGroupExactlyOne:
+			if len(set) == 0 {
+				err = fmt.Errorf("%s is required", g)
+				break
+			}
+			fallthrough
+		case FlagGroupAtMostOne:
+			if len(set) > 1 {
+				err = fmt.Errorf("%s cannot be used together", strings.Join(set, " and "))
+			}
+		case FlagGroupRequires:
+			if len(g.Flags) == 0 {
+				break
+			}
+			if _, ok := given[g.Flags[0]]; !ok {
+				break
+			}
+			for _, name := range g.Flags[1:] {
+				if _, ok := given[name]; !ok {
+					err = fmt.Errorf("--%s requires --%s", g.Flags[0], name)
+					break
+				}
+			}
+		}
+		if err != nil {
+			flag := ""
+			if len(g.Flags) > 0 {
+				flag = g.Flags[0]
+			}
+			return &ParseError{Err: err, Flag: flag}
+		}
+	}
+	return nil
+}
+
+// castArgValue validates JSON positional arguments that declare a Schema or DecodeAs,
+// and converts network and time ones. Other positional values are kept as raw strings.
+func castArgValue(arg ArgSpec, raw string) (any, error) {
+	switch arg.Type {
+	case ArgTypeIP, ArgTypeCIDR, ArgTypeMAC, ArgTypePort, ArgTypeHostPort, ArgTypeTime:
+		return castValue(arg.Type, raw, nil)
+	}
+	if arg.Type != ArgTypeJSON || (arg.Schema == nil && arg.DecodeAs == nil) {
+		return raw, nil
+	}
+	value, err := decodeJSONValue(raw, arg.Schema, arg.DecodeAs)
+	if err != nil || arg.DecodeAs != nil {
+		return value, err
+	}
+	return raw, nil
+}
+
+// nextArg returns the arg the next positional value fills, or nil when none
+// is left.
+func nextArg(spec CommandSpec, posIndex int, repeatableArg *ArgSpec) *ArgSpec {
+	if posIndex < len(spec.Args) {
+		return &spec.Args[posIndex]
+	}
+	return repeatableArg
+}
+
+// dashValue reports whether token, which starts with "-" but names no flag, is
+// a value for arg instead: a negative offset such as -2h, or a value arg's
+// numeric or time type accepts, such as -5. Other values starting with a dash
+// follow --.
+func dashValue(token string, arg *ArgSpec) bool {
+	if arg == nil {
+		return false
+	}
+	if _, err := parseOffset(strings.ToLower(token)); err == nil {
+		return true
+	}
+	switch arg.Type {
+	case ArgTypeInt, ArgTypeFloat, ArgTypeDuration, ArgTypeTime:
+		_, err := castValue(arg.Type, token, nil)
+		return err == nil
+	}
+	return false
+}
+
+// castFlagValue is the package castFlagValue after reading an @path or @- value the flag accepts.
+func (p *ArgsParser) castFlagValue(flag FlagSpec, raw string, files map[string][]byte) (any, error) {
+	text, err := p.readFileRef(raw, flag.FromFile, flag.Name, files)
+	if err != nil {
+		return nil, err
+	}
+	return castFlagValue(flag, text)
+}
+
+// readFileRef returns the contents of the file an @path value names, or of
+// stdin for @-, recording them in files under name. Other values, and any
+// value when allowed is false, are returned as is. @- is refused when stdin is
+// a terminal, where the line editor reads it.
+func (p *ArgsParser) readFileRef(raw string, allowed bool, name string, files map[string][]byte) (string, error) {
+	path, ok := strings.CutPrefix(raw, "@")
+	if !allowed || !ok || path == "" {
+		return raw, nil
+	}
+	var data []byte
+	var err error
+	if path == "-" {
+		stdin := p.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		if f, ok := stdin.(*os.File); ok && readline.IsTerminal(int(f.Fd())) {
+			return "", errors.New("@- reads piped input, but stdin is a terminal")
+		}
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	files[name] = data
+	return string(data), nil
+}
+
+func castFlagValue(flag FlagSpec, raw string) (any, error) {
+	if flag.Type == ArgTypeJSON {
+		return decodeJSONValue(raw, flag.Schema, flag.DecodeAs)
+	}
+	return castValue(flag.Type, raw, flag.EnumValues)
+}
+
 func castValue(kind ArgType, raw string, enum []string) (any, error) {
 	switch kind {
-	case ArgTypeString, "":
+	case ArgTypeString, ArgTypeSecret, "":
 		return raw, nil
 	case ArgTypeInt:
 		i, err := strconv.Atoi(raw)
@@ -353,26 +700,128 @@ func castValue(kind ArgType, raw string, enum []string) (any, error) {
 		}
 		return nil, fmt.Errorf("value %q not in enum", raw)
 	case ArgTypeJSON:
-		if !json.Valid([]byte(raw)) {
-			return nil, fmt.Errorf("invalid json for value %q", raw)
+		return decodeJSONValue(raw, nil, nil)
+	case ArgTypeIP:
+		ip := net.ParseIP(raw)
+		if ip == nil {
+			return nil, fmt.Errorf("%q is not an IP address", raw)
+		}
+		return ip, nil
+	case ArgTypeCIDR:
+		_, n, err := net.ParseCIDR(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a CIDR prefix", raw)
+		}
+		return n, nil
+	case ArgTypeMAC:
+		mac, err := net.ParseMAC(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a MAC address", raw)
 		}
-		var v any
-		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return mac, nil
+	case ArgTypePort:
+		return parsePort(raw)
+	case ArgTypeHostPort:
+		host, port, err := net.SplitHostPort(raw)
+		if err != nil || host == "" {
+			return nil, fmt.Errorf("%q is not host:port", raw)
+		}
+		if _, err := parsePort(port); err != nil {
 			return nil, err
 		}
-		return v, nil
+		return raw, nil
+	case ArgTypeTime:
+		return parseTime(raw, time.Now())
 	default:
 		return raw, nil
 	}
 }
 
+func parsePort(raw string) (int, error) {
+	port, err := strconv.Atoi(raw)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%q is not a port number (1-65535)", raw)
+	}
+	return port, nil
+}
+
+// timeLayouts are the absolute forms parseTime accepts besides Unix seconds.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// parseTime reads an ArgTypeTime value relative to now. Dates without a zone
+// are local; relative offsets take the time.ParseDuration units plus d and w.
+func parseTime(raw string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch s {
+	case "now":
+		return now, nil
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		if d, err := parseOffset(s); err == nil {
+			return now.Add(d), nil
+		}
+	}
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(raw), now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time (use RFC 3339, YYYY-MM-DD, Unix seconds, -2h, or yesterday)", raw)
+}
+
+// parseOffset parses a signed duration that may use d (days) and w (weeks),
+// e.g. -1w2d or +90m.
+func parseOffset(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	if s[0] == '-' {
+		sign = -1
+	}
+	s = s[1:]
+	if s == "" {
+		return 0, errors.New("empty offset")
+	}
+	var total time.Duration
+	for s != "" {
+		i := strings.IndexAny(s, "dw")
+		if i < 0 {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return 0, err
+			}
+			total += d
+			break
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset %q", s)
+		}
+		unit := 24 * time.Hour
+		if s[i] == 'w' {
+			unit *= 7
+		}
+		total += time.Duration(n) * unit
+		s = s[i+1:]
+	}
+	return sign * total, nil
+}
+
 func applyDefaultsAndValidate(target map[string]any, specs any) error {
 	switch list := specs.(type) {
 	case []ArgSpec:
 		for _, arg := range list {
 			if _, ok := target[arg.Name]; !ok {
 				if arg.Required && arg.Default == nil && !arg.Repeatable {
-					return fmt.Errorf("missing required argument: %s", arg.Name)
+					return &ParseError{Err: fmt.Errorf("%w: %s", ErrMissingArgument, arg.Name), Arg: arg.Name}
 				}
 				if arg.Default != nil {
 					target[arg.Name] = arg.Default
@@ -386,7 +835,7 @@ func applyDefaultsAndValidate(target map[string]any, specs any) error {
 			}
 			if _, ok := target[flag.Name]; !ok {
 				if flag.Required && flag.Default == nil && flag.Type != ArgTypeBool {
-					return fmt.Errorf("missing required flag: --%s", flag.Name)
+					return &ParseError{Err: fmt.Errorf("missing required flag: --%s", flag.Name), Flag: flag.Name}
 				}
 				if flag.Default != nil {
 					target[flag.Name] = flag.Default
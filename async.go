@@ -2,7 +2,9 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
@@ -19,12 +21,28 @@ const (
 	TaskSucceeded TaskStatus = "succeeded"
 	TaskFailed    TaskStatus = "failed"
 	TaskCancelled TaskStatus = "cancelled"
+	// TaskPaused marks a periodic task whose runs are suspended.
+	TaskPaused TaskStatus = "paused"
 )
 
 // TaskOptions configure async tasks.
 type TaskOptions struct {
+	// Timeout bounds each attempt.
 	Timeout  time.Duration
 	Metadata map[string]any
+	// MaxRetries re-runs a failed task up to this many more times. Cancelled
+	// tasks are never retried.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry; it doubles after each one.
+	RetryBackoff time.Duration
+	// Delay postpones the first attempt. At schedules it for a wall-clock time;
+	// when both are set the later one applies.
+	Delay time.Duration
+	At    time.Time
+	// Silent captures the task's output without echoing it to the session.
+	Silent bool
+	// NoNotify suppresses the engine's completion notification for the task.
+	NoNotify bool
 }
 
 // TaskHandle represents a running task.
@@ -33,82 +51,420 @@ type TaskHandle struct {
 	Name     string
 	Status   TaskStatus
 	Error    error
-	Metadata map[string]any
+	Progress TaskProgress
+	// Attempts counts the runs started so far; NextRun is when the next one is
+	// due while the task waits for its schedule or a retry.
+	Attempts int
+	NextRun  time.Time
+	// Group is the ID of the TaskGroup the task belongs to, if any; DependsOn
+	// lists the tasks it waits for.
+	Group     string
+	DependsOn []string
+	// Interval is the time between the runs of a periodic task.
+	Interval time.Duration
+	// Owner names the child runtime that spawned the task, e.g. "deploy/web",
+	// and InvocationID the command invocation.
+	Owner        string
+	InvocationID string
+	Metadata     map[string]any
+	// Output is the task's own channel; see TaskManager.Output for what it captured.
+	Output   OutputChannel
 	cancel   context.CancelFunc
+	capture  *ringBuffer
+	noNotify bool
+	seq      int
+	control  *periodicControl
+}
+
+// TaskProgress is the latest progress a task reported. Total is zero when unknown.
+type TaskProgress struct {
+	Current int
+	Total   int
+	Message string
+}
+
+// TaskEvent records a status change or progress update of a task.
+type TaskEvent struct {
+	Time     time.Time
+	Status   TaskStatus
+	Progress TaskProgress
+	Error    error
 }
 
+// maxTaskEvents bounds the events kept per task.
+const maxTaskEvents = 256
+
 // TaskManager supervises background tasks.
 type TaskManager struct {
-	mu     sync.RWMutex
-	seq    int
-	tasks  map[string]*TaskHandle
-	output OutputChannel
+	*taskState
+	// owner and invocation are recorded on tasks spawned through this manager;
+	// see CommandRuntime.Child and InvocationMeta.
+	owner      string
+	invocation string
+}
+
+// taskState is shared by a session's TaskManager and its owner-scoped views.
+type taskState struct {
+	mu       sync.RWMutex
+	seq      int
+	groupSeq int
+	tasks    map[string]*TaskHandle
+	groups   map[string]*TaskGroup
+	events   map[string][]TaskEvent
+	watchers map[string][]chan TaskEvent
+	hooks    []func(*TaskHandle)
+	output   OutputChannel
+	// slots holds one token per running attempt when concurrency is bounded.
+	slots chan struct{}
+	// outputSize and outputTotal bound captured output; see WithTaskOutputLimit.
+	outputSize  int
+	outputTotal int64
+}
+
+// WithMaxConcurrentTasks bounds how many background tasks each session runs at
+// once; see WithMaxConcurrent.
+func WithMaxConcurrentTasks(n int) Option {
+	return func(e *Engine) { e.maxTasks = n }
+}
+
+// TaskManagerOption configures a TaskManager.
+type TaskManagerOption func(*TaskManager)
+
+// WithMaxConcurrent bounds how many tasks run at once. Tasks spawned beyond
+// the limit stay TaskPending until a running one finishes. n <= 0 means no limit.
+func WithMaxConcurrent(n int) TaskManagerOption {
+	return func(m *TaskManager) {
+		m.slots = nil
+		if n > 0 {
+			m.slots = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithTaskOutputLimit sets how many bytes of output are captured per task
+// (DefaultTaskOutputSize when perTask <= 0) and, when total > 0, across all
+// tasks, dropping the output of the oldest finished tasks first.
+func WithTaskOutputLimit(perTask int, total int64) TaskManagerOption {
+	return func(m *TaskManager) {
+		m.outputSize, m.outputTotal = perTask, total
+	}
 }
 
 // NewTaskManager constructs a TaskManager.
-func NewTaskManager(output OutputChannel) *TaskManager {
-	return &TaskManager{tasks: map[string]*TaskHandle{}, output: output}
+func NewTaskManager(output OutputChannel, opts ...TaskManagerOption) *TaskManager {
+	m := &TaskManager{taskState: &taskState{
+		tasks:    map[string]*TaskHandle{},
+		groups:   map[string]*TaskGroup{},
+		events:   map[string][]TaskEvent{},
+		watchers: map[string][]chan TaskEvent{},
+		output:   output,
+	}}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// scoped returns a view of m attributing the tasks it spawns to owner and the
+// invocation.
+func (m *TaskManager) scoped(owner, invocation string) *TaskManager {
+	return &TaskManager{taskState: m.taskState, owner: owner, invocation: invocation}
+}
+
+// ProgressFunc reports task progress; total is zero when unknown.
+type ProgressFunc func(current, total int, message string)
+
+type progressKey struct{}
+
+// ReportProgress returns the progress callback of the task whose TaskFunc
+// received ctx. Outside a task it returns a no-op.
+func ReportProgress(ctx context.Context) ProgressFunc {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		return fn
+	}
+	return func(int, int, string) {}
 }
 
 // Spawn launches an async task.
 func (m *TaskManager) Spawn(name string, fn TaskFunc, opts TaskOptions) *TaskHandle {
+	return m.spawn(name, fn, opts, nil)
+}
+
+// taskGate holds a group member back until its dependencies finish.
+type taskGate struct {
+	group     string
+	dependsOn []string
+	// wait blocks until the task may start, failing if it must not run.
+	wait func(ctx context.Context) error
+	// done receives the task's final status.
+	done func(TaskStatus)
+}
+
+func (m *TaskManager) spawn(name string, fn TaskFunc, opts TaskOptions, gate *taskGate) *TaskHandle {
+	handle, ctx, flush := m.register(name, opts, gate)
+	id, output := handle.ID, handle.Output
+
+	go func() {
+		finish := func(status TaskStatus, err error) {
+			flush()
+			m.updateStatus(id, status, err)
+			if gate != nil {
+				gate.done(status)
+			}
+		}
+		if gate != nil {
+			if err := gate.wait(ctx); err != nil {
+				finish(TaskCancelled, err)
+				return
+			}
+		}
+		wait := firstRunDelay(opts)
+		backoff := opts.RetryBackoff
+		for attempt := 0; ; attempt++ {
+			if wait > 0 {
+				m.schedule(id, time.Now().Add(wait))
+				select {
+				case <-ctx.Done():
+					finish(TaskCancelled, context.Canceled)
+					return
+				case <-time.After(wait):
+				}
+			}
+			err := m.runAttempt(ctx, id, fn, output, opts.Timeout)
+			switch {
+			case err == nil:
+				finish(TaskSucceeded, nil)
+				return
+			case errors.Is(err, context.Canceled) || ctx.Err() != nil:
+				finish(TaskCancelled, err)
+				return
+			case attempt >= opts.MaxRetries:
+				finish(TaskFailed, err)
+				return
+			}
+			m.updateStatus(id, TaskPending, err)
+			wait = backoff
+			backoff *= 2
+		}
+	}()
+
+	return handle
+}
+
+// firstRunDelay is how long a task waits for opts.Delay and opts.At.
+func firstRunDelay(opts TaskOptions) time.Duration {
+	wait := opts.Delay
+	if until := time.Until(opts.At); until > wait {
+		wait = until
+	}
+	return wait
+}
+
+// register records a new pending task, returning it with the context its
+// runs receive and the func flushing its echoed output.
+func (m *TaskManager) register(name string, opts TaskOptions, gate *taskGate) (*TaskHandle, context.Context, func()) {
 	m.mu.Lock()
 	m.seq++
 	id := fmt.Sprintf("task-%d", m.seq)
 	ctx, cancel := context.WithCancel(context.Background())
-	if opts.Timeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+	size := m.outputSize
+	if size <= 0 {
+		size = DefaultTaskOutputSize
 	}
+	capture := newRingBuffer(size)
+	output, flush := newTaskOutput(id, m.output, capture, opts.Silent)
 	handle := &TaskHandle{
-		ID:       id,
-		Name:     name,
-		Status:   TaskPending,
-		Metadata: opts.Metadata,
-		cancel:   cancel,
+		ID:           id,
+		Name:         name,
+		Status:       TaskPending,
+		Metadata:     opts.Metadata,
+		Output:       output,
+		cancel:       cancel,
+		capture:      capture,
+		noNotify:     opts.NoNotify,
+		seq:          m.seq,
+		Owner:        m.owner,
+		InvocationID: m.invocation,
+	}
+	if gate != nil {
+		handle.Group = gate.group
+		handle.DependsOn = gate.dependsOn
 	}
 	m.tasks[id] = handle
 	m.mu.Unlock()
+	if m.invocation != "" {
+		ctx = withInvocationID(ctx, m.invocation)
+	}
+	ctx = context.WithValue(ctx, progressKey{}, ProgressFunc(func(current, total int, message string) {
+		m.updateProgress(id, TaskProgress{Current: current, Total: total, Message: message})
+	}))
+	return handle, ctx, flush
+}
 
-	go func() {
-		m.updateStatus(id, TaskRunning, nil)
-		err := fn(ctx, m.output)
-		switch {
-		case err == context.Canceled:
-			m.updateStatus(id, TaskCancelled, err)
-		case err == nil:
-			m.updateStatus(id, TaskSucceeded, nil)
-		default:
-			m.updateStatus(id, TaskFailed, err)
+func (m *TaskManager) runAttempt(ctx context.Context, id string, fn TaskFunc, output OutputChannel, timeout time.Duration) error {
+	if m.slots != nil {
+		// Queued attempts wait here, still pending, for a free worker.
+		select {
+		case m.slots <- struct{}{}:
+			defer func() { <-m.slots }()
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-	}()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	m.mu.Lock()
+	if handle, ok := m.tasks[id]; ok {
+		handle.Attempts++
+		handle.NextRun = time.Time{}
+	}
+	m.mu.Unlock()
+	m.updateStatus(id, TaskRunning, nil)
+	return fn(ctx, output)
+}
 
-	return handle
+// schedule records when a waiting task will next run.
+func (m *TaskManager) schedule(id string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if handle, ok := m.tasks[id]; ok {
+		handle.NextRun = at
+	}
 }
 
 func (m *TaskManager) updateStatus(id string, status TaskStatus, err error) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	handle, ok := m.tasks[id]
 	if !ok {
+		m.mu.Unlock()
 		return
 	}
 	handle.Status = status
 	handle.Error = err
+	m.publish(id, TaskEvent{Time: time.Now(), Status: status, Progress: handle.Progress, Error: err})
+	if !taskFinished(status) {
+		m.mu.Unlock()
+		return
+	}
+	for _, ch := range m.watchers[id] {
+		close(ch)
+	}
+	delete(m.watchers, id)
+	m.trimOutputLocked()
+	hooks := m.hooks
+	done := *handle
+	m.mu.Unlock()
+	for _, fn := range hooks {
+		fn(&done)
+	}
 }
 
-// Cancel cancels a task by ID.
-func (m *TaskManager) Cancel(id string) bool {
+// OnComplete registers fn to be called with a snapshot of each task that
+// succeeds, fails, or is cancelled. It runs on the task's goroutine.
+func (m *TaskManager) OnComplete(fn func(*TaskHandle)) {
+	if fn == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, fn)
+}
+
+func (m *TaskManager) updateProgress(id string, progress TaskProgress) {
 	m.mu.Lock()
+	defer m.mu.Unlock()
+	handle, ok := m.tasks[id]
+	if !ok || taskFinished(handle.Status) {
+		return
+	}
+	handle.Progress = progress
+	m.publish(id, TaskEvent{Time: time.Now(), Status: handle.Status, Progress: progress})
+}
+
+// publish must be called with m.mu held. Slow watchers miss events rather
+// than blocking the task.
+func (m *TaskManager) publish(id string, ev TaskEvent) {
+	events := append(m.events[id], ev)
+	if len(events) > maxTaskEvents {
+		events = events[len(events)-maxTaskEvents:]
+	}
+	m.events[id] = events
+	for _, ch := range m.watchers[id] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func taskFinished(status TaskStatus) bool {
+	return status == TaskSucceeded || status == TaskFailed || status == TaskCancelled
+}
+
+// Events returns the recorded events of a task, oldest first.
+func (m *TaskManager) Events(id string) []TaskEvent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]TaskEvent(nil), m.events[id]...)
+}
+
+// Follow returns the events recorded so far and a channel receiving later
+// ones. The channel is closed when the task finishes; call stop to unsubscribe
+// earlier. ok is false for unknown tasks.
+func (m *TaskManager) Follow(id string) (past []TaskEvent, events <-chan TaskEvent, stop func(), ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	handle, ok := m.tasks[id]
-	m.mu.Unlock()
 	if !ok {
-		return false
+		return nil, nil, nil, false
+	}
+	past = append([]TaskEvent(nil), m.events[id]...)
+	ch := make(chan TaskEvent, 64)
+	if taskFinished(handle.Status) {
+		close(ch)
+		return past, ch, func() {}, true
 	}
-	handle.cancel()
-	return true
+	m.watchers[id] = append(m.watchers[id], ch)
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			watchers := m.watchers[id]
+			for i, w := range watchers {
+				if w == ch {
+					m.watchers[id] = append(watchers[:i], watchers[i+1:]...)
+					close(ch)
+					break
+				}
+			}
+		})
+	}
+	return past, ch, stop, true
+}
+
+// Cancel cancels a task, or every task of a group, by ID.
+func (m *TaskManager) Cancel(id string) bool {
+	m.mu.Lock()
+	var cancels []context.CancelFunc
+	if handle, ok := m.tasks[id]; ok {
+		cancels = append(cancels, handle.cancel)
+	} else if group, ok := m.groups[id]; ok {
+		for _, tid := range group.Tasks {
+			cancels = append(cancels, m.tasks[tid].cancel)
+		}
+	}
+	m.mu.Unlock()
+	for _, cancel := range cancels {
+		cancel()
+	}
+	return len(cancels) > 0
 }
 
-// Tasks lists tasks.
+// Tasks lists tasks in the order they were spawned.
 func (m *TaskManager) Tasks() []*TaskHandle {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -117,6 +473,7 @@ func (m *TaskManager) Tasks() []*TaskHandle {
 		copy := *t
 		list = append(list, &copy)
 	}
+	sort.Slice(list, func(i, j int) bool { return list[i].seq < list[j].seq })
 	return list
 }
 
@@ -132,6 +489,54 @@ func (m *TaskManager) DescribeTask(id string) (*TaskHandle, bool) {
 	return &copy, true
 }
 
+// Output returns the output captured for a task, up to its most recent
+// DefaultTaskOutputSize bytes unless WithTaskOutputLimit says otherwise.
+func (m *TaskManager) Output(id string) (string, bool) {
+	m.mu.RLock()
+	h, ok := m.tasks[id]
+	m.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	return h.capture.String(), true
+}
+
+// OutputSize returns how many bytes of task output are captured.
+func (m *TaskManager) OutputSize() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var total int64
+	for _, t := range m.tasks {
+		total += int64(t.capture.Len())
+	}
+	return total
+}
+
+// trimOutputLocked drops the output of the oldest finished tasks while the
+// captured total exceeds outputTotal.
+func (m *TaskManager) trimOutputLocked() {
+	if m.outputTotal <= 0 {
+		return
+	}
+	list := make([]*TaskHandle, 0, len(m.tasks))
+	var total int64
+	for _, t := range m.tasks {
+		list = append(list, t)
+		total += int64(t.capture.Len())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].seq < list[j].seq })
+	for _, t := range list {
+		if total <= m.outputTotal {
+			return
+		}
+		if !taskFinished(t.Status) {
+			continue
+		}
+		total -= int64(t.capture.Len())
+		t.capture.Reset()
+	}
+}
+
 // SetOutputChannel updates the output destination for future task logs.
 func (m *TaskManager) SetOutputChannel(out OutputChannel) {
 	if out == nil {
@@ -0,0 +1,348 @@
+package tui
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionKeyPrincipal is the session store key holding the authenticated Principal.
+const SessionKeyPrincipal = "auth.principal"
+
+// ErrInvalidCredentials is returned by authenticators when credentials are rejected.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal identifies an authenticated operator.
+type Principal struct {
+	Name            string
+	Roles           []string
+	Attributes      map[string]string
+	Method          string
+	AuthenticatedAt time.Time
+}
+
+// HasRole reports whether the principal carries role.
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Credentials carries the material presented to an Authenticator.
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// Authenticator validates credentials and returns the resulting principal.
+// Implementations may back onto static users, PAM, OIDC, or any other store.
+type Authenticator interface {
+	Authenticate(ctx context.Context, creds Credentials) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function into an Authenticator.
+type AuthenticatorFunc func(ctx context.Context, creds Credentials) (*Principal, error)
+
+// Authenticate implements Authenticator.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	return f(ctx, creds)
+}
+
+// StaticUser describes a user known to StaticAuthenticator.
+type StaticUser struct {
+	Password string
+	Roles    []string
+}
+
+// StaticAuthenticator authenticates against an in-memory user table.
+type StaticAuthenticator struct {
+	mu    sync.RWMutex
+	users map[string]StaticUser
+}
+
+// NewStaticAuthenticator constructs a StaticAuthenticator.
+func NewStaticAuthenticator(users map[string]StaticUser) *StaticAuthenticator {
+	a := &StaticAuthenticator{users: map[string]StaticUser{}}
+	for name, user := range users {
+		a.users[name] = user
+	}
+	return a
+}
+
+// AddUser registers or replaces a user.
+func (a *StaticAuthenticator) AddUser(name string, user StaticUser) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.users[name] = user
+}
+
+// Authenticate implements Authenticator.
+func (a *StaticAuthenticator) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	a.mu.RLock()
+	user, ok := a.users[creds.Username]
+	a.mu.RUnlock()
+	if !ok || subtle.ConstantTimeCompare([]byte(user.Password), []byte(creds.Password)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{
+		Name:   creds.Username,
+		Roles:  append([]string(nil), user.Roles...),
+		Method: "static",
+	}, nil
+}
+
+// TokenVerifier validates a bearer token (for example an OIDC ID token) and returns its principal.
+type TokenVerifier func(ctx context.Context, token string) (*Principal, error)
+
+// TokenAuthenticator authenticates bearer tokens through a verifier.
+type TokenAuthenticator struct {
+	verify TokenVerifier
+}
+
+// NewTokenAuthenticator constructs a TokenAuthenticator.
+func NewTokenAuthenticator(verify TokenVerifier) *TokenAuthenticator {
+	return &TokenAuthenticator{verify: verify}
+}
+
+// Authenticate implements Authenticator.
+func (a *TokenAuthenticator) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	if creds.Token == "" || a.verify == nil {
+		return nil, ErrInvalidCredentials
+	}
+	p, err := a.verify(ctx, creds.Token)
+	if err != nil {
+		return nil, err
+	}
+	if p.Method == "" {
+		p.Method = "token"
+	}
+	return p, nil
+}
+
+// AuthEventType enumerates authentication events.
+type AuthEventType string
+
+const (
+	AuthLogin       AuthEventType = "login"
+	AuthLoginFailed AuthEventType = "login_failed"
+	AuthLogout      AuthEventType = "logout"
+)
+
+// AuthEvent describes an authentication state change, for audit or RBAC consumers.
+type AuthEvent struct {
+	Type      AuthEventType
+	Username  string
+	Principal *Principal
+	Err       error
+	Time      time.Time
+}
+
+// AuthListener observes authentication events.
+type AuthListener func(AuthEvent)
+
+// WithAuthenticator configures the authenticator used by the login built-in.
+func WithAuthenticator(a Authenticator) Option {
+	return func(e *Engine) { e.authenticator = a }
+}
+
+// WithAuthListener registers a listener for authentication events.
+func WithAuthListener(fn AuthListener) Option {
+	return func(e *Engine) {
+		if fn != nil {
+			e.authListeners = append(e.authListeners, fn)
+		}
+	}
+}
+
+// PrincipalFromSession returns the authenticated principal stored in a session.
+func PrincipalFromSession(store SessionStore) (*Principal, bool) {
+	if store == nil {
+		return nil, false
+	}
+	v, ok := store.Get(SessionKeyPrincipal)
+	if !ok {
+		return nil, false
+	}
+	p, ok := v.(*Principal)
+	return p, ok && p != nil
+}
+
+func (e *Engine) emitAuthEvent(evt AuthEvent) {
+	if evt.Time.IsZero() {
+		evt.Time = time.Now()
+	}
+	e.mu.RLock()
+	listeners := append([]AuthListener(nil), e.authListeners...)
+	e.mu.RUnlock()
+	for _, fn := range listeners {
+		fn(evt)
+	}
+}
+
+// login command ---------------------------------------------------------------
+
+type loginCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *loginCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:    "login",
+			Summary: "Authenticate the current session",
+			Context: "",
+			Args: []ArgSpec{
+				{Name: "user", Type: ArgTypeString, Description: "Username"},
+			},
+			Flags: []FlagSpec{
+				{Name: "password", Shorthand: "p", Type: ArgTypeSecret, Description: "Password"},
+				{Name: "token", Shorthand: "t", Type: ArgTypeSecret, Description: "Bearer token (e.g. OIDC)"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *loginCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &loginCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type loginCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *loginCommand) Spec() CommandSpec { return c.spec }
+
+func (c *loginCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	if c.engine.authenticator == nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "authentication is not configured", Severity: SeverityError}}
+	}
+	creds := Credentials{
+		Username: strings.TrimSpace(input.Args.String("user")),
+		Password: input.Flags.String("password"),
+		Token:    input.Flags.String("token"),
+	}
+	if creds.Password == "" && creds.Token == "" {
+		password, err := rt.PromptSecret("Password")
+		if err != nil {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: fmt.Sprintf("login: reading password: %v", err), Severity: SeverityError, Hints: []string{"pass --password or --token when no terminal is attached"}}}
+		}
+		creds.Password = password
+	}
+	principal, err := c.engine.authenticator.Authenticate(rt.Cancellation(), creds)
+	if err != nil || principal == nil {
+		if err == nil {
+			err = ErrInvalidCredentials
+		}
+		c.engine.emitAuthEvent(AuthEvent{Type: AuthLoginFailed, Username: creds.Username, Err: err})
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: fmt.Sprintf("login failed: %v", err), Severity: SeverityError}}
+	}
+	if principal.Name == "" {
+		principal.Name = creds.Username
+	}
+	if principal.AuthenticatedAt.IsZero() {
+		principal.AuthenticatedAt = time.Now()
+	}
+	rt.Session().Set(SessionKeyPrincipal, principal)
+	c.engine.emitAuthEvent(AuthEvent{Type: AuthLogin, Username: principal.Name, Principal: principal})
+	rt.Output().Info(fmt.Sprintf("Logged in as %s", principal.Name))
+	return CommandResult{Status: StatusSuccess, Payload: principal}
+}
+
+// logout command --------------------------------------------------------------
+
+type logoutCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *logoutCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:    "logout",
+			Summary: "End the authenticated session",
+			Context: "",
+		}
+	}
+	return f.spec
+}
+
+func (f *logoutCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &logoutCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type logoutCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *logoutCommand) Spec() CommandSpec { return c.spec }
+
+func (c *logoutCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	principal, ok := PrincipalFromSession(rt.Session())
+	if !ok {
+		rt.Output().Info("Not logged in")
+		return CommandResult{Status: StatusSuccess}
+	}
+	rt.Session().Delete(SessionKeyPrincipal)
+	c.engine.emitAuthEvent(AuthEvent{Type: AuthLogout, Username: principal.Name, Principal: principal})
+	rt.Output().Info(fmt.Sprintf("Logged out %s", principal.Name))
+	return CommandResult{Status: StatusSuccess}
+}
+
+// whoami command --------------------------------------------------------------
+
+type whoamiCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *whoamiCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:       "whoami",
+			Summary:    "Show the authenticated principal",
+			Context:    "",
+			Concurrent: true,
+		}
+	}
+	return f.spec
+}
+
+func (f *whoamiCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &whoamiCommand{spec: f.Spec()}, nil
+}
+
+type whoamiCommand struct {
+	spec CommandSpec
+}
+
+func (c *whoamiCommand) Spec() CommandSpec { return c.spec }
+
+func (c *whoamiCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	principal, ok := PrincipalFromSession(rt.Session())
+	if !ok {
+		rt.Output().Info("anonymous")
+		return CommandResult{Status: StatusSuccess}
+	}
+	roles := "-"
+	if len(principal.Roles) > 0 {
+		roles = strings.Join(principal.Roles, ",")
+	}
+	rt.Output().WriteTable([]string{"User", "Roles", "Method", "Since"}, [][]string{{
+		principal.Name, roles, principal.Method, principal.AuthenticatedAt.Format(time.RFC3339),
+	}})
+	return CommandResult{Status: StatusSuccess, Payload: principal}
+}
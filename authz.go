@@ -0,0 +1,115 @@
+package tui
+
+import "fmt"
+
+// SessionKeyPermissions is the session store key SessionPermissions reads: a
+// []string of permissions granted to the session.
+const SessionKeyPermissions = "auth.permissions"
+
+// PermissionResolver decides whether the operator of a session holds a
+// permission named in CommandSpec.Permissions. principal is nil when the
+// session is anonymous.
+type PermissionResolver interface {
+	HasPermission(store SessionStore, principal *Principal, permission string) bool
+}
+
+// PermissionResolverFunc adapts a function, e.g. a call to a policy service,
+// into a PermissionResolver.
+type PermissionResolverFunc func(store SessionStore, principal *Principal, permission string) bool
+
+// HasPermission implements PermissionResolver.
+func (f PermissionResolverFunc) HasPermission(store SessionStore, principal *Principal, permission string) bool {
+	return f(store, principal, permission)
+}
+
+// RolePermissions grants permissions by principal role; a "*" entry grants
+// every permission.
+type RolePermissions map[string][]string
+
+// HasPermission implements PermissionResolver.
+func (r RolePermissions) HasPermission(_ SessionStore, principal *Principal, permission string) bool {
+	if principal == nil {
+		return false
+	}
+	for _, role := range principal.Roles {
+		for _, granted := range r[role] {
+			if granted == permission || granted == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SessionPermissions grants the permissions stored in the session under
+// SessionKeyPermissions, e.g. by a login hook.
+type SessionPermissions struct{}
+
+// HasPermission implements PermissionResolver.
+func (SessionPermissions) HasPermission(store SessionStore, _ *Principal, permission string) bool {
+	if store == nil {
+		return false
+	}
+	v, _ := store.Get(SessionKeyPermissions)
+	granted, _ := v.([]string)
+	for _, p := range granted {
+		if p == permission || p == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// missingPermission returns the first of spec's permissions the session lacks.
+func missingPermission(resolver PermissionResolver, store SessionStore, spec CommandSpec) (string, bool) {
+	if resolver == nil {
+		return "", false
+	}
+	principal, _ := PrincipalFromSession(store)
+	for _, perm := range spec.Permissions {
+		if !resolver.HasPermission(store, principal, perm) {
+			return perm, true
+		}
+	}
+	return "", false
+}
+
+// AuthMiddleware rejects commands whose Permissions the operator does not all
+// hold, failing with an error that wraps ErrPermissionDenied.
+func AuthMiddleware(resolver PermissionResolver) Middleware {
+	return func(rt CommandRuntime, input CommandInput, entry CommandEntry, next NextFunc) CommandResult {
+		perm, missing := missingPermission(resolver, rt.Session(), entry.Spec)
+		if !missing {
+			return next(rt, input)
+		}
+		return permissionFailure(rt, entry.Spec.Name, perm)
+	}
+}
+
+// permissionFailure is the result of running what, e.g. a command name,
+// without perm.
+func permissionFailure(rt CommandRuntime, what, perm string) CommandResult {
+	err := fmt.Errorf("%w: %s requires %s", ErrPermissionDenied, what, perm)
+	hint := fmt.Sprintf("ask an administrator for the %s permission", perm)
+	if rt.Principal() == nil {
+		hint = "log in with an account that holds " + perm
+	}
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), ErrCode: ErrCodePermissionDenied, Severity: SeverityError, Hints: []string{hint}}}
+}
+
+// WithAuthorization enforces CommandSpec.Permissions through resolver with
+// AuthMiddleware and hides commands the operator may not run from help and
+// completion.
+func WithAuthorization(resolver PermissionResolver) Option {
+	return func(e *Engine) {
+		e.permissions = resolver
+		e.middleware = append(e.middleware, AuthMiddleware(resolver))
+	}
+}
+
+// permitted reports whether the session may run spec, as far as help and
+// completion are concerned.
+func (s *Session) permitted(spec CommandSpec) bool {
+	_, missing := missingPermission(s.engine.permissions, s.store, spec)
+	return !missing
+}
@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// BenchReport summarises repeated command executions.
+type BenchReport struct {
+	Command     string
+	Runs        int
+	Failures    int
+	Min         time.Duration
+	Avg         time.Duration
+	P95         time.Duration
+	Max         time.Duration
+	AllocsPerOp uint64
+	BytesPerOp  uint64
+}
+
+// bench command ---------------------------------------------------------------
+
+type benchCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *benchCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "bench",
+			Summary:     "Run a command repeatedly and report latency",
+			Description: "Executes the command N times through the normal pipeline with output suppressed, then reports latency and allocation statistics.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "n", Type: ArgTypeInt, Required: true, Description: "Number of runs"},
+				{Name: "command", Type: ArgTypeString, Required: true, Repeatable: true, Passthrough: true, Description: "Command line to execute"},
+			},
+			Examples: []Example{{Description: "Profile a lookup", Command: "bench 50 routes show"}},
+		}
+	}
+	return f.spec
+}
+
+func (f *benchCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &benchCommand{spec: f.Spec()}, nil
+}
+
+type benchCommand struct {
+	spec CommandSpec
+}
+
+func (c *benchCommand) Spec() CommandSpec { return c.spec }
+
+func (c *benchCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	n := input.Args.Int("n")
+	if n <= 0 {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "run count must be a positive integer", Severity: SeverityError}}
+	}
+	tokens := input.Args.Strings("command")
+	if len(tokens) == 0 {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "bench <n> <command...>", Severity: SeverityError}}
+	}
+	session, ok := sessionOf(rt)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "bench requires an engine session", Severity: SeverityError}}
+	}
+	entry, args, err := session.resolveCommand(tokens)
+	if err != nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+	}
+	if entry.Spec.Name == c.spec.Name {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "bench cannot benchmark itself", Severity: SeverityError}}
+	}
+
+	report := BenchReport{Command: entry.Spec.Name, Runs: n}
+	durations := make([]time.Duration, 0, n)
+	var before, after runtime.MemStats
+	var allocs, bytes uint64
+	for i := 0; i < n; i++ {
+		if err := rt.Cancellation().Err(); err != nil {
+			report.Runs = i
+			break
+		}
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		result, err := session.invoke(entry, args, io.Discard)
+		durations = append(durations, time.Since(start))
+		runtime.ReadMemStats(&after)
+		allocs += after.Mallocs - before.Mallocs
+		bytes += after.TotalAlloc - before.TotalAlloc
+		if err != nil {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+		}
+		if result.Status == StatusFailed {
+			report.Failures++
+		}
+	}
+	if len(durations) == 0 {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "benchmark cancelled", Severity: SeverityWarning}}
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	report.Min = durations[0]
+	report.Max = durations[len(durations)-1]
+	report.Avg = total / time.Duration(len(durations))
+	report.P95 = durations[(len(durations)*95+99)/100-1]
+	report.AllocsPerOp = allocs / uint64(len(durations))
+	report.BytesPerOp = bytes / uint64(len(durations))
+
+	rt.Output().WriteTable(
+		[]string{"Command", "Runs", "Failed", "Min", "Avg", "P95", "Max", "Allocs/op", "Bytes/op"},
+		[][]string{{
+			report.Command,
+			fmt.Sprint(report.Runs),
+			fmt.Sprint(report.Failures),
+			report.Min.String(),
+			report.Avg.String(),
+			report.P95.String(),
+			report.Max.String(),
+			fmt.Sprint(report.AllocsPerOp),
+			fmt.Sprint(report.BytesPerOp),
+		}},
+	)
+	return CommandResult{Status: StatusSuccess, Payload: report}
+}
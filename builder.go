@@ -0,0 +1,142 @@
+package tui
+
+// HandlerFunc executes a command built from a spec and a function.
+type HandlerFunc func(rt CommandRuntime, input CommandInput) CommandResult
+
+// CommandBuilder assembles a command spec and handler without a hand-written
+// factory:
+//
+//	tui.RegisterCommand(tui.NewCommand("show-routes").
+//		Summary("List routes").
+//		StringFlag("vrf", "", "VRF to show").
+//		Handler(showRoutes).
+//		Build())
+type CommandBuilder struct {
+	spec    CommandSpec
+	handler HandlerFunc
+}
+
+// NewCommand starts building a command named name.
+func NewCommand(name string) *CommandBuilder {
+	return &CommandBuilder{spec: CommandSpec{Name: name}}
+}
+
+// Summary sets the one-line summary shown in help listings.
+func (b *CommandBuilder) Summary(summary string) *CommandBuilder {
+	b.spec.Summary = summary
+	return b
+}
+
+// Description sets the long help text.
+func (b *CommandBuilder) Description(description string) *CommandBuilder {
+	b.spec.Description = description
+	return b
+}
+
+// Context places the command in a context; the default is the root.
+func (b *CommandBuilder) Context(ctx string) *CommandBuilder {
+	b.spec.Context = ctx
+	return b
+}
+
+// Aliases adds alternative names.
+func (b *CommandBuilder) Aliases(aliases ...string) *CommandBuilder {
+	b.spec.Aliases = append(b.spec.Aliases, aliases...)
+	return b
+}
+
+// Category sets the help category.
+func (b *CommandBuilder) Category(category string) *CommandBuilder {
+	b.spec.Category = category
+	return b
+}
+
+// Tags adds tags.
+func (b *CommandBuilder) Tags(tags ...string) *CommandBuilder {
+	b.spec.Tags = append(b.spec.Tags, tags...)
+	return b
+}
+
+// Permissions adds permissions required to run the command.
+func (b *CommandBuilder) Permissions(perms ...string) *CommandBuilder {
+	b.spec.Permissions = append(b.spec.Permissions, perms...)
+	return b
+}
+
+// Hidden keeps the command out of help and completion.
+func (b *CommandBuilder) Hidden() *CommandBuilder {
+	b.spec.Hidden = true
+	return b
+}
+
+// Example adds an example invocation.
+func (b *CommandBuilder) Example(description, command string) *CommandBuilder {
+	b.spec.Examples = append(b.spec.Examples, Example{Description: description, Command: command})
+	return b
+}
+
+// Arg appends a positional argument.
+func (b *CommandBuilder) Arg(arg ArgSpec) *CommandBuilder {
+	b.spec.Args = append(b.spec.Args, arg)
+	return b
+}
+
+// Flag appends a flag.
+func (b *CommandBuilder) Flag(flag FlagSpec) *CommandBuilder {
+	b.spec.Flags = append(b.spec.Flags, flag)
+	return b
+}
+
+// StringFlag appends a string flag; shorthand may be empty.
+func (b *CommandBuilder) StringFlag(name, shorthand, description string) *CommandBuilder {
+	return b.Flag(FlagSpec{Name: name, Shorthand: shorthand, Type: ArgTypeString, Description: description})
+}
+
+// BoolFlag appends a boolean flag; shorthand may be empty.
+func (b *CommandBuilder) BoolFlag(name, shorthand, description string) *CommandBuilder {
+	return b.Flag(FlagSpec{Name: name, Shorthand: shorthand, Type: ArgTypeBool, Description: description})
+}
+
+// Configure applies fn to the spec for settings without a builder method.
+func (b *CommandBuilder) Configure(fn func(*CommandSpec)) *CommandBuilder {
+	fn(&b.spec)
+	return b
+}
+
+// Handler sets the function run for each invocation.
+func (b *CommandBuilder) Handler(fn HandlerFunc) *CommandBuilder {
+	b.handler = fn
+	return b
+}
+
+// Build returns the factory for the command. It panics when no handler was
+// set, like registration does for a spec without a name.
+func (b *CommandBuilder) Build() CommandFactory {
+	if b.handler == nil {
+		panic("command " + b.spec.Name + " has no handler")
+	}
+	return &funcCommandFactory{spec: b.spec, fn: b.handler}
+}
+
+// funcCommandFactory serves a spec and handler function as a command.
+type funcCommandFactory struct {
+	spec CommandSpec
+	fn   HandlerFunc
+}
+
+func (f *funcCommandFactory) Spec() CommandSpec { return f.spec }
+
+func (f *funcCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &funcCommand{spec: f.spec, fn: f.fn}, nil
+}
+
+type funcCommand struct {
+	spec CommandSpec
+	fn   HandlerFunc
+}
+
+func (c *funcCommand) Spec() CommandSpec { return c.spec }
+
+func (c *funcCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	return c.fn(rt, input)
+}
@@ -0,0 +1,294 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultChangeSet is the change set StageChange uses when a change names none.
+const DefaultChangeSet = "candidate"
+
+// Change is one staged operation, applied when its change set is committed.
+type Change struct {
+	ID int `json:"id"`
+	// Set names the change set; empty means DefaultChangeSet.
+	Set string `json:"set"`
+	// Applier names the ChangeApplier, registered with WithChangeApplier, that commits it.
+	Applier string `json:"applier"`
+	Summary string `json:"summary"`
+	// Before and After are payloads describing the affected state, compared by `changes diff`.
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+	// Data is whatever the applier needs to carry out the change.
+	Data         any       `json:"data,omitempty"`
+	InvocationID string    `json:"invocation_id"`
+	Staged       time.Time `json:"staged"`
+}
+
+// ChangeApplier commits staged changes, receiving each consecutive run of
+// changes that name it in the order they were staged.
+type ChangeApplier interface {
+	Apply(ctx context.Context, changes []Change) error
+}
+
+// ChangeApplierFunc adapts a function to ChangeApplier.
+type ChangeApplierFunc func(ctx context.Context, changes []Change) error
+
+// Apply implements ChangeApplier.
+func (f ChangeApplierFunc) Apply(ctx context.Context, changes []Change) error {
+	return f(ctx, changes)
+}
+
+// WithChangeApplier registers applier under name for changes staged with
+// StageChange.
+func WithChangeApplier(name string, applier ChangeApplier) Option {
+	return func(e *Engine) {
+		if e.changeAppliers == nil {
+			e.changeAppliers = map[string]ChangeApplier{}
+		}
+		e.changeAppliers[name] = applier
+	}
+}
+
+// changeSets holds a session's staged changes by set name.
+type changeSets struct {
+	mu   sync.Mutex
+	sets map[string][]Change
+	next int
+}
+
+// StageChange adds change to the invoking session's change set instead of
+// applying it, so operators can review a candidate with `changes show` and
+// `changes diff` before `changes commit`. It returns the change as staged.
+func StageChange(rt CommandRuntime, change Change) (Change, error) {
+	s, ok := sessionOf(rt)
+	if !ok {
+		return Change{}, errors.New("change sets require an engine session")
+	}
+	if _, ok := s.engine.changeAppliers[change.Applier]; !ok {
+		return Change{}, fmt.Errorf("unknown change applier %q", change.Applier)
+	}
+	if change.Set == "" {
+		change.Set = DefaultChangeSet
+	}
+	change.InvocationID = rt.InvocationMeta().ID
+	change.Staged = time.Now()
+	s.changes.mu.Lock()
+	defer s.changes.mu.Unlock()
+	s.changes.next++
+	change.ID = s.changes.next
+	if s.changes.sets == nil {
+		s.changes.sets = map[string][]Change{}
+	}
+	s.changes.sets[change.Set] = append(s.changes.sets[change.Set], change)
+	return change, nil
+}
+
+// Changes returns the changes staged in set, oldest first.
+func (s *Session) Changes(set string) []Change {
+	s.changes.mu.Lock()
+	defer s.changes.mu.Unlock()
+	return append([]Change(nil), s.changes.sets[set]...)
+}
+
+// ChangeSets returns the names of the session's non-empty change sets.
+func (s *Session) ChangeSets() []string {
+	s.changes.mu.Lock()
+	defer s.changes.mu.Unlock()
+	names := make([]string, 0, len(s.changes.sets))
+	for name := range s.changes.sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DiscardChanges drops the changes in set, or only the change with id when it
+// is non-zero, returning how many were dropped.
+func (s *Session) DiscardChanges(set string, id int) int {
+	s.changes.mu.Lock()
+	defer s.changes.mu.Unlock()
+	changes := s.changes.sets[set]
+	kept := changes[:0:0]
+	for _, c := range changes {
+		if id != 0 && c.ID != id {
+			kept = append(kept, c)
+		}
+	}
+	s.setChangesLocked(set, kept)
+	return len(changes) - len(kept)
+}
+
+// CommitChanges applies set through the registered appliers. Changes are
+// removed as their applier succeeds; after a failure the rest stay staged.
+// It returns how many changes were applied.
+func (s *Session) CommitChanges(ctx context.Context, set string) (int, error) {
+	changes := s.Changes(set)
+	applied := 0
+	for len(changes) > 0 {
+		n := 1
+		for n < len(changes) && changes[n].Applier == changes[0].Applier {
+			n++
+		}
+		name := changes[0].Applier
+		applier, ok := s.engine.changeAppliers[name]
+		if !ok {
+			return applied, fmt.Errorf("unknown change applier %q", name)
+		}
+		if err := applier.Apply(ctx, changes[:n]); err != nil {
+			return applied, fmt.Errorf("%s: %w", name, err)
+		}
+		s.removeChanges(set, changes[:n])
+		applied += n
+		changes = changes[n:]
+	}
+	return applied, nil
+}
+
+func (s *Session) removeChanges(set string, done []Change) {
+	s.changes.mu.Lock()
+	defer s.changes.mu.Unlock()
+	ids := map[int]bool{}
+	for _, c := range done {
+		ids[c.ID] = true
+	}
+	var kept []Change
+	for _, c := range s.changes.sets[set] {
+		if !ids[c.ID] {
+			kept = append(kept, c)
+		}
+	}
+	s.setChangesLocked(set, kept)
+}
+
+func (s *Session) setChangesLocked(set string, changes []Change) {
+	if len(changes) == 0 {
+		delete(s.changes.sets, set)
+		return
+	}
+	s.changes.sets[set] = changes
+}
+
+// changes command -------------------------------------------------------------
+
+type changesCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *changesCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "changes",
+			Summary:     "Review, commit, or discard staged changes",
+			Description: "Commands that stage their operations collect them in a change set (candidate by default) until it is committed or discarded.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"show", "diff", "commit", "discard"}, Default: "show", Description: "Action to perform"},
+				{Name: "set", Type: ArgTypeString, Default: DefaultChangeSet, Description: "Change set name", Complete: completeChangeSets},
+			},
+			Flags: []FlagSpec{
+				{Name: "id", Type: ArgTypeInt, Description: "Discard only this change"},
+			},
+			Examples: []Example{
+				{Description: "List staged changes", Command: "changes"},
+				{Description: "Compare the candidate with the current state", Command: "changes diff"},
+				{Description: "Apply the candidate", Command: "changes commit"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func completeChangeSets(prefix string, rt CommandRuntime) []string {
+	s, ok := sessionOf(rt)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, name := range s.ChangeSets() {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (f *changesCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &changesCommand{spec: f.Spec()}, nil
+}
+
+type changesCommand struct {
+	spec CommandSpec
+}
+
+func (c *changesCommand) Spec() CommandSpec { return c.spec }
+
+func (c *changesCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	s, ok := sessionOf(rt)
+	if !ok {
+		return changesFailure(errors.New("change sets require an engine session"))
+	}
+	set := input.Args.String("set")
+	changes := s.Changes(set)
+	out := rt.Output()
+	switch input.Args.String("action") {
+	case "diff":
+		if len(changes) == 0 {
+			out.Info(fmt.Sprintf("No changes staged in %s.", set))
+			return CommandResult{Status: StatusSuccess}
+		}
+		reports := make([]DiffReport, 0, len(changes))
+		for _, ch := range changes {
+			report := DiffPayloads(ch.Before, ch.After)
+			out.Info(fmt.Sprintf("#%d %s", ch.ID, ch.Summary))
+			renderDiff(out, report)
+			reports = append(reports, report)
+		}
+		return CommandResult{Status: StatusSuccess, Payload: reports}
+	case "commit":
+		if len(changes) == 0 {
+			out.Info(fmt.Sprintf("No changes staged in %s.", set))
+			return CommandResult{Status: StatusSuccess}
+		}
+		if input.DryRun {
+			out.Info(fmt.Sprintf("Would commit %d change(s) in %s.", len(changes), set))
+			return CommandResult{Status: StatusSuccess, Payload: changes}
+		}
+		applied, err := s.CommitChanges(rt.Cancellation(), set)
+		if err != nil {
+			result := changesFailure(fmt.Errorf("commit of %s stopped after %d of %d change(s): %w", set, applied, len(changes), err))
+			result.Error.Hints = []string{"The remaining changes are still staged; fix the problem and commit again, or discard them."}
+			return result
+		}
+		out.Info(fmt.Sprintf("Committed %d change(s) in %s.", applied, set))
+		return CommandResult{Status: StatusSuccess, Payload: changes}
+	case "discard":
+		id := input.Flags.Int("id")
+		n := s.DiscardChanges(set, id)
+		if id != 0 && n == 0 {
+			return changesFailure(fmt.Errorf("no change #%d in %s", id, set))
+		}
+		out.Info(fmt.Sprintf("Discarded %d change(s) from %s.", n, set))
+		return CommandResult{Status: StatusSuccess}
+	}
+	if len(changes) == 0 {
+		out.Info(fmt.Sprintf("No changes staged in %s.", set))
+		return CommandResult{Status: StatusSuccess, Payload: changes}
+	}
+	rows := make([][]string, 0, len(changes))
+	for _, ch := range changes {
+		rows = append(rows, []string{strconv.Itoa(ch.ID), ch.Applier, ch.Summary, ch.Staged.Format(time.TimeOnly)})
+	}
+	out.WriteTable([]string{"#", "Applier", "Change", "Staged"}, rows)
+	return CommandResult{Status: StatusSuccess, Payload: changes}
+}
+
+func changesFailure(err error) CommandResult {
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+}
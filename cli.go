@@ -0,0 +1,41 @@
+package tui
+
+// ExecuteLine runs one command line on the session and returns its result,
+// without a prompt. Output goes to the session's writer as usual; err reports
+// parse and resolution errors, while command failures are in the result.
+func (s *Session) ExecuteLine(line string) (CommandResult, error) {
+	tokens, _, err := lexTokens(line)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if len(tokens) == 0 {
+		return CommandResult{}, nil
+	}
+	return s.dispatchResult(tokens)
+}
+
+// ExecuteLine runs one command line on the default session; see Session.ExecuteLine.
+func (e *Engine) ExecuteLine(line string) (CommandResult, error) {
+	return e.defaultSession.ExecuteLine(line)
+}
+
+// RunOnce runs the command given by args, typically os.Args[1:], as a regular
+// CLI invocation such as `mytool network show --json`, and returns its exit
+// code. args are used as already split by the shell. Errors are reported as in
+// the interactive loop; with no args the help listing is shown.
+func (s *Session) RunOnce(args []string) int {
+	if len(args) == 0 {
+		args = []string{"help"}
+	}
+	release := s.catchInterrupts()
+	defer release()
+	if _, err := s.dispatchResult(literalTokens(args)); err != nil {
+		s.reportError(err)
+	}
+	return s.ExitCode()
+}
+
+// RunOnce runs args on the default session; see Session.RunOnce.
+func (e *Engine) RunOnce(args []string) int {
+	return e.defaultSession.RunOnce(args)
+}
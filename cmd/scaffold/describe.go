@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// description is the parsed YAML command description.
+type description struct {
+	Package     string
+	Name        string
+	Context     string
+	Summary     string
+	Description string
+	Args        []param
+	Flags       []param
+}
+
+// param is one positional argument or flag.
+type param struct {
+	Name        string
+	Shorthand   string
+	Type        string
+	Required    bool
+	Description string
+	Enum        []string
+}
+
+var (
+	identifier = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	argTypes   = map[string]string{
+		"string": "ArgTypeString", "int": "ArgTypeInt", "float": "ArgTypeFloat",
+		"bool": "ArgTypeBool", "duration": "ArgTypeDuration", "enum": "ArgTypeEnum",
+		"json": "ArgTypeJSON", "path": "ArgTypePath", "secret": "ArgTypeSecret",
+	}
+)
+
+// parseDescription reads the YAML subset documented on the command: top-level
+// "key: value" scalars plus args and flags as lists of such maps.
+func parseDescription(r io.Reader) (description, error) {
+	var desc description
+	var list *[]param
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := scanner.Text()
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = raw[:i]
+		}
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fail := func(format string, args ...any) (description, error) {
+			return description{}, fmt.Errorf("line %d: %s", lineNo, fmt.Sprintf(format, args...))
+		}
+		indented := raw[0] == ' ' || raw[0] == '\t'
+		if strings.HasPrefix(line, "- ") {
+			if list == nil {
+				return fail("list item outside args or flags")
+			}
+			*list = append(*list, param{Type: "string"})
+			line = strings.TrimSpace(line[2:])
+		} else if !indented {
+			list = nil
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fail("expected key: value")
+		}
+		key, value = strings.TrimSpace(key), unquote(strings.TrimSpace(value))
+		if list != nil && indented {
+			if len(*list) == 0 {
+				return fail("%s outside a list item", key)
+			}
+			if err := setParam(&(*list)[len(*list)-1], key, value); err != nil {
+				return fail("%v", err)
+			}
+			continue
+		}
+		switch key {
+		case "package":
+			desc.Package = value
+		case "name":
+			desc.Name = value
+		case "context":
+			desc.Context = value
+		case "summary":
+			desc.Summary = value
+		case "description":
+			desc.Description = value
+		case "args":
+			list = &desc.Args
+		case "flags":
+			list = &desc.Flags
+		default:
+			return fail("unknown key %q", key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return description{}, err
+	}
+	return desc, desc.validate()
+}
+
+func setParam(p *param, key, value string) error {
+	switch key {
+	case "name":
+		p.Name = value
+	case "shorthand":
+		p.Shorthand = value
+	case "type":
+		p.Type = value
+	case "description":
+		p.Description = value
+	case "required":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("required: %v", err)
+		}
+		p.Required = b
+	case "enum":
+		p.Enum = nil
+		for _, v := range strings.Split(strings.Trim(value, "[]"), ",") {
+			if v = unquote(strings.TrimSpace(v)); v != "" {
+				p.Enum = append(p.Enum, v)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+	return nil
+}
+
+func (d description) validate() error {
+	if !identifier.MatchString(d.Name) {
+		return fmt.Errorf("name %q must be lower-case letters, digits, and dashes", d.Name)
+	}
+	for _, p := range append(append([]param{}, d.Args...), d.Flags...) {
+		if !identifier.MatchString(p.Name) {
+			return fmt.Errorf("%s: parameter name %q must be lower-case letters, digits, and dashes", d.Name, p.Name)
+		}
+		if _, ok := argTypes[p.Type]; !ok {
+			return fmt.Errorf("%s: %s has unknown type %q", d.Name, p.Name, p.Type)
+		}
+		if p.Type == "enum" && len(p.Enum) == 0 {
+			return fmt.Errorf("%s: enum %s has no values", d.Name, p.Name)
+		}
+	}
+	return nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+// Command scaffold writes the skeleton of a planetui command — spec, factory,
+// command, and a test — from a small YAML description, so commands built by
+// different teams share one layout:
+//
+//	go run github.com/network-plane/planetui/cmd/scaffold -in show-routes.yaml -out ./commands
+//
+// The description supports the subset of YAML below; nested values beyond the
+// args and flags lists are not understood.
+//
+//	package: commands
+//	name: show-routes
+//	context: net
+//	summary: List routes
+//	description: Lists the routes of a VRF.
+//	args:
+//	  - name: prefix
+//	    type: string
+//	    required: true
+//	flags:
+//	  - name: vrf
+//	    shorthand: v
+//	    type: enum
+//	    enum: [default, mgmt]
+//	    description: VRF to show
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	in := flag.String("in", "", "YAML command description (default stdin)")
+	out := flag.String("out", ".", "directory to write the generated files to")
+	force := flag.Bool("force", false, "overwrite existing files")
+	flag.Parse()
+
+	if err := run(*in, *out, *force); err != nil {
+		fmt.Fprintf(os.Stderr, "scaffold: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out string, force bool) error {
+	src := os.Stdin
+	if in != "" {
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		src = f
+	}
+	desc, err := parseDescription(src)
+	if err != nil {
+		return err
+	}
+	files, err := render(desc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+	for name, body := range files {
+		path := filepath.Join(out, name)
+		if _, err := os.Stat(path); err == nil && !force {
+			return fmt.Errorf("%s exists; use -force to overwrite", path)
+		}
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			return err
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
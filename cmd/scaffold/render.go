@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// render returns the generated files keyed by file name.
+func render(d description) (map[string][]byte, error) {
+	if d.Package == "" {
+		d.Package = "commands"
+	}
+	base := strings.ReplaceAll(d.Name, "-", "_")
+	files := map[string][]byte{}
+	for name, tmpl := range map[string]*template.Template{base + ".go": commandTemplate, base + "_test.go": testTemplate} {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, d); err != nil {
+			return nil, err
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s: generated code does not parse: %v", name, err)
+		}
+		files[name] = src
+	}
+	return files, nil
+}
+
+var funcs = template.FuncMap{
+	"exported": func(name string) string {
+		var b strings.Builder
+		for _, part := range strings.Split(name, "-") {
+			if part != "" {
+				b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+			}
+		}
+		return b.String()
+	},
+	"quote":   strconv.Quote,
+	"argType": func(t string) string { return argTypes[t] },
+	"quoteList": func(values []string) string {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = strconv.Quote(v)
+		}
+		return strings.Join(quoted, ", ")
+	},
+	"accessor": func(t string) string {
+		switch t {
+		case "int":
+			return "Int"
+		case "float":
+			return "Float"
+		case "bool":
+			return "Bool"
+		case "duration":
+			return "Duration"
+		default:
+			return "String"
+		}
+	},
+	"invocation": func(d description) string {
+		parts := []string{d.Name}
+		for _, a := range d.Args {
+			if !a.Required {
+				continue
+			}
+			switch {
+			case len(a.Enum) > 0:
+				parts = append(parts, a.Enum[0])
+			case a.Type == "int", a.Type == "float":
+				parts = append(parts, "1")
+			case a.Type == "bool":
+				parts = append(parts, "true")
+			case a.Type == "duration":
+				parts = append(parts, "1s")
+			default:
+				parts = append(parts, "example")
+			}
+		}
+		for _, f := range d.Flags {
+			if f.Required {
+				value := "example"
+				if len(f.Enum) > 0 {
+					value = f.Enum[0]
+				}
+				parts = append(parts, "--"+f.Name+"="+value)
+			}
+		}
+		return strings.Join(parts, " ")
+	},
+}
+
+var commandTemplate = template.Must(template.New("command").Funcs(funcs).Parse(`package {{.Package}}
+
+import tui "github.com/network-plane/planetui"
+
+{{$type := exported .Name}}// {{$type}}Factory builds the {{.Name}} command. Register it with
+// tui.RegisterCommand(&{{.Package}}.{{$type}}Factory{}).
+type {{$type}}Factory struct {
+	spec tui.CommandSpec
+}
+
+func (f *{{$type}}Factory) Spec() tui.CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = tui.CommandSpec{
+			Name:        {{quote .Name}},
+			Summary:     {{quote .Summary}},
+			Description: {{quote .Description}},
+			Context:     {{quote .Context}},
+{{- if .Args}}
+			Args: []tui.ArgSpec{
+{{- range .Args}}
+				{Name: {{quote .Name}}, Type: tui.{{argType .Type}}{{if .Required}}, Required: true{{end}}{{if .Enum}}, EnumValues: []string{ {{- quoteList .Enum -}} }{{end}}, Description: {{quote .Description}}},
+{{- end}}
+			},
+{{- end}}
+{{- if .Flags}}
+			Flags: []tui.FlagSpec{
+{{- range .Flags}}
+				{Name: {{quote .Name}}{{if .Shorthand}}, Shorthand: {{quote .Shorthand}}{{end}}, Type: tui.{{argType .Type}}{{if .Required}}, Required: true{{end}}{{if .Enum}}, EnumValues: []string{ {{- quoteList .Enum -}} }{{end}}, Description: {{quote .Description}}},
+{{- end}}
+			},
+{{- end}}
+		}
+	}
+	return f.spec
+}
+
+func (f *{{$type}}Factory) New(rt tui.CommandRuntime) (tui.Command, error) {
+	return &{{$type}}Command{spec: f.Spec()}, nil
+}
+
+// {{$type}}Command runs one invocation of {{.Name}}.
+type {{$type}}Command struct {
+	spec tui.CommandSpec
+}
+
+func (c *{{$type}}Command) Spec() tui.CommandSpec { return c.spec }
+
+func (c *{{$type}}Command) Execute(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+{{- range .Args}}
+	_ = input.Args.{{accessor .Type}}({{quote .Name}})
+{{- end}}
+{{- range .Flags}}
+	_ = input.Flags.{{accessor .Type}}({{quote .Name}})
+{{- end}}
+	// TODO: implement {{.Name}}. Fail with
+	// tui.CommandResult{Status: tui.StatusFailed, Error: &tui.CommandError{Message: "...", Severity: tui.SeverityError}}.
+	return tui.CommandResult{Status: tui.StatusSuccess}
+}
+`))
+
+var testTemplate = template.Must(template.New("test").Funcs(funcs).Parse(`package {{.Package}}
+
+import (
+	"bytes"
+	"testing"
+
+	tui "github.com/network-plane/planetui"
+)
+
+{{$type := exported .Name}}func Test{{$type}}SpecLint(t *testing.T) {
+	e := tui.NewEngine(tui.WithOutputWriter(&bytes.Buffer{}))
+	e.RegisterCommand(&{{$type}}Factory{})
+	for _, finding := range e.Registry().Lint() {
+		if finding.Command == {{quote .Name}} {
+			t.Errorf("lint: %s", finding)
+		}
+	}
+}
+
+func Test{{$type}}Execute(t *testing.T) {
+	var out bytes.Buffer
+	e := tui.NewEngine(tui.WithOutputWriter(&out))
+	e.RegisterCommand(&{{$type}}Factory{})
+{{- if .Context}}
+	if err := e.DefaultSession().Execute({{quote .Context}}); err != nil {
+		t.Fatalf("enter context: %v", err)
+	}
+{{- end}}
+	if err := e.DefaultSession().Execute({{quote (invocation .)}}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	// TODO: assert on out.String().
+}
+`))
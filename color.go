@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ANSI SGR sequences used by the status renderers.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiDim    = "\x1b[2m"
+)
+
+var colorMode atomic.Int32 // 0 = auto, 1 = on, 2 = off
+
+// SetColorEnabled forces status colouring on or off for the whole process.
+func SetColorEnabled(enabled bool) {
+	if enabled {
+		colorMode.Store(1)
+	} else {
+		colorMode.Store(2)
+	}
+}
+
+// ColorEnabled reports whether renderers emit ANSI colour. By default colour is
+// used on interactive terminals unless NO_COLOR is set.
+func ColorEnabled() bool {
+	switch colorMode.Load() {
+	case 1:
+		return true
+	case 2:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return TerminalAvailable()
+}
+
+// Colorize wraps s in the given ANSI sequence when colour is enabled.
+func Colorize(s, color string) string {
+	if color == "" || !ColorEnabled() {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// StatusColor returns the ANSI sequence for a command status.
+func StatusColor(status CommandStatus) string {
+	switch status {
+	case StatusSuccess:
+		return ansiGreen
+	case StatusPartial:
+		return ansiYellow
+	case StatusFailed:
+		return ansiRed
+	case StatusPending:
+		return ansiDim
+	}
+	return ""
+}
+
+// SeverityColor returns the ANSI sequence for a severity level.
+func SeverityColor(level SeverityLevel) string {
+	switch level {
+	case SeverityInfo:
+		return ansiBlue
+	case SeverityWarning:
+		return ansiYellow
+	case SeverityError:
+		return ansiRed
+	}
+	return ""
+}
+
+// RenderStatus renders a command status: green success, yellow partial, red failed.
+func RenderStatus(status CommandStatus) string {
+	return Colorize(string(status), StatusColor(status))
+}
+
+// RenderSeverity renders a severity level: blue info, yellow warning, red error.
+func RenderSeverity(level SeverityLevel) string {
+	return Colorize(string(level), SeverityColor(level))
+}
+
+// WithStatusSummary prints a footer after each command with its coloured status,
+// duration, and any CommandResult.Summary counts.
+func WithStatusSummary() Option {
+	return func(e *Engine) { e.statusSummary = true }
+}
+
+// SetStatusSummary toggles the execution footer at runtime.
+func (e *Engine) SetStatusSummary(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.statusSummary = enabled
+}
+
+func (e *Engine) summaryEnabled() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.statusSummary
+}
+
+// statusSummary formats the footer printed by WithStatusSummary.
+func statusSummary(status CommandStatus, elapsed time.Duration, summary *ResultSummary) string {
+	line := RenderStatus(status) + Colorize(" in "+elapsed.Round(time.Microsecond).String(), ansiDim)
+	if summary == nil {
+		return line
+	}
+	keys := make([]string, 0, len(summary.Counts))
+	for k := range summary.Counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s %d", k, summary.Counts[k]))
+	}
+	if summary.Note != "" {
+		parts = append(parts, summary.Note)
+	}
+	if len(parts) > 0 {
+		line += " · " + strings.Join(parts, ", ")
+	}
+	return line
+}
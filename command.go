@@ -1,6 +1,10 @@
 package tui
 
-import "context"
+import (
+	"context"
+	"strings"
+	"time"
+)
 
 // Command is the primary interface implemented by concrete commands.
 type Command interface {
@@ -31,6 +35,30 @@ type CommandSpec struct {
 	Usage        string
 	AllowPipes   bool
 	DefaultAlias string
+	// PipelineAccepts lists payload types the command can consume; empty accepts anything.
+	PipelineAccepts []PipelineType
+	// PipelineProduces declares the payload type placed in CommandResult.Pipeline.
+	PipelineProduces PipelineType
+	// Complete supplies dynamic completion candidates for args and flag values.
+	Complete CompleteFunc
+	// SuppressUsage disables the usage hint printed after parse errors.
+	SuppressUsage bool
+	// Concurrent marks the command safe to run alongside itself, e.g. under `parallel`.
+	// Concurrent commands must not navigate contexts.
+	Concurrent bool
+	// NoImplicitFlags opts out of the engine-provided --verbose/-v and --quiet/-q flags.
+	NoImplicitFlags bool
+	// NoHistory keeps invocations of this command out of the command history.
+	NoHistory bool
+	// Middleware wraps only this command, inside engine and context middleware.
+	Middleware []Middleware
+	// Timeout bounds each invocation; the input context's deadline is set from it
+	// and the invocation fails when it passes. Zero uses the engine default; see
+	// WithDefaultCommandTimeout.
+	Timeout time.Duration
+	// FlagGroups relate flags to each other, e.g. --file XOR --inline; the
+	// args parser enforces them.
+	FlagGroups []FlagGroup
 }
 
 // Example documents an example invocation of a command.
@@ -50,6 +78,24 @@ const (
 	ArgTypeDuration ArgType = "duration"
 	ArgTypeEnum     ArgType = "enum"
 	ArgTypeJSON     ArgType = "json"
+	ArgTypePath     ArgType = "path"
+	// ArgTypeSecret is a string masked as **** wherever the command line is kept.
+	ArgTypeSecret ArgType = "secret"
+	// ArgTypePayload takes an @name reference; the command receives the stored payload.
+	ArgTypePayload ArgType = "payload"
+	// ArgTypeIP is an IPv4 or IPv6 address, read with ValueSet.IP.
+	ArgTypeIP ArgType = "ip"
+	// ArgTypeCIDR is a prefix such as 10.0.0.0/8, read with ValueSet.CIDR.
+	ArgTypeCIDR ArgType = "cidr"
+	// ArgTypeMAC is a hardware address, read with ValueSet.MAC.
+	ArgTypeMAC ArgType = "mac"
+	// ArgTypePort is a TCP/UDP port number from 1 to 65535, read with ValueSet.Int.
+	ArgTypePort ArgType = "port"
+	// ArgTypeHostPort is host:port or [ipv6]:port, read with ValueSet.String.
+	ArgTypeHostPort ArgType = "hostport"
+	// ArgTypeTime is a point in time: RFC 3339, a date, Unix seconds, or a
+	// relative form such as -2h, -3d, now, today, or yesterday. Read it with ValueSet.Time.
+	ArgTypeTime ArgType = "time"
 )
 
 // ArgSpec defines positional argument metadata.
@@ -61,6 +107,24 @@ type ArgSpec struct {
 	Description string
 	Default     any
 	EnumValues  []string
+	// Passthrough captures this and all remaining tokens verbatim. Flags declared by the
+	// command are still parsed before it, and a leading "--" separator is dropped.
+	Passthrough bool
+	// Schema validates ArgTypeJSON values at parse time.
+	Schema *JSONSchema
+	// DecodeAs, for ArgTypeJSON, is a prototype (e.g. Payload{}) the value must strictly
+	// decode into; the decoded value of that type is stored instead of the raw text.
+	DecodeAs any
+	// Complete suggests values for tab completion, e.g. live resource names.
+	Complete func(prefix string, rt CommandRuntime) []string
+	// Prompt replaces the default "value for NAME: " prompt shown when a required
+	// argument is missing in an interactive session.
+	Prompt string
+	// Secret reads the prompted value without echo and masks it like ArgTypeSecret.
+	Secret bool
+	// FromFile accepts @path to read the value from a file and @- to read it
+	// from piped stdin.
+	FromFile bool
 }
 
 // FlagSpec defines flag metadata.
@@ -73,6 +137,53 @@ type FlagSpec struct {
 	Default     any
 	EnumValues  []string
 	Hidden      bool
+	// Schema validates ArgTypeJSON values at parse time.
+	Schema *JSONSchema
+	// DecodeAs, for ArgTypeJSON, is a prototype (e.g. Payload{}) the value must strictly
+	// decode into; the decoded value of that type is stored instead of a generic map.
+	DecodeAs any
+	// Complete suggests values for tab completion, e.g. live resource names.
+	Complete func(prefix string, rt CommandRuntime) []string
+	// FromFile accepts @path to read the value from a file and @- to read it
+	// from piped stdin.
+	FromFile bool
+}
+
+// FlagGroupKind says how the flags of a FlagGroup relate.
+type FlagGroupKind string
+
+const (
+	// FlagGroupExactlyOne requires exactly one of the flags.
+	FlagGroupExactlyOne FlagGroupKind = "exactly-one"
+	// FlagGroupAtMostOne allows at most one of the flags.
+	FlagGroupAtMostOne FlagGroupKind = "at-most-one"
+	// FlagGroupRequires requires the other flags whenever the first is given.
+	FlagGroupRequires FlagGroupKind = "requires"
+)
+
+// FlagGroup is a relationship between flags, named without dashes.
+type FlagGroup struct {
+	Kind  FlagGroupKind
+	Flags []string
+}
+
+// String describes the group for help, e.g. "exactly one of --file, --inline".
+func (g FlagGroup) String() string {
+	names := make([]string, len(g.Flags))
+	for i, name := range g.Flags {
+		names[i] = "--" + name
+	}
+	switch g.Kind {
+	case FlagGroupExactlyOne:
+		return "exactly one of " + strings.Join(names, ", ")
+	case FlagGroupAtMostOne:
+		return "at most one of " + strings.Join(names, ", ")
+	case FlagGroupRequires:
+		if len(names) > 1 {
+			return names[0] + " requires " + strings.Join(names[1:], ", ")
+		}
+	}
+	return string(g.Kind) + " " + strings.Join(names, ", ")
 }
 
 // CommandStatus indicates the result of a command invocation.
@@ -96,8 +207,12 @@ const (
 
 // CommandError wraps an error with user facing metadata.
 type CommandError struct {
-	Err         error
-	Message     string
+	Err     error
+	Message string
+	// ErrCode classifies the failure for automation, e.g. ErrCodeTimeout or
+	// an application code registered with WithErrorCode. When empty, Code
+	// derives one from Err.
+	ErrCode     string
 	Severity    SeverityLevel
 	Hints       []string
 	Recoverable bool
@@ -111,6 +226,16 @@ type CommandResult struct {
 	Messages    []OutputMessage
 	NextContext string
 	Pipeline    any
+	// Undo, when set on success, is pushed onto the session's undo stack.
+	Undo *UndoOperation
+	// Summary reports affected-object counts for the execution footer.
+	Summary *ResultSummary
+}
+
+// ResultSummary describes what a command changed, e.g. {"created": 2, "deleted": 1}.
+type ResultSummary struct {
+	Counts map[string]int
+	Note   string
 }
 
 // OutputMessage allows commands to suggest standardised output.
@@ -127,6 +252,9 @@ type CommandInput struct {
 	Args     ValueSet
 	Flags    ValueSet
 	Pipeline any
+	// DryRun asks the command to report what it would change instead of changing
+	// it; it is set by --dry-run or `set dry-run on`.
+	DryRun bool
 }
 
 // CommandRuntime presents runtime services to commands.
@@ -142,4 +270,20 @@ type CommandRuntime interface {
 	PopContext() error
 	PipelineData() any
 	SetPipelineData(v any)
+	// Form asks the operator for each field in turn; see Session.Form.
+	Form(fields ...FormField) (ValueSet, error)
+	// PromptSecret reads a credential without echo; see Session.PromptSecret.
+	PromptSecret(label string) (string, error)
+	// Select asks the operator to pick one of options; see Session.Select.
+	Select(title string, options []SelectOption) (SelectOption, error)
+	// Principal returns the authenticated operator, or nil when anonymous.
+	Principal() *Principal
+	// InvocationMeta describes this invocation: its ID, source, client, and start time.
+	InvocationMeta() InvocationMeta
+	// Child derives a runtime for a helper of a composite command. It is cancelled
+	// with this runtime or by the returned func, prefixes the lines it writes with
+	// [name], and sets Owner on tasks it spawns to the path of child names, e.g.
+	// "deploy/web". Call the func when the helper finishes; navigation requested
+	// through a child is ignored.
+	Child(name string) (CommandRuntime, context.CancelFunc)
 }
@@ -0,0 +1,619 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/chzyer/readline"
+)
+
+// CompleteFunc returns dynamic completion candidates for an argument or flag value.
+// name is the ArgSpec or FlagSpec name being completed and prefix the partial input.
+type CompleteFunc func(name, prefix string, rt CommandRuntime) []string
+
+// CompletionProvider is implemented by a CommandFactory, or by the Command it
+// creates, to complete the command's positional arguments from live state, e.g.
+// interface names fetched from the connected device through rt.Services().
+// Its candidates are offered alongside the ones the ArgSpec itself provides.
+type CompletionProvider interface {
+	CompleteArgs(req CompletionRequest, rt CommandRuntime) []Candidate
+}
+
+// CompletionRequest describes the positional argument being completed.
+type CompletionRequest struct {
+	// Arg is the spec of the argument under the cursor and Index its position.
+	Arg   ArgSpec
+	Index int
+	// Args holds the tokens typed after the command name, before the cursor.
+	Args   []string
+	Prefix string
+}
+
+// Candidate is a ranked completion suggestion with an optional inline description.
+type Candidate struct {
+	Value       string
+	Description string
+	Score       int
+}
+
+// Matcher scores candidate against typed input, returning false when it does not match.
+// Higher scores rank first.
+type Matcher func(candidate, input string) (int, bool)
+
+// PrefixMatch accepts candidates starting with input, preferring shorter ones.
+func PrefixMatch(candidate, input string) (int, bool) {
+	if !strings.HasPrefix(candidate, input) {
+		return 0, false
+	}
+	return 1000 - len(candidate), true
+}
+
+// FuzzyMatch accepts candidates containing input as a case-insensitive subsequence.
+// Prefix matches, consecutive runs, and word-boundary hits score higher.
+func FuzzyMatch(candidate, input string) (int, bool) {
+	if input == "" {
+		return 1000 - len(candidate), true
+	}
+	if strings.HasPrefix(candidate, input) {
+		return 2000 - len(candidate), true
+	}
+	cand := []rune(strings.ToLower(candidate))
+	want := []rune(strings.ToLower(input))
+	score := 0
+	ci := 0
+	prev := -2
+	for _, r := range want {
+		found := false
+		for ; ci < len(cand); ci++ {
+			if cand[ci] != r {
+				continue
+			}
+			score += 10
+			if ci == prev+1 {
+				score += 15
+			}
+			if ci == 0 || !unicode.IsLetter(cand[ci-1]) && !unicode.IsDigit(cand[ci-1]) {
+				score += 20
+			}
+			prev = ci
+			ci++
+			found = true
+			break
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return score - len(cand), true
+}
+
+// CompletionEngine produces ranked candidates for the token under the cursor.
+type CompletionEngine interface {
+	Complete(s *Session, line string) (token string, candidates []Candidate)
+}
+
+// RegistryCompletionEngine gathers candidates from the registry and ranks them with a Matcher.
+type RegistryCompletionEngine struct {
+	Matcher Matcher
+	Limit   int
+}
+
+// NewRegistryCompletionEngine constructs an engine using matcher (PrefixMatch when nil).
+func NewRegistryCompletionEngine(matcher Matcher) *RegistryCompletionEngine {
+	if matcher == nil {
+		matcher = PrefixMatch
+	}
+	return &RegistryCompletionEngine{Matcher: matcher, Limit: 100}
+}
+
+// Complete implements CompletionEngine.
+func (c *RegistryCompletionEngine) Complete(s *Session, line string) (string, []Candidate) {
+	lexed, trailingSpace, _ := lexTokens(line)
+	token := ""
+	if len(lexed) > 0 && !trailingSpace {
+		token = lexed[len(lexed)-1].text
+		lexed = lexed[:len(lexed)-1]
+	}
+	// Each pipeline stage is completed as a command line of its own.
+	stages := pipeStages(lexed)
+	tokens := stages[len(stages)-1]
+	return token, rankCandidates(s.completeTokens(tokens, token), token, c.Matcher, c.Limit)
+}
+
+func rankCandidates(candidates []Candidate, input string, matcher Matcher, limit int) []Candidate {
+	seen := map[string]bool{}
+	ranked := make([]Candidate, 0, len(candidates))
+	for _, cand := range candidates {
+		if seen[cand.Value] {
+			continue
+		}
+		score, ok := matcher(cand.Value, input)
+		if !ok {
+			continue
+		}
+		seen[cand.Value] = true
+		cand.Score = score
+		ranked = append(ranked, cand)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Value < ranked[j].Value
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// WithCompletionEngine replaces the engine used for interactive completion.
+func WithCompletionEngine(ce CompletionEngine) Option {
+	return func(e *Engine) {
+		if ce != nil {
+			e.completion = ce
+		}
+	}
+}
+
+// WithFuzzyCompletion switches the registry completion engine to fuzzy matching.
+func WithFuzzyCompletion() Option {
+	return func(e *Engine) { e.completion = NewRegistryCompletionEngine(FuzzyMatch) }
+}
+
+// WithCompletionDescriptions renders candidate descriptions beside ambiguous completions.
+func WithCompletionDescriptions(enabled bool) Option {
+	return func(e *Engine) { e.describeCandidates = enabled }
+}
+
+// sessionCompleter adapts a CompletionEngine to readline.AutoCompleter.
+type sessionCompleter struct {
+	session *Session
+	rl      *readline.Instance
+}
+
+// Do implements readline.AutoCompleter.
+func (c *sessionCompleter) Do(line []rune, pos int) (suffixes [][]rune, length int) {
+	// Readline calls completers on its own goroutine, out of the read loop's reach.
+	defer func() {
+		if r := recover(); r != nil {
+			c.session.recoverCrash("completion", string(line), r)
+			suffixes, length = nil, 0
+		}
+	}()
+	input := string(line[:pos])
+	if labels := c.session.activeChoices(); labels != nil {
+		var out [][]rune
+		for _, label := range labels {
+			if strings.HasPrefix(label, input) {
+				out = append(out, []rune(label[len(input):]))
+			}
+		}
+		return out, len(input)
+	}
+	token, candidates := c.session.engine.completion.Complete(c.session, input)
+	if len(candidates) == 0 {
+		return nil, 0
+	}
+	describe := c.session.engine.describeCandidates && c.rl != nil && len(candidates) > 1
+
+	allPrefix := true
+	for _, cand := range candidates {
+		if !strings.HasPrefix(cand.Value, token) {
+			allPrefix = false
+			break
+		}
+	}
+	if !allPrefix {
+		if c.rl == nil {
+			return nil, 0
+		}
+		// Fuzzy hits cannot be expressed as suffixes, so replace the token with the best match.
+		if describe {
+			c.writeDescriptions(candidates)
+		}
+		best := candidates[0].Value
+		if !strings.HasSuffix(best, string(filepath.Separator)) {
+			best += " "
+		}
+		c.rl.Operation.SetBuffer(input[:len(input)-len(token)] + best + string(line[pos:]))
+		return nil, 0
+	}
+	if describe {
+		c.writeDescriptions(candidates)
+		values := make([]string, len(candidates))
+		for i, cand := range candidates {
+			values[i] = cand.Value
+		}
+		common := commonPrefix(values)
+		if len(common) <= len(token) {
+			return nil, 0
+		}
+		return [][]rune{[]rune(strings.TrimPrefix(common, token))}, len([]rune(token))
+	}
+	return suffixCandidates(candidates, token), len([]rune(token))
+}
+
+func (c *sessionCompleter) writeDescriptions(candidates []Candidate) {
+	width := 0
+	for _, cand := range candidates {
+		if len(cand.Value) > width {
+			width = len(cand.Value)
+		}
+	}
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, cand := range candidates {
+		if cand.Description == "" {
+			fmt.Fprintf(&b, "  %s\n", cand.Value)
+			continue
+		}
+		fmt.Fprintf(&b, "  %-*s  (%s)\n", width, cand.Value, cand.Description)
+	}
+	c.rl.Write([]byte(b.String()))
+}
+
+// completeTokens returns unranked candidates for prefix given the preceding tokens.
+func (s *Session) completeTokens(tokens []string, prefix string) []Candidate {
+	registry := s.engine.registry
+	tokens = s.engine.aliases.Expand(tokens)
+	if len(tokens) > 0 {
+		if candidates, ok := s.completeBuiltin(tokens, prefix); ok {
+			return candidates
+		}
+	}
+	ctx := s.contexts.Current().Spec.Name
+	if len(tokens) > 0 {
+		if canonical, ok := registry.ResolveContextName(tokens[0]); ok && canonical != "" {
+			ctx = canonical
+			tokens = tokens[1:]
+		}
+	}
+
+	if len(tokens) == 0 {
+		candidates := s.engine.completions.lookup(registry, ctx, func() []Candidate {
+			var candidates []Candidate
+			if ctx == "" {
+				for _, spec := range registry.Contexts(false) {
+					candidates = append(candidates, Candidate{Value: spec.Name, Description: spec.Description})
+				}
+			}
+			for _, spec := range registry.Commands(ctx, false) {
+				candidates = append(candidates, Candidate{Value: spec.Name, Description: spec.Summary})
+			}
+			return candidates
+		})
+		// Aliases apply in every context; the full slice expression keeps the shared index intact.
+		for _, a := range s.engine.aliases.List() {
+			candidates = append(candidates[:len(candidates):len(candidates)], Candidate{Value: a.Name, Description: a.Expansion})
+		}
+		if s.engine.permissions == nil {
+			return candidates
+		}
+		// The index is shared by all sessions, so permissions are applied per lookup.
+		permitted := make([]Candidate, 0, len(candidates))
+		for _, c := range candidates {
+			if entry, ok := registry.Resolve(ctx, c.Value); ok && !s.permitted(entry.Spec) {
+				continue
+			}
+			permitted = append(permitted, c)
+		}
+		return permitted
+	}
+
+	entry, ok := registry.Resolve(ctx, tokens[0])
+	if !ok {
+		return nil
+	}
+	return s.completeCommand(entry, tokens[1:], prefix)
+}
+
+// completeBuiltin handles the navigation keywords processed ahead of the registry.
+func (s *Session) completeBuiltin(tokens []string, prefix string) ([]Candidate, bool) {
+	switch tokens[0] {
+	case "ctx":
+		switch {
+		case len(tokens) == 1:
+			return []Candidate{
+				{Value: "goto", Description: "Replace the stack with a context"},
+				{Value: "push", Description: "Push a context onto the stack"},
+				{Value: "pop", Description: "Return to the previous context"},
+				{Value: "show", Description: "Show the current context, payload, and state"},
+			}, true
+		case len(tokens) == 2 && (tokens[1] == "goto" || tokens[1] == "push"):
+			return s.contextCandidates(), true
+		case len(tokens) == 3 && (tokens[1] == "goto" || tokens[1] == "push"):
+			return s.payloadCandidates(tokens[2], prefix), true
+		}
+		return nil, true
+	case "switch", "cd":
+		if len(tokens) > 1 {
+			return nil, true
+		}
+		candidates := s.contextCandidates()
+		if tokens[0] == "cd" {
+			candidates = append(candidates, Candidate{Value: "..", Description: "Parent context"}, Candidate{Value: "/", Description: "Root context"})
+		}
+		return candidates, true
+	}
+	return nil, false
+}
+
+// contextCandidates lists registered context names and aliases.
+func (s *Session) contextCandidates() []Candidate {
+	// Context names cannot contain NUL, so this key never clashes with one.
+	return s.engine.completions.lookup(s.engine.registry, "\x00contexts", func() []Candidate {
+		var candidates []Candidate
+		for _, spec := range s.engine.registry.Contexts(false) {
+			candidates = append(candidates, Candidate{Value: spec.Name, Description: spec.Description})
+			for _, alias := range spec.Aliases {
+				candidates = append(candidates, Candidate{Value: alias, Description: "alias for " + spec.Name})
+			}
+		}
+		return candidates
+	})
+}
+
+// payloadCandidates asks the target context's provider for payload values.
+func (s *Session) payloadCandidates(name, prefix string) []Candidate {
+	spec, ok := s.engine.registry.Context(name)
+	if !ok || spec.CompletePayload == nil {
+		return nil
+	}
+	return s.cachedCompletion("payload\x00"+spec.Name+"\x00"+prefix, func() []Candidate {
+		var candidates []Candidate
+		for _, v := range spec.CompletePayload(spec.Name, prefix, s.completionRuntime()) {
+			candidates = append(candidates, Candidate{Value: v})
+		}
+		return candidates
+	})
+}
+
+func (s *Session) completeCommand(entry CommandEntry, args []string, prefix string) []Candidate {
+	spec, compiled := entry.Spec, entry.resolved()
+	endOfFlags := slices.Contains(args, "--")
+	if name, value, ok := strings.Cut(strings.TrimPrefix(prefix, "--"), "="); ok && strings.HasPrefix(prefix, "--") && !endOfFlags {
+		for _, flag := range spec.Flags {
+			if flag.Name != name {
+				continue
+			}
+			candidates := s.completeValue(spec, flag.Name, flag.Type, flag.EnumValues, flag.Complete, value)
+			for i := range candidates {
+				candidates[i].Value = "--" + name + "=" + candidates[i].Value
+			}
+			return candidates
+		}
+		return nil
+	}
+	if strings.HasPrefix(prefix, "-") && !endOfFlags {
+		return append([]Candidate(nil), compiled.flagCandidates...)
+	}
+
+	positional, pendingFlag := scanArgs(compiled, args)
+	if pendingFlag != nil {
+		return s.completeValue(spec, pendingFlag.Name, pendingFlag.Type, pendingFlag.EnumValues, pendingFlag.Complete, prefix)
+	}
+	if len(spec.Args) == 0 {
+		return nil
+	}
+	idx := positional
+	if idx >= len(spec.Args) {
+		last := spec.Args[len(spec.Args)-1]
+		if !last.Repeatable {
+			return nil
+		}
+		idx = len(spec.Args) - 1
+	}
+	arg := spec.Args[idx]
+	candidates := s.completeValue(spec, arg.Name, arg.Type, arg.EnumValues, arg.Complete, prefix)
+	key := fmt.Sprintf("provider\x00%s\x00%s\x00%q\x00%s", spec.Context, spec.Name, args, prefix)
+	return append(candidates, s.cachedCompletion(key, func() []Candidate {
+		if provider, rt, ok := s.completionProvider(entry); ok {
+			return provider.CompleteArgs(CompletionRequest{Arg: arg, Index: idx, Args: args, Prefix: prefix}, rt)
+		}
+		return nil
+	})...)
+}
+
+// completionProvider returns the CompletionProvider of entry's factory or, failing
+// that, of a command instance, with the runtime to call it with.
+func (s *Session) completionProvider(entry CommandEntry) (CompletionProvider, CommandRuntime, bool) {
+	rt := s.completionRuntime()
+	if provider, ok := entry.Factory.(CompletionProvider); ok {
+		return provider, rt, true
+	}
+	if entry.Factory == nil {
+		return nil, nil, false
+	}
+	cmd, err := entry.Factory.New(rt)
+	if err != nil {
+		return nil, nil, false
+	}
+	provider, ok := cmd.(CompletionProvider)
+	return provider, rt, ok
+}
+
+// completeValue gathers candidates for one value: built-in ones for the type, the
+// spec's own Complete callback, then the command-wide CommandSpec.Complete.
+func (s *Session) completeValue(spec CommandSpec, name string, kind ArgType, enum []string, own func(string, CommandRuntime) []string, prefix string) []Candidate {
+	var values []string
+	switch kind {
+	case ArgTypeEnum:
+		values = append(values, enum...)
+	case ArgTypeBool:
+		values = append(values, "true", "false")
+	case ArgTypePath:
+		values = completePath(prefix)
+	case ArgTypePayload:
+		values = s.payloadCandidateNames()
+	case ArgTypeTime:
+		values = append(values, "now", "today", "yesterday")
+	}
+	candidates := make([]Candidate, 0, len(values))
+	for _, v := range values {
+		candidates = append(candidates, Candidate{Value: v})
+	}
+	if own == nil && spec.Complete == nil {
+		return candidates
+	}
+	key := "value\x00" + spec.Context + "\x00" + spec.Name + "\x00" + name + "\x00" + prefix
+	return append(candidates, s.cachedCompletion(key, func() []Candidate {
+		var dynamic []string
+		if own != nil {
+			dynamic = append(dynamic, own(prefix, s.completionRuntime())...)
+		}
+		if spec.Complete != nil {
+			dynamic = append(dynamic, spec.Complete(name, prefix, s.completionRuntime())...)
+		}
+		out := make([]Candidate, 0, len(dynamic))
+		for _, v := range dynamic {
+			out = append(out, Candidate{Value: v})
+		}
+		return out
+	})...)
+}
+
+// scanArgs walks already-typed tokens, returning the number of positional
+// arguments consumed and the flag still awaiting a value, if any.
+func scanArgs(c *compiledSpec, args []string) (int, *FlagSpec) {
+	flags := c.flags
+	positional := 0
+	endOfFlags := false
+	var pendingFlag *FlagSpec
+	for _, token := range args {
+		if pendingFlag != nil {
+			pendingFlag = nil
+			continue
+		}
+		if token == "--" && !endOfFlags {
+			endOfFlags = true
+			continue
+		}
+		if strings.HasPrefix(token, "-") && token != "-" && !endOfFlags {
+			if strings.Contains(token, "=") {
+				continue
+			}
+			name := strings.TrimLeft(token, "-")
+			if !strings.HasPrefix(token, "--") {
+				if long, ok := flags.resolveShorthand(name); ok {
+					name = long
+				}
+			}
+			if flag, ok := flags.lookup(name); ok && flag.Type != ArgTypeBool {
+				f := flag
+				pendingFlag = &f
+			}
+			continue
+		}
+		positional++
+	}
+	return positional, pendingFlag
+}
+
+// completionRuntime builds a throwaway runtime for completion callbacks.
+func (s *Session) completionRuntime() CommandRuntime {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := NewOutputChannel(io.Discard)
+	out.SetLevel(OutputQuiet)
+	return &executionRuntime{
+		session:  s,
+		ctx:      ctx,
+		cancel:   cancel,
+		output:   out,
+		pipeline: s.contexts.Current().Payload,
+		meta:     s.invocationMeta(time.Now()),
+	}
+}
+
+func completePath(prefix string) []string {
+	dir, base := filepath.Split(prefix)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		full := dir + name
+		if entry.IsDir() {
+			full += string(filepath.Separator)
+		}
+		matches = append(matches, full)
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func commonPrefix(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	prefix := values[0]
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// suffixCandidates converts candidates into the suffix form readline expects.
+func suffixCandidates(candidates []Candidate, prefix string) [][]rune {
+	out := make([][]rune, 0, len(candidates))
+	for _, c := range candidates {
+		suffix := strings.TrimPrefix(c.Value, prefix)
+		if !strings.HasSuffix(c.Value, string(filepath.Separator)) {
+			suffix += " "
+		}
+		out = append(out, []rune(suffix))
+	}
+	return out
+}
+
+// completionIndex caches the candidates offered for an empty command line, per
+// context, so large registries are not rescanned on every keystroke. The cache
+// is dropped whenever the registry generation changes.
+type completionIndex struct {
+	mu         sync.Mutex
+	generation uint64
+	entries    map[string][]Candidate
+}
+
+// lookup returns the cached candidates for key, calling build on a miss.
+// The result must not be modified.
+func (c *completionIndex) lookup(registry *CommandRegistry, key string, build func() []Candidate) []Candidate {
+	gen := registry.Generation()
+	c.mu.Lock()
+	if c.entries == nil || c.generation != gen {
+		c.entries = map[string][]Candidate{}
+		c.generation = gen
+	}
+	if cached, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		return cached[:len(cached):len(cached)]
+	}
+	c.mu.Unlock()
+	candidates := build()
+	c.mu.Lock()
+	if c.generation == gen {
+		c.entries[key] = candidates
+	}
+	c.mu.Unlock()
+	return candidates[:len(candidates):len(candidates)]
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithCompletionCacheTTL caches the candidates of dynamic completion callbacks
+// (ArgSpec/FlagSpec/CommandSpec Complete, CompletionProvider, and context
+// payload completion) per session and context for ttl, so slow sources such as
+// remote APIs are queried once per TTL rather than on every Tab. `completion
+// refresh` drops the cache early. Caching is off when ttl is zero.
+func WithCompletionCacheTTL(ttl time.Duration) Option {
+	return func(e *Engine) { e.completionTTL = ttl }
+}
+
+// completionCache holds a session's dynamic completion results.
+type completionCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedCandidates
+}
+
+type cachedCandidates struct {
+	candidates []Candidate
+	expires    time.Time
+}
+
+// cachedCompletion returns the candidates cached under key in the current
+// context, calling build when caching is off or the entry is missing or expired.
+func (s *Session) cachedCompletion(key string, build func() []Candidate) []Candidate {
+	ttl := s.engine.completionTTL
+	if ttl <= 0 {
+		return build()
+	}
+	key = s.contexts.Current().Spec.Name + "\x00" + key
+	now := time.Now()
+	c := &s.completionCache
+	c.mu.Lock()
+	if cached, ok := c.entries[key]; ok && now.Before(cached.expires) {
+		c.mu.Unlock()
+		return cached.candidates[:len(cached.candidates):len(cached.candidates)]
+	}
+	c.mu.Unlock()
+	candidates := build()
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string]cachedCandidates{}
+	}
+	c.entries[key] = cachedCandidates{candidates: candidates, expires: now.Add(ttl)}
+	c.mu.Unlock()
+	return candidates[:len(candidates):len(candidates)]
+}
+
+// RefreshCompletions drops the session's cached completion results and returns
+// how many were dropped.
+func (s *Session) RefreshCompletions() int {
+	c := &s.completionCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.entries)
+	c.entries = nil
+	return n
+}
+
+// completion command ----------------------------------------------------------
+
+type completionCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *completionCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "completion",
+			Summary:     "Manage tab completion",
+			Description: "refresh drops cached completion results so the next Tab queries their sources again.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"refresh"}, Required: true, Description: "Action to perform"},
+			},
+			Examples: []Example{{Description: "Re-fetch device names", Command: "completion refresh"}},
+		}
+	}
+	return f.spec
+}
+
+func (f *completionCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &completionCommand{spec: f.Spec()}, nil
+}
+
+type completionCommand struct {
+	spec CommandSpec
+}
+
+func (c *completionCommand) Spec() CommandSpec { return c.spec }
+
+func (c *completionCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "completion requires an engine session", Severity: SeverityError}}
+	}
+	n := session.RefreshCompletions()
+	rt.Output().Info(fmt.Sprintf("Dropped %d cached completion result(s).", n))
+	return CommandResult{Status: StatusSuccess, Payload: n}
+}
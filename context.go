@@ -15,6 +15,11 @@ type ContextSpec struct {
 	Aliases     []string
 	Tags        []string
 	Hidden      bool
+	// CompletePayload offers payload candidates (e.g. IDs) for `ctx goto <name> <payload>`.
+	CompletePayload CompleteFunc
+	// Middleware wraps the commands of this context and of contexts whose
+	// Parent chain leads here, inside engine middleware.
+	Middleware []Middleware
 }
 
 // ExecutionContext is an active context on the stack.
@@ -60,7 +65,7 @@ func (m *ContextManager) Navigate(name string, payload any) error {
 	}
 	spec, ok := m.registry.Context(name)
 	if !ok {
-		return fmt.Errorf("unknown context: %s", name)
+		return unknownContextError(name)
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -72,7 +77,7 @@ func (m *ContextManager) Navigate(name string, payload any) error {
 func (m *ContextManager) Push(name string, payload any) error {
 	spec, ok := m.registry.Context(name)
 	if !ok {
-		return fmt.Errorf("unknown context: %s", name)
+		return unknownContextError(name)
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
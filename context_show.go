@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// showContext renders the current context's spec, the stack leading to it,
+// its payload with type, and its state, for `ctx show`.
+func (s *Session) showContext(out OutputChannel) {
+	stack := s.contexts.Stack()
+	current := stack[len(stack)-1]
+	spec := current.Spec
+
+	names := make([]string, len(stack))
+	for i, ctx := range stack {
+		names[i] = contextLabel(ctx.Spec.Name)
+	}
+	out.Info(fmt.Sprintf("Context: %s", contextLabel(spec.Name)))
+	if spec.Parent != "" {
+		out.Info(fmt.Sprintf("  Parent:      %s", spec.Parent))
+	}
+	if spec.Description != "" {
+		out.Info(fmt.Sprintf("  Description: %s", spec.Description))
+	}
+	if spec.Prompt != "" {
+		out.Info(fmt.Sprintf("  Prompt:      %q", spec.Prompt))
+	}
+	if len(spec.Aliases) > 0 {
+		out.Info(fmt.Sprintf("  Aliases:     %s", strings.Join(spec.Aliases, ", ")))
+	}
+	if len(spec.Tags) > 0 {
+		out.Info(fmt.Sprintf("  Tags:        %s", strings.Join(spec.Tags, ", ")))
+	}
+	out.Info(fmt.Sprintf("Stack: %s", strings.Join(names, " > ")))
+
+	if current.Payload == nil {
+		out.Info("Payload: none")
+	} else {
+		out.Info(fmt.Sprintf("Payload (%s):", payloadTypeLabel(current.Payload)))
+		for _, line := range strings.Split(formatPayload(current.Payload), "\n") {
+			out.Info("  " + line)
+		}
+	}
+
+	if len(current.State) == 0 {
+		out.Info("State: empty")
+		return
+	}
+	keys := make([]string, 0, len(current.State))
+	for key := range current.State {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out.Info("State:")
+	for _, key := range keys {
+		v := current.State[key]
+		out.Info(fmt.Sprintf("  %s (%T): %v", key, v, v))
+	}
+}
+
+func contextLabel(name string) string {
+	if name == "" {
+		return "root"
+	}
+	return name
+}
+
+// payloadTypeLabel names the Go type of v and, when it declares one, its
+// pipeline type.
+func payloadTypeLabel(v any) string {
+	label := fmt.Sprintf("%T", v)
+	if typed, ok := v.(PipelineTyped); ok {
+		label += ", pipeline " + string(typed.PipelineType())
+	}
+	return label
+}
+
+// formatPayload pretty-prints v as indented JSON, falling back to %+v for
+// values JSON cannot represent.
+func formatPayload(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	return string(data)
+}
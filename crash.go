@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// maxCrashes is how many crashes within crashWindow the read loop survives
+// before giving up, so a panic on every prompt does not spin forever.
+const (
+	maxCrashes  = 5
+	crashWindow = time.Minute
+)
+
+// CrashReport describes a panic caught outside a command: in the read loop,
+// prompt rendering, completion, or hint painting.
+type CrashReport struct {
+	Time    time.Time
+	Session string
+	Context string
+	// Where names the part of the shell that panicked, e.g. "read loop".
+	Where string
+	// Line is the command line being handled, if any.
+	Line  string
+	Panic any
+	Stack []byte
+	// Path is the file the report was written to, or "" if writing failed.
+	Path string
+}
+
+// String renders the report as written to the crash file.
+func (r CrashReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\nsession: %s\ncontext: %s\nwhere: %s\n", r.Time.Format(time.RFC3339), r.Session, r.Context, r.Where)
+	if r.Line != "" {
+		fmt.Fprintf(&b, "line: %s\n", r.Line)
+	}
+	fmt.Fprintf(&b, "panic: %v\n\n%s", r.Panic, r.Stack)
+	return b.String()
+}
+
+// WithCrashHandler calls fn after the shell recovers from a panic outside a
+// command, e.g. to forward the report to an error tracker. Commands are
+// covered by RecoveryMiddleware instead.
+func WithCrashHandler(fn func(CrashReport)) Option {
+	return func(e *Engine) {
+		if fn != nil {
+			e.crashHandlers = append(e.crashHandlers, fn)
+		}
+	}
+}
+
+// WithCrashReportDir sets where crash reports are written; the default is the
+// system temporary directory.
+func WithCrashReportDir(dir string) Option {
+	return func(e *Engine) { e.crashDir = dir }
+}
+
+// recoverCrash handles a recovered panic: it writes a crash report, tells the
+// operator, and runs the crash handlers.
+func (s *Session) recoverCrash(where, line string, r any) CrashReport {
+	report := CrashReport{
+		Time:    time.Now(),
+		Session: s.id,
+		Context: s.contexts.Current().Spec.Name,
+		Where:   where,
+		Line:    s.redactLine(tokenize(line)),
+		Panic:   r,
+		Stack:   debug.Stack(),
+	}
+	dir := s.engine.crashDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	path := filepath.Join(dir, fmt.Sprintf("planetui-crash-%s.log", report.Time.Format("20060102-150405.000")))
+	if err := os.WriteFile(path, []byte(report.String()), 0o600); err == nil {
+		report.Path = path
+	}
+	msg := fmt.Sprintf("Internal error in %s: %v", where, r)
+	if report.Path != "" {
+		msg += "; crash report saved to " + report.Path
+	}
+	fmt.Fprintln(s.OutputWriter(), Colorize(msg, s.engine.Theme().Error))
+	s.engine.mu.RLock()
+	handlers := s.engine.crashHandlers
+	s.engine.mu.RUnlock()
+	for _, fn := range handlers {
+		fn(report)
+	}
+	return report
+}
+
+// crashBarrier runs loop, restarting it from the root context after a panic
+// until it returns or crashes maxCrashes times within crashWindow. line reports
+// the command line being handled, for the crash report.
+func (s *Session) crashBarrier(line func() string, loop func() error) error {
+	var crashes []time.Time
+	for {
+		err, panicked := guardLoop(loop)
+		if panicked == nil {
+			return err
+		}
+		s.recoverCrash("read loop", line(), panicked)
+		func() {
+			defer func() { recover() }()
+			_ = s.contexts.PopToRoot()
+		}()
+		now := time.Now()
+		crashes = append(crashes, now)
+		for len(crashes) > 0 && now.Sub(crashes[0]) > crashWindow {
+			crashes = crashes[1:]
+		}
+		if len(crashes) >= maxCrashes {
+			return fmt.Errorf("giving up after %d crashes within %s: %v", len(crashes), crashWindow, panicked)
+		}
+	}
+}
+
+func guardLoop(loop func() error) (err error, panicked any) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = r
+		}
+	}()
+	return loop(), nil
+}
@@ -0,0 +1,327 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiffEntry is one difference between two payloads. Op is "+" (added),
+// "-" (removed), or "~" (changed).
+type DiffEntry struct {
+	Op     string `json:"op"`
+	Key    string `json:"key"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// DiffReport describes how a payload changed.
+type DiffReport struct {
+	Added   int         `json:"added"`
+	Removed int         `json:"removed"`
+	Changed int         `json:"changed"`
+	Entries []DiffEntry `json:"entries"`
+}
+
+// DiffPayloads compares two payloads. Tables (and lists of objects) with the
+// same columns are matched row by row on their first column; anything else is
+// compared field by field on its JSON paths.
+func DiffPayloads(before, after any) DiffReport {
+	var report DiffReport
+	if entries, ok := diffTables(before, after); ok {
+		report.Entries = entries
+	} else {
+		report.Entries = diffFields(flattenPayload(before), flattenPayload(after))
+	}
+	if report.Entries == nil {
+		report.Entries = []DiffEntry{}
+	}
+	for _, e := range report.Entries {
+		switch e.Op {
+		case "+":
+			report.Added++
+		case "-":
+			report.Removed++
+		default:
+			report.Changed++
+		}
+	}
+	return report
+}
+
+func diffTables(before, after any) ([]DiffEntry, bool) {
+	bh, brows, ok := tabulate(before)
+	if !ok || len(bh) == 0 {
+		return nil, false
+	}
+	ah, arows, ok := tabulate(after)
+	if !ok || strings.Join(ah, "\x00") != strings.Join(bh, "\x00") {
+		return nil, false
+	}
+	bindex, ok := rowsByKey(brows)
+	if !ok {
+		return nil, false
+	}
+	aindex, ok := rowsByKey(arows)
+	if !ok {
+		return nil, false
+	}
+	var entries []DiffEntry
+	for _, row := range arows {
+		key := cell(row, 0)
+		old, existed := bindex[key]
+		if !existed {
+			entries = append(entries, DiffEntry{Op: "+", Key: key, After: joinCells(row[min(1, len(row)):])})
+			continue
+		}
+		var changes, was []string
+		for i := 1; i < len(bh); i++ {
+			if cell(old, i) != cell(row, i) {
+				changes = append(changes, fmt.Sprintf("%s=%s", strings.TrimSpace(bh[i]), cell(row, i)))
+				was = append(was, fmt.Sprintf("%s=%s", strings.TrimSpace(bh[i]), cell(old, i)))
+			}
+		}
+		if len(changes) > 0 {
+			entries = append(entries, DiffEntry{Op: "~", Key: key, Before: strings.Join(was, " "), After: strings.Join(changes, " ")})
+		}
+	}
+	for _, row := range brows {
+		if _, kept := aindex[cell(row, 0)]; !kept {
+			entries = append(entries, DiffEntry{Op: "-", Key: cell(row, 0), Before: joinCells(row[min(1, len(row)):])})
+		}
+	}
+	return entries, true
+}
+
+// rowsByKey indexes rows by their first cell, failing when keys repeat.
+func rowsByKey(rows [][]string) (map[string][]string, bool) {
+	index := make(map[string][]string, len(rows))
+	for _, row := range rows {
+		key := cell(row, 0)
+		if _, dup := index[key]; dup {
+			return nil, false
+		}
+		index[key] = row
+	}
+	return index, true
+}
+
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+func joinCells(cells []string) string {
+	return strings.Join(cells, "  ")
+}
+
+func diffFields(before, after map[string]string) []DiffEntry {
+	keys := make([]string, 0, len(before)+len(after))
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var entries []DiffEntry
+	for _, k := range keys {
+		old, hadOld := before[k]
+		cur, hasCur := after[k]
+		switch {
+		case !hadOld:
+			entries = append(entries, DiffEntry{Op: "+", Key: k, After: cur})
+		case !hasCur:
+			entries = append(entries, DiffEntry{Op: "-", Key: k, Before: old})
+		case old != cur:
+			entries = append(entries, DiffEntry{Op: "~", Key: k, Before: old, After: cur})
+		}
+	}
+	return entries
+}
+
+// flattenPayload maps each scalar in v's JSON form to its path, e.g. "peers[0].state".
+func flattenPayload(v any) map[string]string {
+	out := map[string]string{}
+	generic, err := toGenericJSON(v)
+	if err != nil {
+		out["$"] = fmt.Sprint(v)
+		return out
+	}
+	var walk func(path string, v any)
+	walk = func(path string, v any) {
+		switch t := v.(type) {
+		case map[string]any:
+			for k, item := range t {
+				if path == "" {
+					walk(k, item)
+				} else {
+					walk(path+"."+k, item)
+				}
+			}
+		case []any:
+			for i, item := range t {
+				walk(path+"["+strconv.Itoa(i)+"]", item)
+			}
+		default:
+			if path == "" {
+				path = "$"
+			}
+			out[path] = cellString(t)
+		}
+	}
+	walk("", generic)
+	return out
+}
+
+// renderDiff writes one coloured line per entry followed by a summary.
+func renderDiff(out OutputChannel, report DiffReport) {
+	if len(report.Entries) == 0 {
+		out.Info("No differences.")
+		return
+	}
+	for _, e := range report.Entries {
+		switch e.Op {
+		case "+":
+			out.Info(Colorize(strings.TrimSpace("+ "+e.Key+"  "+e.After), ansiGreen))
+		case "-":
+			out.Info(Colorize(strings.TrimSpace("- "+e.Key+"  "+e.Before), ansiRed))
+		default:
+			out.Info(Colorize(fmt.Sprintf("~ %s  %s -> %s", e.Key, e.Before, e.After), ansiYellow))
+		}
+	}
+	out.Info(fmt.Sprintf("%d added, %d removed, %d changed", report.Added, report.Removed, report.Changed))
+}
+
+// diff command ----------------------------------------------------------------
+
+type diffCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *diffCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:    "diff",
+			Summary: "Show how a command's result changed",
+			Description: "With two @payload references, compares them. With a command, runs it and compares its payload against --against, " +
+				"or runs it again after --wait (or after Enter is pressed) and compares the two runs.",
+			Context: "",
+			Args: []ArgSpec{
+				{Name: "command", Type: ArgTypeString, Required: true, Repeatable: true, Passthrough: true, Description: "Command line to run, or two @payload references"},
+			},
+			Flags: []FlagSpec{
+				{Name: "wait", Shorthand: "w", Type: ArgTypeDuration, Description: "Re-run the command after this long"},
+				{Name: "against", Type: ArgTypePayload, Description: "Stored payload to compare the command's result with"},
+			},
+			Examples: []Example{
+				{Description: "Compare two saved payloads", Command: "diff @before @after"},
+				{Description: "Watch a route table change", Command: "diff --wait 30s routes show"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *diffCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &diffCommand{spec: f.Spec()}, nil
+}
+
+type diffCommand struct {
+	spec CommandSpec
+}
+
+func (c *diffCommand) Spec() CommandSpec { return c.spec }
+
+func (c *diffCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "diff requires an engine session", Severity: SeverityError}}
+	}
+	tokens := input.Args.Strings("command")
+	if len(tokens) == 2 && strings.HasPrefix(tokens[0], PayloadRefPrefix) && strings.HasPrefix(tokens[1], PayloadRefPrefix) {
+		var values [2]any
+		for i, ref := range tokens {
+			stored, ok := session.payloads.Get(ref)
+			if !ok {
+				return diffFailure(fmt.Errorf("%w: %s", ErrUnknownPayload, ref))
+			}
+			values[i] = stored.Value
+		}
+		return c.report(rt, values[0], values[1])
+	}
+
+	entry, args, err := session.resolveCommand(tokens)
+	if err != nil {
+		return diffFailure(err)
+	}
+	if entry.Spec.Name == c.spec.Name {
+		return diffFailure(errors.New("diff cannot run itself"))
+	}
+	run := func() (any, error) {
+		result, err := session.invoke(entry, args, io.Discard)
+		if err != nil {
+			return nil, err
+		}
+		if result.Status == StatusFailed {
+			return nil, fmt.Errorf("%s failed", entry.Spec.Name)
+		}
+		if result.Payload == nil {
+			return nil, fmt.Errorf("%s returned no payload to compare", entry.Spec.Name)
+		}
+		return result.Payload, nil
+	}
+
+	if against, ok := input.Flags.Raw("against"); ok {
+		after, err := run()
+		if err != nil {
+			return diffFailure(err)
+		}
+		return c.report(rt, against, after)
+	}
+
+	before, err := run()
+	if err != nil {
+		return diffFailure(err)
+	}
+	if wait := input.Flags.Duration("wait"); wait > 0 {
+		ctx, cancel := signal.NotifyContext(rt.Cancellation(), os.Interrupt)
+		defer cancel()
+		rt.Output().Info(fmt.Sprintf("Waiting %s before re-running %s...", wait, entry.Spec.Name))
+		select {
+		case <-ctx.Done():
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "diff cancelled", Severity: SeverityWarning}}
+		case <-time.After(wait):
+		}
+	} else if _, err := session.Ask("Press Enter to run it again and compare..."); err != nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError, Hints: []string{"use --wait DURATION or --against @payload"}}}
+	}
+	after, err := run()
+	if err != nil {
+		return diffFailure(err)
+	}
+	return c.report(rt, before, after)
+}
+
+func (c *diffCommand) report(rt CommandRuntime, before, after any) CommandResult {
+	report := DiffPayloads(before, after)
+	if rt.Output().Format() == OutputFormatTable {
+		// Other formats render the payload.
+		renderDiff(rt.Output(), report)
+	}
+	return CommandResult{Status: StatusSuccess, Payload: report}
+}
+
+func diffFailure(err error) CommandResult {
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+}
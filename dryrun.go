@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetDryRun turns session-wide dry-run mode on or off; while on, every command
+// receives CommandInput.DryRun as if --dry-run were given.
+func (s *Session) SetDryRun(on bool) { s.dryRun.Store(on) }
+
+// DryRun reports whether session-wide dry-run mode is on.
+func (s *Session) DryRun() bool { return s.dryRun.Load() }
+
+// DryRunMiddleware marks the output of dry-run invocations so operators do not
+// mistake them for real changes. The set command, which switches the mode, is
+// left alone.
+func DryRunMiddleware(rt CommandRuntime, input CommandInput, entry CommandEntry, next NextFunc) CommandResult {
+	if _, toggle := entry.Factory.(*setCommandFactory); !input.DryRun || toggle {
+		return next(rt, input)
+	}
+	rt.Output().Warn(fmt.Sprintf("dry run: %s will not apply changes", entry.Spec.Name))
+	result := next(rt, input)
+	if result.Summary != nil {
+		summary := *result.Summary
+		if summary.Note == "" {
+			summary.Note = "dry run"
+		} else {
+			summary.Note = "dry run: " + summary.Note
+		}
+		result.Summary = &summary
+	}
+	return result
+}
+
+// set command -----------------------------------------------------------------
+
+type setCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *setCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "set",
+			Summary:     "Show or change session settings and variables",
+			Description: "Without arguments, lists the session settings and variables. dry-run on makes every command report what it would change instead of changing it. NAME=value sets a variable that later command lines use as $NAME, ${NAME}, or ${NAME:-default}; NAME= removes it.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "setting", Type: ArgTypeString, Description: "Setting to show or change, or NAME=value", Complete: completeSettings},
+				{Name: "value", Type: ArgTypeString, Repeatable: true, Description: "New value", Complete: completeSettingValues},
+			},
+			Examples: []Example{
+				{Description: "Preview changes only", Command: "set dry-run on"},
+				{Description: "Set a variable", Command: "set region=eu-west-1"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *setCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &setCommand{spec: f.Spec()}, nil
+}
+
+type setCommand struct {
+	spec CommandSpec
+}
+
+func (c *setCommand) Spec() CommandSpec { return c.spec }
+
+func (c *setCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return setFailure(errors.New("set requires an engine session"))
+	}
+	setting := input.Args.String("setting")
+	values := input.Args.Strings("value")
+	if name, value, assign := strings.Cut(setting, "="); assign {
+		value = strings.Join(append([]string{value}, values...), " ")
+		if err := session.SetVar(name, strings.TrimSpace(value)); err != nil {
+			return setFailure(err)
+		}
+		return CommandResult{Status: StatusSuccess}
+	}
+	switch setting {
+	case "":
+	case "dry-run":
+		switch strings.Join(values, " ") {
+		case "":
+		case "on":
+			session.SetDryRun(true)
+		case "off":
+			session.SetDryRun(false)
+		default:
+			return setFailure(fmt.Errorf("dry-run must be on or off, not %q", strings.Join(values, " ")))
+		}
+	default:
+		err := fmt.Errorf("unknown setting: %s", setting)
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), ErrCode: ErrCodeUsage, Severity: SeverityError, Hints: []string{"set a variable with set " + setting + "=value"}}}
+	}
+	state := "off"
+	if session.DryRun() {
+		state = "on"
+	}
+	rows := [][]string{{"dry-run", state}}
+	if setting == "" {
+		vars := session.Vars()
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			rows = append(rows, []string{"$" + name, vars[name]})
+		}
+	}
+	rt.Output().WriteTable([]string{"Setting", "Value"}, rows)
+	return CommandResult{Status: StatusSuccess, Payload: map[string]bool{"dry-run": session.DryRun()}}
+}
+
+func completeSettings(prefix string, rt CommandRuntime) []string {
+	candidates := []string{"dry-run"}
+	for _, name := range completeVarNames("", rt) {
+		candidates = append(candidates, name+"=")
+	}
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+func completeSettingValues(prefix string, rt CommandRuntime) []string {
+	var matches []string
+	for _, v := range []string{"on", "off"} {
+		if strings.HasPrefix(v, prefix) {
+			matches = append(matches, v)
+		}
+	}
+	return matches
+}
+
+func setFailure(err error) CommandResult {
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+}
@@ -14,26 +14,75 @@ import (
 	"github.com/chzyer/readline"
 )
 
-// Middleware wraps command execution with cross-cutting logic.
+// Middleware wraps command execution with cross-cutting logic. It may pass next
+// a modified copy of the input, changing values with ValueSet.With and Without
+// so the caller's input is untouched, and may inspect or change the result next
+// returns. InputMiddleware and ResultMiddleware cover the common cases.
 type Middleware func(CommandRuntime, CommandInput, CommandEntry, NextFunc) CommandResult
 
 // NextFunc represents the next handler in the middleware chain.
 type NextFunc func(CommandRuntime, CommandInput) CommandResult
 
 // Engine orchestrates command resolution and execution.
+// Per-operator state lives in Session; the registry, services, and middleware are shared.
 type Engine struct {
-	registry     *CommandRegistry
-	contexts     *ContextManager
-	session      SessionStore
-	services     ServiceRegistry
-	parser       *ArgsParser
-	middleware   []Middleware
-	outputWriter io.Writer
-	outputLevel  OutputLevel
-	helpHeader   string
-	promptBase   string
-	tasks        *TaskManager
-	mu           sync.RWMutex
+	registry           *CommandRegistry
+	services           ServiceRegistry
+	parser             *ArgsParser
+	middleware         []Middleware
+	outputWriter       io.Writer
+	outputLevel        OutputLevel
+	helpHeader         string
+	promptBase         string
+	converters         *PipelineConverters
+	sessions           map[string]*Session
+	sessionSeq         int
+	defaultSession     *Session
+	authenticator      Authenticator
+	authListeners      []AuthListener
+	permissions        PermissionResolver
+	plainMode          bool
+	completion         CompletionEngine
+	completions        completionIndex
+	completionTTL      time.Duration
+	metrics            *MetricsRegistry
+	interruptTasks     bool
+	rightPrompt        rightPromptCache
+	defaultTimeout     time.Duration
+	crashHandlers      []func(CrashReport)
+	crashDir           string
+	describeCandidates bool
+	inlineHints        bool
+	resultLimit        int
+	undoListeners      []UndoListener
+	snippets           *SnippetLibrary
+	aliases            *AliasManager
+	errorCodes         []ErrorCodeInfo
+	hintProviders      []HintProvider
+	troubleshooters    troubleshooters
+	workflows          workflows
+	approvals          ApprovalBackend
+	approvalTimeout    time.Duration
+	changeAppliers     map[string]ChangeApplier
+	pprof              pprofServer
+	memory             MemoryBudget
+	statusSummary      bool
+	advisories         *AdvisoryService
+	idle               IdleOptions
+	history            *HistoryManager
+	noArgPrompt        bool
+	usageRecorders     []UsageRecorder
+	started            time.Time
+	theme              Theme
+	payloadLimit       int
+	maxTasks           int
+	router             *OutputRouter
+	exitCodes          ExitCodes
+	notifier           NotificationSink
+	notifierSet        bool
+	startup            []StartupPhase
+	startupProfile     io.Writer
+	mu                 sync.RWMutex
 }
 
 // Option configures the engine.
@@ -79,44 +128,135 @@ func WithOutputWriter(w io.Writer) Option {
 	}
 }
 
+// WithPipelineConverter registers a converter between pipeline payload types.
+func WithPipelineConverter(from, to PipelineType, fn PipelineConverter) Option {
+	return func(e *Engine) { e.converters.Register(from, to, fn) }
+}
+
 // NewEngine constructs an Engine with defaults.
 func NewEngine(options ...Option) *Engine {
-	registry := NewCommandRegistry()
-	contexts := NewContextManager(registry)
-	session := NewSessionStore()
-	services := NewServiceRegistry()
+	start := time.Now()
 	engine := &Engine{
-		registry:     registry,
-		contexts:     contexts,
-		session:      session,
-		services:     services,
+		registry:     NewCommandRegistry(),
+		services:     NewServiceRegistry(),
 		parser:       NewArgsParser(),
+		converters:   NewPipelineConverters(),
 		outputWriter: os.Stdout,
 		outputLevel:  OutputNormal,
 		helpHeader:   "Available commands:",
 		promptBase:   "> ",
+		sessions:     map[string]*Session{},
+		completion:   NewRegistryCompletionEngine(PrefixMatch),
+		snippets:     NewSnippetLibrary(),
+		aliases:      NewAliasManager(),
+		advisories:   NewAdvisoryService(),
+		history:      NewHistoryManager(DefaultHistorySize),
+		started:      time.Now(),
+		theme:        DefaultTheme(),
+		router:       NewOutputRouter(),
+		exitCodes:    DefaultExitCodes(),
 	}
 	engine.middleware = []Middleware{RecoveryMiddleware}
 	engine.registerBuiltins()
+	engine.startupPhase("init", start)
+	optionsStart := time.Now()
 	for _, opt := range options {
 		opt(engine)
 	}
-	engine.tasks = NewTaskManager(NewOutputChannel(engine.outputWriter))
+	engine.startupPhase("options", optionsStart)
+	engine.startConfiguredPprof()
+	sessionStart := time.Now()
+	engine.defaultSession = engine.NewSession(WithSessionID("default"))
+	engine.startupPhase("default session", sessionStart)
+	engine.startupPhase("total", start)
+	engine.writeStartupProfile()
 	return engine
 }
 
 // Registry exposes the command registry for external registration.
 func (e *Engine) Registry() *CommandRegistry { return e.registry }
 
-// Contexts returns the context manager.
-func (e *Engine) Contexts() *ContextManager { return e.contexts }
+// Contexts returns the context manager of the default session.
+func (e *Engine) Contexts() *ContextManager { return e.defaultSession.contexts }
 
-// Session exposes the session store.
-func (e *Engine) Session() SessionStore { return e.session }
+// Session exposes the default session's store.
+func (e *Engine) Session() SessionStore { return e.defaultSession.store }
+
+// DefaultSession returns the session used by Run and the package-level helpers.
+func (e *Engine) DefaultSession() *Session { return e.defaultSession }
+
+// NewSession creates and tracks an independent operator session.
+func (e *Engine) NewSession(opts ...SessionOption) *Session {
+	e.mu.Lock()
+	e.sessionSeq++
+	s := &Session{
+		id:            fmt.Sprintf("session-%d", e.sessionSeq),
+		engine:        e,
+		contexts:      NewContextManager(e.registry),
+		store:         NewSessionStore(),
+		output:        newSwapWriter(e.outputWriter),
+		outputLevel:   e.outputLevel,
+		active:        map[*DefaultOutputChannel]struct{}{},
+		queue:         newCommandQueue(),
+		results:       NewResultHistory(e.resultLimit),
+		payloads:      NewPayloadStore(e.payloadLimit),
+		undo:          NewUndoStack(DefaultUndoDepth),
+		acked:         map[string]time.Time{},
+		advisoryShown: map[string]bool{},
+		source:        SourceInteractive,
+	}
+	maxTasks := e.maxTasks
+	budget := e.memory
+	e.mu.Unlock()
+	for _, opt := range opts {
+		opt(s)
+	}
+	if store, ok := s.store.(*MemorySessionStore); ok && budget.SessionStore > 0 {
+		store.SetBudget(budget.SessionStore)
+	}
+	s.results.SetBudget(budget.Results)
+	taskOut := e.newOutputChannel(s.output)
+	taskOut.lineProgress = true
+	s.tasks = NewTaskManager(taskOut, WithMaxConcurrent(maxTasks), WithTaskOutputLimit(budget.TaskOutput, budget.TaskOutputTotal))
+	s.tasks.OnComplete(s.notifyTaskDone)
+	e.mu.Lock()
+	e.sessions[s.id] = s
+	e.mu.Unlock()
+	return s
+}
+
+// Sessions lists active sessions sorted by ID.
+func (e *Engine) Sessions() []*Session {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	list := make([]*Session, 0, len(e.sessions))
+	for _, s := range e.sessions {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].id < list[j].id })
+	return list
+}
+
+// LookupSession finds a session by ID.
+func (e *Engine) LookupSession(id string) (*Session, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	s, ok := e.sessions[id]
+	return s, ok
+}
+
+func (e *Engine) removeSession(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.sessions, id)
+}
 
 // Services exposes the service registry.
 func (e *Engine) Services() ServiceRegistry { return e.services }
 
+// Converters exposes the pipeline converter registry.
+func (e *Engine) Converters() *PipelineConverters { return e.converters }
+
 // RegisterContext adds a context specification to the registry.
 func (e *Engine) RegisterContext(spec ContextSpec) {
 	e.registry.RegisterContext(spec)
@@ -127,6 +267,37 @@ func (e *Engine) RegisterCommand(factory CommandFactory) {
 	e.registry.RegisterCommand(factory)
 }
 
+// RegisterFunc registers fn as the command described by spec, for commands
+// that need no factory state. See NewCommand for building the spec fluently.
+func (e *Engine) RegisterFunc(spec CommandSpec, fn func(CommandRuntime, CommandInput) CommandResult) {
+	if fn == nil {
+		panic("command " + spec.Name + " has no handler")
+	}
+	e.registry.RegisterCommand(&funcCommandFactory{spec: spec, fn: fn})
+}
+
+// Use appends middleware at runtime. The chain is copied on write, so in-flight
+// invocations keep the chain they started with while new ones see the update.
+func (e *Engine) Use(mw ...Middleware) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	chain := make([]Middleware, 0, len(e.middleware)+len(mw))
+	chain = append(chain, e.middleware...)
+	e.middleware = append(chain, mw...)
+}
+
+func (e *Engine) prompt() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.promptBase
+}
+
+func (e *Engine) header() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.helpHeader
+}
+
 // SetPrompt updates the base prompt string.
 func (e *Engine) SetPrompt(prompt string) {
 	e.mu.Lock()
@@ -145,182 +316,392 @@ func (e *Engine) SetHelpHeader(header string) {
 	}
 }
 
-// SetOutputLevel updates output verbosity.
+// SetOutputLevel updates output verbosity of the default session.
 func (e *Engine) SetOutputLevel(level OutputLevel) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	e.outputLevel = level
+	e.defaultSession.SetOutputLevel(level)
 }
 
-// SetOutputWriter swaps the engine's writer for command output, returning the previous writer.
+// SetOutputWriter swaps the default session's writer for command output, returning the previous writer.
 func (e *Engine) SetOutputWriter(w io.Writer) io.Writer {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	prev := e.outputWriter
-	if w == nil {
-		e.outputWriter = os.Stdout
-	} else {
-		e.outputWriter = w
-	}
-	if e.tasks != nil {
-		e.tasks.SetOutputChannel(NewOutputChannel(e.outputWriter))
-	}
-	return prev
+	return e.defaultSession.SetOutputWriter(w)
 }
 
-// Run starts the interactive loop.
+// Run starts the interactive loop on the default session.
 func (e *Engine) Run(rl *readline.Instance) error {
+	return e.defaultSession.Run(rl)
+}
+
+// Run starts the interactive loop for this session.
+// In plain mode, or when rl is nil and no terminal is attached, it falls back to RunPlain on stdin.
+func (s *Session) Run(rl *readline.Instance) error {
+	if s.engine.plainMode || (rl == nil && !TerminalAvailable()) {
+		return s.RunPlain(os.Stdin)
+	}
 	if rl == nil {
 		return errors.New("readline instance is required")
 	}
-	for {
-		e.refreshAutocomplete(rl)
-		prompt := e.contexts.Prompt(e.promptBase)
+	readLine := func(prompt string) (string, error) {
 		rl.SetPrompt(prompt)
-		line, err := rl.Readline()
-		if err != nil {
-			if errors.Is(err, readline.ErrInterrupt) {
-				return nil
+		return rl.Readline()
+	}
+	readPassword := func(prompt string) (string, error) {
+		pw, err := rl.ReadPassword(prompt)
+		return string(pw), err
+	}
+	defer s.attachInput(readLine)()
+	defer s.attachSecretInput(readPassword)()
+	defer s.attachNotice(func(message string) {
+		rl.Write([]byte(message + "\n"))
+	})()
+	filter := rl.Config.FuncFilterInputRune
+	rl.Config.FuncFilterInputRune = s.choiceFilter(rl)
+	defer func() { rl.Config.FuncFilterInputRune = filter }()
+	if rl.Config.HistoryFile == "" {
+		// Seed readline's recall from the persisted engine history.
+		for _, entry := range s.engine.history.Entries() {
+			_ = rl.SaveHistory(entry.Line)
+		}
+	}
+	idle := s.startIdle(func() { rl.Close() })
+	defer idle.pause()
+	current := ""
+	return s.crashBarrier(func() string { return current }, func() error {
+		// Restarting after a crash resumes the idle timer the crash left paused.
+		idle.touch()
+		for {
+			s.announceAdvisories(s.OutputWriter())
+			s.refreshAutocomplete(rl)
+			rl.SetPrompt(s.promptString())
+			line, err := rl.Readline()
+			if err != nil {
+				if errors.Is(err, readline.ErrInterrupt) {
+					return nil
+				}
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+			if idle.takeExpired() {
+				idle.pause()
+				if !s.unlock(readLine, readPassword) {
+					return nil
+				}
+				idle.touch()
+				continue
+			}
+			idle.touch()
+			line = strings.TrimSpace(line)
+			current = line
+			if line == "" {
+				continue
+			}
+			if line, err = s.expandHistory(line); err != nil {
+				s.reportError(err)
+				continue
 			}
-			if errors.Is(err, io.EOF) {
+			tokens, err := SplitCommandLine(line)
+			if err != nil {
+				s.reportError(err)
+				continue
+			}
+			if len(tokens) == 0 {
+				continue
+			}
+			if exitRequested(tokens[0]) {
+				fmt.Fprintf(s.OutputWriter(), "\nShutting down.\n")
 				return nil
 			}
-			return err
-		}
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		tokens := tokenize(line)
-		if len(tokens) == 0 {
-			continue
-		}
-		if exitRequested(tokens[0]) {
-			fmt.Fprintf(e.outputWriter, "\nShutting down.\n")
-			return nil
-		}
-		if err := rl.SaveHistory(line); err != nil {
-			fmt.Fprintf(e.outputWriter, "Error saving history: %v\n", err)
-		}
-		if err := e.process(tokens); err != nil {
-			fmt.Fprintf(e.outputWriter, "Error: %v\n", err)
+			if saved, ok := s.historyLine(tokens); ok {
+				if err := rl.SaveHistory(saved); err != nil {
+					fmt.Fprintf(s.OutputWriter(), "Error saving history: %v\n", err)
+				}
+				s.recordHistory(saved)
+			}
+			if line, err = s.collectInput(line); err != nil {
+				s.reportError(err)
+				continue
+			}
+			lexed, err := s.splitLine(line)
+			if err != nil {
+				s.reportError(err)
+				continue
+			}
+			if len(lexed) == 0 {
+				continue
+			}
+			idle.pause()
+			s.dispatchForeground(lexed)
+			current = ""
+			idle.touch()
 		}
-	}
+	})
 }
 
-func (e *Engine) refreshAutocomplete(rl *readline.Instance) {
-	ctx := e.contexts.Current().Spec.Name
-	if ctx == "" {
-		var items []readline.PrefixCompleterInterface
-		contexts := e.registry.Contexts(false)
-		for _, ctxSpec := range contexts {
-			commands := e.registry.Commands(ctxSpec.Name, false)
-			var subitems []readline.PrefixCompleterInterface
-			for _, cmd := range commands {
-				subitems = append(subitems, readline.PcItem(cmd.Name))
-			}
-			items = append(items, readline.PcItem(ctxSpec.Name, subitems...))
-		}
-		rootCmds := e.registry.Commands("", false)
-		for _, cmd := range rootCmds {
-			items = append(items, readline.PcItem(cmd.Name))
+func (s *Session) refreshAutocomplete(rl *readline.Instance) {
+	if s.engine.inlineHints || s.engine.Theme().RightPrompt != "" {
+		if p, ok := rl.Config.Painter.(*hintPainter); !ok || p.session != s {
+			rl.Config.Painter = &hintPainter{session: s}
 		}
-		rl.Config.AutoComplete = readline.NewPrefixCompleter(items...)
-		return
 	}
-	commands := e.registry.Commands(ctx, false)
-	var completions []string
-	for _, cmd := range commands {
-		completions = append(completions, cmd.Name)
+	if c, ok := rl.Config.AutoComplete.(*sessionCompleter); ok && c.session == s && c.rl == rl {
+		return
 	}
-	rl.Config.AutoComplete = readline.NewPrefixCompleter(
-		readline.PcItemDynamic(func(prefix string) []string { return completions }),
-	)
+	rl.Config.AutoComplete = &sessionCompleter{session: s, rl: rl}
+}
+
+func (s *Session) process(tokens []token) (CommandResult, error) {
+	result, err := s.execute(context.Background(), tokens)
+	s.setExitCode(s.engine.ExitCode(result, err))
+	s.exit.errCode.Store(ErrorCodeOf(result, err))
+	return result, err
 }
 
-func (e *Engine) process(tokens []string) error {
-	ctx := e.contexts.Current().Spec.Name
+// execute runs one lexed line, handling navigation built-ins, and returns the
+// command result. Cancelling ctx cancels the commands the line runs.
+func (s *Session) execute(ctx context.Context, lexed []token) (CommandResult, error) {
+	lexed = s.engine.aliases.expandTokens(lexed)
+	if len(lexed) == 0 {
+		return CommandResult{}, nil
+	}
+	if line, ok := backgroundLine(lexed); ok {
+		if len(pipeStages(line)) > 1 {
+			return CommandResult{}, errors.New("pipelines cannot run in the background")
+		}
+		return s.runBackground(words(line))
+	}
+	if stages := pipeStages(lexed); len(stages) > 1 {
+		return s.runPipeline(ctx, stages)
+	}
+	tokens := words(lexed)
+	current := s.contexts.Current().Spec.Name
 	switch tokens[0] {
 	case "help", "?", "h", "ls":
-		e.renderHelp(ctx)
-		return nil
+		out := s.engine.newOutputChannel(s.OutputWriter())
+		if len(tokens) > 1 {
+			entry, _, err := s.resolveCommand(tokens[1:])
+			if err != nil {
+				return CommandResult{}, err
+			}
+			renderCommandHelp(out, entry.Spec)
+			EnsureLineBreak(out)
+			return CommandResult{}, nil
+		}
+		s.engine.renderHelp(out, current, s.permitted)
+		return CommandResult{}, nil
 	case "contexts":
-		e.listContexts()
-		return nil
+		s.listContexts()
+		return CommandResult{}, nil
 	case "ctx":
-		return e.handleCtxCommand(tokens[1:])
+		return CommandResult{}, s.handleCtxCommand(tokens[1:])
 	case "switch":
-		return e.handleSwitchCommand(tokens[1:])
+		return CommandResult{}, s.handleSwitchCommand(tokens[1:])
 	case "cd":
-		return e.handleCDCommand(tokens[1:])
+		return CommandResult{}, s.handleCDCommand(tokens[1:])
 	case "back", "..":
-		return e.contexts.Pop()
+		return CommandResult{}, s.contexts.Pop()
 	case "/":
-		return e.contexts.PopToRoot()
-	case "history":
-		e.showHistory()
-		return nil
+		return CommandResult{}, s.contexts.PopToRoot()
 	}
 
-	ctx = e.contexts.Current().Spec.Name
-	if canonical, ok := e.registry.ResolveContextName(tokens[0]); ok && canonical != "" {
+	current = s.contexts.Current().Spec.Name
+	if canonical, ok := s.engine.registry.ResolveContextName(tokens[0]); ok && canonical != "" {
 		if len(tokens) == 1 {
-			if canonical == ctx {
-				return nil
+			if canonical == current {
+				return CommandResult{}, nil
 			}
-			return e.contexts.Navigate(canonical, nil)
+			return CommandResult{}, s.contexts.Navigate(canonical, nil)
 		}
-		if canonical != ctx {
-			if err := e.contexts.Navigate(canonical, nil); err != nil {
-				return err
+		if canonical != current {
+			if err := s.contexts.Navigate(canonical, nil); err != nil {
+				return CommandResult{}, err
 			}
-			ctx = e.contexts.Current().Spec.Name
+			current = s.contexts.Current().Spec.Name
 		}
 		tokens = tokens[1:]
-	} else if ctx != "" && tokens[0] == ctx {
+	} else if current != "" && tokens[0] == current {
 		tokens = tokens[1:]
 	}
 
 	if len(tokens) == 0 {
-		return nil
+		return CommandResult{}, nil
 	}
 
-	entry, ok := e.registry.Resolve(ctx, tokens[0])
-	if !ok {
-		return fmt.Errorf("unknown command: %s", tokens[0])
+	entry, err := s.engine.registry.ResolveCommand(current, tokens[0])
+	if err != nil {
+		return CommandResult{}, err
 	}
 
-	return e.invoke(entry, tokens[1:])
+	run := s.runCommand(ctx, entry, tokens, s.OutputWriter(), nil, false)
+	if run.suspended {
+		return run.result, nil
+	}
+	if run.err == nil && entry.Spec.Name != "result" {
+		s.results.Record(ResultRecord{
+			Command:      entry.Spec.Name,
+			Line:         run.line,
+			Status:       run.result.Status,
+			Payload:      run.result.Payload,
+			Error:        run.result.Error,
+			Summary:      run.result.Summary,
+			Duration:     time.Since(run.start),
+			Time:         run.start,
+			InvocationID: run.meta.ID,
+		})
+	}
+	return run.result, run.err
+}
+
+// commandRun is the outcome of runCommand.
+type commandRun struct {
+	result    CommandResult
+	err       error
+	suspended bool
+	// line is the command line with secret values redacted.
+	line  string
+	meta  InvocationMeta
+	start time.Time
 }
 
-func (e *Engine) invoke(entry CommandEntry, args []string) error {
-	parsedArgs, parsedFlags, err := e.parser.Parse(args, entry.Spec)
+// runCommand runs entry at the prompt through invokeForeground, offering to
+// repair invalid JSON flags and to prompt for missing arguments, and records
+// its usage and undo operation. tokens are the command word and its args; ctx,
+// w, in, and piped are as for invokePiped.
+func (s *Session) runCommand(ctx context.Context, entry CommandEntry, tokens []string, w io.Writer, in any, piped bool) commandRun {
+	start := time.Now()
+	meta := s.invocationMeta(start)
+	result, err, suspended := s.invokeForeground(ctx, meta, entry, tokens[1:], w, in, piped)
+	if suspended {
+		return commandRun{result: result, suspended: true, meta: meta, start: start}
+	}
+	if err != nil {
+		if fixed, ok := s.repairJSONFlag(tokens[1:], entry.Spec, err); ok {
+			tokens = append(tokens[:1:1], fixed...)
+			result, err = s.invokePiped(ctx, meta, entry, tokens[1:], w, in, piped)
+		}
+	}
+	if err != nil {
+		if filled, ok := s.promptMissingArgs(entry, tokens[1:], err); ok {
+			tokens = append(tokens[:1:1], filled...)
+			result, err = s.invokePiped(ctx, meta, entry, tokens[1:], w, in, piped)
+		}
+	}
+	status := result.Status
+	if err != nil {
+		status = StatusFailed
+	} else if status == "" {
+		status = StatusSuccess
+	}
+	s.engine.recordUsage(UsageEvent{Command: entry.Spec.Name, Context: entry.Spec.Context, Status: status, Duration: time.Since(start), Time: start, InvocationID: meta.ID})
+	line := QuoteCommandLine(append(tokens[:1:1], redactArgs(entry.resolved(), tokens[1:])...))
+	if err == nil && result.Status != StatusFailed && result.Undo != nil && result.Undo.Revert != nil {
+		s.recordUndo(UndoEntry{Command: entry.Spec.Name, Line: line, Operation: *result.Undo, Time: start, InvocationID: meta.ID})
+	}
+	return commandRun{result: result, err: err, line: line, meta: meta, start: start}
+}
+
+// resolveCommand finds the command named by tokens without navigating contexts.
+func (s *Session) resolveCommand(tokens []string) (CommandEntry, []string, error) {
+	ctx := s.contexts.Current().Spec.Name
+	if len(tokens) > 0 {
+		if canonical, ok := s.engine.registry.ResolveContextName(tokens[0]); ok && canonical != "" {
+			ctx = canonical
+			tokens = tokens[1:]
+		}
+	}
+	if len(tokens) == 0 {
+		return CommandEntry{}, nil, errors.New("missing command")
+	}
+	entry, err := s.engine.registry.ResolveCommand(ctx, tokens[0])
 	if err != nil {
-		return err
+		return CommandEntry{}, nil, err
 	}
+	return entry, tokens[1:], nil
+}
 
-	current := e.contexts.Current()
-	ctxObj, cancel := context.WithCancel(context.Background())
+// invoke parses args and runs entry through the middleware chain, writing output to w.
+func (s *Session) invoke(entry CommandEntry, args []string, w io.Writer) (CommandResult, error) {
+	return s.invokeAs(s.invocationMeta(time.Now()), entry, args, w)
+}
+
+// invokeAs is invoke for the invocation described by meta.
+func (s *Session) invokeAs(meta InvocationMeta, entry CommandEntry, args []string, w io.Writer) (CommandResult, error) {
+	return s.invokePiped(context.Background(), meta, entry, args, w, nil, false)
+}
+
+// invokePiped is invokeAs for a pipeline stage: when piped, in replaces the
+// current context's payload as the command's pipeline input. The invocation's
+// context is derived from ctx.
+func (s *Session) invokePiped(ctx context.Context, meta InvocationMeta, entry CommandEntry, args []string, w io.Writer, in any, piped bool) (CommandResult, error) {
+	start := time.Now()
+	compiled := entry.resolved()
+	if helpRequested(args, compiled) {
+		out := s.engine.newOutputChannel(w)
+		renderCommandHelp(out, entry.Spec)
+		EnsureLineBreak(out)
+		return CommandResult{Status: StatusSuccess}, nil
+	}
+
+	parsedArgs, parsedFlags, err := s.engine.parser.parse(args, compiled.spec, compiled.flags)
+	if err != nil {
+		return CommandResult{}, withUsage(err, entry.Spec)
+	}
+	level, override, err := outputLevelOverride(entry.Spec, parsedFlags)
+	if err != nil {
+		return CommandResult{}, withUsage(err, entry.Spec)
+	}
+	if err := s.resolvePayloadRefs(entry.Spec, parsedArgs, parsedFlags); err != nil {
+		return CommandResult{}, withUsage(err, entry.Spec)
+	}
+
+	source := s.contexts.Current().Payload
+	if piped {
+		source = in
+	}
+	pipeline, err := negotiatePipeline(s.engine.converters, source, entry.Spec.PipelineAccepts)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("%s: %w", entry.Spec.Name, err)
+	}
+	parent := withInvocationID(ctx, meta.ID)
+	ctxObj, cancel := context.WithCancel(parent)
+	timeout := s.engine.commandTimeout(entry.Spec)
+	if timeout > 0 {
+		cancel()
+		ctxObj, cancel = context.WithTimeout(parent, timeout)
+	}
+	out := s.engine.newOutputChannel(w)
+	out.bindContext(ctxObj)
+	defer s.trackOutput(out)()
+	if override {
+		out.SetLevel(level)
+	}
+	format, formatOverride := outputFormatOverride(entry.Spec, parsedFlags)
+	if formatOverride {
+		out.SetFormat(format)
+	}
 	execRT := &executionRuntime{
-		engine:   e,
+		session:  s,
 		ctx:      ctxObj,
 		cancel:   cancel,
-		output:   NewOutputChannel(e.outputWriter),
-		pipeline: current.Payload,
+		output:   out,
+		pipeline: pipeline,
+		meta:     meta,
+		tasks:    s.tasks.scoped("", meta.ID),
 	}
 	defer cancel()
-	execRT.output.SetLevel(e.outputLevel)
+	defer s.trackInvocation(meta.ID, cancel)()
 
 	input := CommandInput{
 		Context:  ctxObj,
 		Raw:      args,
 		Args:     parsedArgs,
 		Flags:    parsedFlags,
-		Pipeline: current.Payload,
+		Pipeline: pipeline,
+		DryRun:   s.DryRun() || parsedFlags.Bool("dry-run"),
 	}
 
-	handler := e.coreHandler(entry)
-	result := handler(execRT, input)
+	handler := s.engine.coreHandler(entry)
+	result := runBounded(ctxObj, timeout, entry.Spec, func() CommandResult { return handler(execRT, input) })
 	if result.Status == "" {
 		if result.Error != nil {
 			result.Status = StatusFailed
@@ -328,10 +709,17 @@ func (e *Engine) invoke(entry CommandEntry, args []string) error {
 			result.Status = StatusSuccess
 		}
 	}
+	result = s.engine.withProvidedHints(execRT, input, entry, result)
 
 	AggregateMessages(execRT.output, result.Messages)
+	if formatOverride && result.Status != StatusFailed && result.Payload != nil && !out.wroteStructured() {
+		renderFormatted(out, result.Payload)
+	}
 
-	if result.Error != nil {
+	if result.Error != nil && formatOverride && (format == OutputFormatJSON || format == OutputFormatYAML) {
+		// Structured output keeps the failure parseable, code included.
+		renderFormatted(execRT.output, map[string]any{"error": result.Error, "ref": meta.ID})
+	} else if result.Error != nil {
 		msg := result.Error.Message
 		if msg == "" && result.Error.Err != nil {
 			msg = result.Error.Err.Error()
@@ -340,6 +728,17 @@ func (e *Engine) invoke(entry CommandEntry, args []string) error {
 		for _, hint := range result.Error.Hints {
 			execRT.output.Info(fmt.Sprintf("hint: %s", hint))
 		}
+		execRT.output.Info("ref: " + meta.ID)
+	}
+
+	if result.Status != StatusFailed && result.Pipeline != nil && entry.Spec.PipelineProduces != "" {
+		converted, err := s.engine.converters.Convert(result.Pipeline, PipelineTypeOf(result.Pipeline), entry.Spec.PipelineProduces)
+		if err != nil {
+			execRT.output.Error(fmt.Sprintf("%s produced an invalid pipeline payload: %v", entry.Spec.Name, err))
+			result.Pipeline = nil
+		} else {
+			result.Pipeline = converted
+		}
 	}
 
 	if result.Status != StatusFailed {
@@ -353,31 +752,129 @@ func (e *Engine) invoke(entry CommandEntry, args []string) error {
 		}
 
 		if execRT.nextContext != "" {
-			if err := e.contexts.Navigate(execRT.nextContext, execRT.nextPayload); err != nil {
+			if err := s.contexts.Navigate(execRT.nextContext, execRT.nextPayload); err != nil {
 				execRT.output.Error(err.Error())
 			}
 		}
 	}
 
+	if s.engine.summaryEnabled() {
+		execRT.output.Info(statusSummary(result.Status, time.Since(start), result.Summary))
+	}
+
 	EnsureLineBreak(execRT.output)
 
-	return nil
+	return result, nil
+}
+
+func (s *Session) listContexts() {
+	contexts := s.engine.registry.Contexts(false)
+	if len(contexts) == 0 {
+		fmt.Fprintln(s.OutputWriter(), "No contexts registered.")
+		return
+	}
+	fmt.Fprintln(s.OutputWriter(), "Contexts:")
+	for _, ctx := range contexts {
+		fmt.Fprintf(s.OutputWriter(), "  %-15s %s\n", ctx.Name, ctx.Description)
+	}
+}
+
+func (s *Session) handleCtxCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("ctx command requires arguments")
+	}
+	switch args[0] {
+	case "goto":
+		if len(args) < 2 {
+			return errors.New("ctx goto <name> [payload]")
+		}
+		return s.contexts.Navigate(args[1], ctxPayloadArg(args))
+	case "push":
+		if len(args) < 2 {
+			return errors.New("ctx push <name> [payload]")
+		}
+		return s.contexts.Push(args[1], ctxPayloadArg(args))
+	case "pop":
+		return s.contexts.Pop()
+	case "show":
+		out := s.engine.newOutputChannel(s.OutputWriter())
+		s.showContext(out)
+		return nil
+	default:
+		return fmt.Errorf("unknown ctx action: %s", args[0])
+	}
+}
+
+// ctxPayloadArg returns the optional payload token following a ctx target.
+func ctxPayloadArg(args []string) any {
+	if len(args) < 3 {
+		return nil
+	}
+	return strings.Join(args[2:], " ")
+}
+
+func (s *Session) handleSwitchCommand(args []string) error {
+	if len(args) != 1 {
+		return errors.New("switch <context>")
+	}
+	canonical, ok := s.engine.registry.ResolveContextName(args[0])
+	if !ok || canonical == "" {
+		return unknownContextError(args[0])
+	}
+	return s.contexts.Navigate(canonical, nil)
+}
+
+func (s *Session) handleCDCommand(args []string) error {
+	if len(args) == 0 {
+		current := s.contexts.Current().Spec.Name
+		if current == "" {
+			fmt.Fprintln(s.OutputWriter(), "Current context: root")
+		} else {
+			fmt.Fprintf(s.OutputWriter(), "Current context: %s\n", current)
+		}
+		return nil
+	}
+	if len(args) > 1 {
+		return errors.New("cd accepts a single target")
+	}
+	target := strings.TrimSpace(args[0])
+	switch target {
+	case "", ".":
+		return nil
+	case "..":
+		return s.contexts.Pop()
+	case "/":
+		return s.contexts.PopToRoot()
+	default:
+		canonical, ok := s.engine.registry.ResolveContextName(target)
+		if !ok || canonical == "" {
+			return unknownContextError(target)
+		}
+		return s.contexts.Navigate(canonical, nil)
+	}
 }
 
 func (e *Engine) coreHandler(entry CommandEntry) func(CommandRuntime, CommandInput) CommandResult {
 	h := func(rt CommandRuntime, input CommandInput) CommandResult {
 		cmd, err := entry.Factory.New(rt)
 		if err != nil {
-			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: "failed to create command", Severity: SeverityError}}
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: "failed to create command", ErrCode: ErrCodeInternal, Severity: SeverityError}}
 		}
-		if entry.Spec.Usage == "" {
-			entry.Spec.Usage = FormatUsage(entry.Spec)
+		result := cmd.Execute(rt, input)
+		if u, ok := cmd.(Undoer); ok && result.Undo == nil && result.Status != StatusFailed && result.Error == nil {
+			result.Undo = u.Inverse(input, result)
 		}
-		return cmd.Execute(rt, input)
+		return result
 	}
 
-	for i := len(e.middleware) - 1; i >= 0; i-- {
-		mw := e.middleware[i]
+	e.mu.RLock()
+	chain := e.middleware
+	e.mu.RUnlock()
+	if scoped := e.scopedMiddleware(entry.Spec); len(scoped) > 0 {
+		chain = append(append([]Middleware(nil), chain...), scoped...)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		mw := chain[i]
 		next := h
 		h = func(rt CommandRuntime, input CommandInput) CommandResult {
 			return mw(rt, input, entry, next)
@@ -387,12 +884,33 @@ func (e *Engine) coreHandler(entry CommandEntry) func(CommandRuntime, CommandInp
 	return h
 }
 
-func (e *Engine) renderHelp(ctx string) {
-	out := NewOutputChannel(e.outputWriter)
+// scopedMiddleware lists the middleware of spec's context and its ancestors,
+// outermost first, followed by spec's own.
+func (e *Engine) scopedMiddleware(spec CommandSpec) []Middleware {
+	var contexts [][]Middleware
+	seen := map[string]bool{}
+	for name := spec.Context; name != "" && !seen[name]; {
+		seen[name] = true
+		ctx, ok := e.registry.Context(name)
+		if !ok {
+			break
+		}
+		contexts = append(contexts, ctx.Middleware)
+		name = ctx.Parent
+	}
+	var chain []Middleware
+	for i := len(contexts) - 1; i >= 0; i-- {
+		chain = append(chain, contexts[i]...)
+	}
+	return append(chain, spec.Middleware...)
+}
+
+// renderHelp lists the contexts and the commands of ctx that allow accepts.
+func (e *Engine) renderHelp(out OutputChannel, ctx string, allow func(CommandSpec) bool) {
 	printLine := func(line string) {
 		out.Info(line)
 	}
-	header := strings.TrimSpace(e.helpHeader)
+	header := strings.TrimSpace(e.header())
 	if header != "" {
 		printLine(header)
 	}
@@ -405,7 +923,7 @@ func (e *Engine) renderHelp(ctx string) {
 				printLine(fmt.Sprintf("  %-15s %s", c.Name, c.Description))
 			}
 		}
-		rootCmds := e.registry.Commands("", false)
+		rootCmds := filterSpecs(e.registry.Commands("", false), allow)
 		if len(rootCmds) > 0 {
 			printLine("")
 			printLine("Global Commands:")
@@ -419,7 +937,7 @@ func (e *Engine) renderHelp(ctx string) {
 		return
 	}
 
-	cmds := e.registry.Commands(ctx, false)
+	cmds := filterSpecs(e.registry.Commands(ctx, false), allow)
 	if len(cmds) == 0 {
 		printLine(fmt.Sprintf("No commands registered for context %s", ctx))
 		EnsureLineBreak(out)
@@ -432,83 +950,14 @@ func (e *Engine) renderHelp(ctx string) {
 	EnsureLineBreak(out)
 }
 
-func (e *Engine) listContexts() {
-	contexts := e.registry.Contexts(false)
-	if len(contexts) == 0 {
-		fmt.Fprintln(e.outputWriter, "No contexts registered.")
-		return
-	}
-	fmt.Fprintln(e.outputWriter, "Contexts:")
-	for _, ctx := range contexts {
-		fmt.Fprintf(e.outputWriter, "  %-15s %s\n", ctx.Name, ctx.Description)
-	}
-}
-
-func (e *Engine) handleCtxCommand(args []string) error {
-	if len(args) == 0 {
-		return errors.New("ctx command requires arguments")
-	}
-	switch args[0] {
-	case "goto":
-		if len(args) < 2 {
-			return errors.New("ctx goto <name>")
-		}
-		return e.contexts.Navigate(args[1], nil)
-	case "push":
-		if len(args) < 2 {
-			return errors.New("ctx push <name>")
-		}
-		return e.contexts.Push(args[1], nil)
-	case "pop":
-		return e.contexts.Pop()
-	default:
-		return fmt.Errorf("unknown ctx action: %s", args[0])
-	}
-}
-
-func (e *Engine) handleSwitchCommand(args []string) error {
-	if len(args) != 1 {
-		return errors.New("switch <context>")
-	}
-	canonical, ok := e.registry.ResolveContextName(args[0])
-	if !ok || canonical == "" {
-		return fmt.Errorf("unknown context: %s", args[0])
-	}
-	return e.contexts.Navigate(canonical, nil)
-}
-
-func (e *Engine) handleCDCommand(args []string) error {
-	if len(args) == 0 {
-		current := e.contexts.Current().Spec.Name
-		if current == "" {
-			fmt.Fprintln(e.outputWriter, "Current context: root")
-		} else {
-			fmt.Fprintf(e.outputWriter, "Current context: %s\n", current)
+func filterSpecs(specs []CommandSpec, allow func(CommandSpec) bool) []CommandSpec {
+	kept := specs[:0]
+	for _, spec := range specs {
+		if allow(spec) {
+			kept = append(kept, spec)
 		}
-		return nil
-	}
-	if len(args) > 1 {
-		return errors.New("cd accepts a single target")
-	}
-	target := strings.TrimSpace(args[0])
-	switch target {
-	case "", ".":
-		return nil
-	case "..":
-		return e.contexts.Pop()
-	case "/":
-		return e.contexts.PopToRoot()
-	default:
-		canonical, ok := e.registry.ResolveContextName(target)
-		if !ok || canonical == "" {
-			return fmt.Errorf("unknown context: %s", target)
-		}
-		return e.contexts.Navigate(canonical, nil)
 	}
-}
-
-func (e *Engine) showHistory() {
-	fmt.Fprintln(e.outputWriter, "Readline history is managed by the readline library. Advanced history tracking TBD.")
+	return kept
 }
 
 func exitRequested(token string) bool {
@@ -520,31 +969,35 @@ func exitRequested(token string) bool {
 	}
 }
 
-func tokenize(input string) []string {
-	fields := strings.Fields(input)
-	return fields
-}
-
 // executionRuntime implements CommandRuntime.
 type executionRuntime struct {
-	engine      *Engine
+	session     *Session
 	ctx         context.Context
 	cancel      context.CancelFunc
 	output      OutputChannel
 	pipeline    any
 	nextContext string
 	nextPayload any
+	meta        InvocationMeta
+	// tasks attributes spawned tasks to the invocation and, in child runtimes, the owner.
+	tasks *TaskManager
+	owner string
 }
 
-func (r *executionRuntime) Session() SessionStore { return r.engine.session }
+func (r *executionRuntime) Session() SessionStore { return r.session.store }
 
-func (r *executionRuntime) Services() ServiceRegistry { return r.engine.services }
+func (r *executionRuntime) Services() ServiceRegistry { return r.session.engine.services }
 
 func (r *executionRuntime) Output() OutputChannel { return r.output }
 
-func (r *executionRuntime) ContextManager() *ContextManager { return r.engine.contexts }
+func (r *executionRuntime) ContextManager() *ContextManager { return r.session.contexts }
 
-func (r *executionRuntime) TaskManager() *TaskManager { return r.engine.tasks }
+func (r *executionRuntime) TaskManager() *TaskManager {
+	if r.tasks != nil {
+		return r.tasks
+	}
+	return r.session.tasks
+}
 
 func (r *executionRuntime) Cancellation() context.Context { return r.ctx }
 
@@ -555,22 +1008,80 @@ func (r *executionRuntime) NavigateTo(name string, payload any) error {
 }
 
 func (r *executionRuntime) PushContext(name string, payload any) error {
-	return r.engine.contexts.Push(name, payload)
+	return r.session.contexts.Push(name, payload)
 }
 
 func (r *executionRuntime) PopContext() error {
-	return r.engine.contexts.Pop()
+	return r.session.contexts.Pop()
 }
 
 func (r *executionRuntime) PipelineData() any { return r.pipeline }
 
+func (r *executionRuntime) Principal() *Principal {
+	p, _ := PrincipalFromSession(r.session.store)
+	return p
+}
+
+func (r *executionRuntime) InvocationMeta() InvocationMeta { return r.meta }
+
 func (r *executionRuntime) SetPipelineData(v any) { r.pipeline = v }
 
+func (r *executionRuntime) Form(fields ...FormField) (ValueSet, error) {
+	return r.session.Form(r.output, fields...)
+}
+
+func (r *executionRuntime) PromptSecret(label string) (string, error) {
+	return r.session.PromptSecret(label)
+}
+
+func (r *executionRuntime) Select(title string, options []SelectOption) (SelectOption, error) {
+	return r.session.Select(r.output, title, options)
+}
+
 func (r *executionRuntime) Close() { r.cancel() }
 
+// sessionOf returns the session backing a runtime created by the engine.
+func sessionOf(rt CommandRuntime) (*Session, bool) {
+	r, ok := rt.(*executionRuntime)
+	if !ok || r.session == nil {
+		return nil, false
+	}
+	return r.session, true
+}
+
+// registerBuiltins queues the built-in commands; they are registered on the
+// first registry lookup and never replace an application command of the same name.
 func (e *Engine) registerBuiltins() {
-	e.registry.RegisterCommand(&helpCommandFactory{engine: e})
-	e.registry.RegisterCommand(&tasksCommandFactory{engine: e})
+	e.registry.registerDeferred(
+		&helpCommandFactory{engine: e},
+		&tasksCommandFactory{engine: e},
+		&taskCommandFactory{},
+		&loginCommandFactory{engine: e},
+		&logoutCommandFactory{engine: e},
+		&whoamiCommandFactory{},
+		&benchCommandFactory{},
+		&resultCommandFactory{},
+		&undoCommandFactory{},
+		&parallelCommandFactory{},
+		&snippetCommandFactory{engine: e},
+		&advisoriesCommandFactory{engine: e},
+		&historyCommandFactory{engine: e},
+		&statusCommandFactory{engine: e},
+		&payloadCommandFactory{},
+		&diffCommandFactory{},
+		&debugCommandFactory{engine: e},
+		&setCommandFactory{},
+		&completionCommandFactory{},
+		&statsCommandFactory{engine: e},
+		&fgCommandFactory{},
+		&bgCommandFactory{},
+		&aliasCommandFactory{engine: e},
+		&envCommandFactory{},
+		&troubleshootCommandFactory{engine: e},
+		&workflowCommandFactory{engine: e},
+		&approvalsCommandFactory{engine: e},
+		&changesCommandFactory{},
+	)
 }
 
 // help command implementation -------------------------------------------------
@@ -587,6 +1098,9 @@ func (f *helpCommandFactory) Spec() CommandSpec {
 			Aliases: []string{"?", "h"},
 			Summary: "Show help for commands and contexts",
 			Context: "",
+			Args: []ArgSpec{
+				{Name: "command", Type: ArgTypeString, Repeatable: true, Passthrough: true, Description: "Command to describe"},
+			},
 		}
 	}
 	return f.spec
@@ -604,8 +1118,24 @@ type helpCommand struct {
 func (c *helpCommand) Spec() CommandSpec { return c.spec }
 
 func (c *helpCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	if target := input.Args.Strings("command"); len(target) > 0 {
+		session, ok := sessionOf(rt)
+		if !ok {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "help requires an engine session", Severity: SeverityError}}
+		}
+		entry, _, err := session.resolveCommand(target)
+		if err != nil {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Severity: SeverityError}}
+		}
+		renderCommandHelp(rt.Output(), entry.Spec)
+		return CommandResult{Status: StatusSuccess}
+	}
 	ctx := rt.ContextManager().Current().Spec.Name
-	c.engine.renderHelp(ctx)
+	allow := func(CommandSpec) bool { return true }
+	if session, ok := sessionOf(rt); ok {
+		allow = session.permitted
+	}
+	c.engine.renderHelp(rt.Output(), ctx, allow)
 	return CommandResult{Status: StatusSuccess}
 }
 
@@ -639,16 +1169,72 @@ type tasksCommand struct {
 func (c *tasksCommand) Spec() CommandSpec { return c.spec }
 
 func (c *tasksCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
-	tasks := rt.TaskManager().Tasks()
-	rows := make([][]string, 0, len(tasks))
+	manager := rt.TaskManager()
+	tasks := manager.Tasks()
+	byID := make(map[string]*TaskHandle, len(tasks))
 	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	rows := make([][]string, 0, len(tasks))
+	taskRow := func(task *TaskHandle, name string) []string {
 		err := ""
 		if task.Error != nil {
 			err = task.Error.Error()
 		}
-		rows = append(rows, []string{task.ID, task.Name, string(task.Status), err})
+		if task.Owner != "" {
+			name += " (" + task.Owner + ")"
+		}
+		progress := formatTaskProgress(task.Progress)
+		var schedule []string
+		if task.Interval > 0 && task.Status != TaskRunning {
+			schedule = append(schedule, "every "+task.Interval.String())
+		}
+		if task.Status == TaskPending && !task.NextRun.IsZero() {
+			schedule = append(schedule, "next run "+task.NextRun.Format("15:04:05"))
+		}
+		if len(schedule) > 0 {
+			progress = strings.Join(schedule, ", ")
+		}
+		return []string{task.ID, name, string(task.Status), progress, err}
+	}
+	shown := map[string]bool{}
+	for _, task := range tasks {
+		if task.Group == "" {
+			rows = append(rows, taskRow(task, task.Name))
+			continue
+		}
+		if shown[task.Group] {
+			continue
+		}
+		shown[task.Group] = true
+		group, ok := manager.Group(task.Group)
+		if !ok {
+			continue
+		}
+		done := 0
+		for _, id := range group.Tasks {
+			if member, ok := byID[id]; ok && taskFinished(member.Status) {
+				done++
+			}
+		}
+		rows = append(rows, []string{group.ID, group.Name, string(group.Status), fmt.Sprintf("%d/%d done", done, len(group.Tasks)), ""})
+		for i, id := range group.Tasks {
+			member, ok := byID[id]
+			if !ok {
+				continue
+			}
+			branch := "|- "
+			if i == len(group.Tasks)-1 {
+				branch = "`- "
+			}
+			name := branch + member.Name
+			if len(member.DependsOn) > 0 {
+				name += " (after " + strings.Join(member.DependsOn, ", ") + ")"
+			}
+			rows = append(rows, taskRow(member, name))
+		}
 	}
-	rt.Output().WriteTable([]string{"ID", "Name", "Status", "Error"}, rows)
+	rt.Output().WriteTable([]string{"ID", "Name", "Status", "Progress", "Error"}, rows)
 	return CommandResult{Status: StatusSuccess, Payload: tasks}
 }
 
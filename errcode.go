@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// Error codes set in CommandError.ErrCode. Automation should branch on these
+// rather than on messages, which may change. Applications register their own
+// codes with WithErrorCode.
+const (
+	// ErrCodeFailed is the code of failures that carry no more specific one.
+	ErrCodeFailed = "failed"
+	// ErrCodeInternal reports a bug, such as a command that could not be created.
+	ErrCodeInternal = "internal"
+	// ErrCodeUsage reports invalid arguments or flags.
+	ErrCodeUsage = "usage"
+	// ErrCodeUnknownCommand reports a command name that did not resolve.
+	ErrCodeUnknownCommand = "unknown_command"
+	// ErrCodeUnknownContext reports a context name that did not resolve.
+	ErrCodeUnknownContext = "unknown_context"
+	// ErrCodeAmbiguousCommand reports a prefix matching several commands.
+	ErrCodeAmbiguousCommand = "ambiguous_command"
+	// ErrCodePermissionDenied reports a command the operator may not run.
+	ErrCodePermissionDenied = "permission_denied"
+	// ErrCodeTimeout reports a command that ran past its timeout.
+	ErrCodeTimeout = "timeout"
+	// ErrCodeCanceled reports a command interrupted by the operator.
+	ErrCodeCanceled = "canceled"
+)
+
+// ErrorCodeInfo documents one error code.
+type ErrorCodeInfo struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+var builtinErrorCodes = []ErrorCodeInfo{
+	{ErrCodeFailed, "The command failed without a more specific code"},
+	{ErrCodeInternal, "Internal error; report it with the invocation ref"},
+	{ErrCodeUsage, "Invalid arguments or flags"},
+	{ErrCodeUnknownCommand, "No command has this name"},
+	{ErrCodeUnknownContext, "No context has this name"},
+	{ErrCodeAmbiguousCommand, "The name matches several commands"},
+	{ErrCodePermissionDenied, "The operator lacks a required permission"},
+	{ErrCodeTimeout, "The command ran past its timeout"},
+	{ErrCodeCanceled, "The command was interrupted"},
+}
+
+// WithErrorCode documents an application error code, listed by ErrorCodes and
+// `debug errors`.
+func WithErrorCode(code, description string) Option {
+	return func(e *Engine) {
+		if code != "" {
+			e.errorCodes = append(e.errorCodes, ErrorCodeInfo{Code: code, Description: description})
+		}
+	}
+}
+
+// ErrorCodes lists the built-in and registered error codes, sorted by code.
+func (e *Engine) ErrorCodes() []ErrorCodeInfo {
+	codes := map[string]ErrorCodeInfo{}
+	for _, info := range builtinErrorCodes {
+		codes[info.Code] = info
+	}
+	for _, info := range e.errorCodes {
+		codes[info.Code] = info
+	}
+	list := make([]ErrorCodeInfo, 0, len(codes))
+	for _, info := range codes {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// Code returns ErrCode, or a code derived from Err when it is unset.
+func (e *CommandError) Code() string {
+	if e == nil {
+		return ""
+	}
+	if e.ErrCode != "" {
+		return e.ErrCode
+	}
+	return errorCode(e.Err)
+}
+
+// MarshalJSON renders the error with its code, message, and hints; Err itself
+// is reported through the message.
+func (e *CommandError) MarshalJSON() ([]byte, error) {
+	msg := e.Message
+	if msg == "" && e.Err != nil {
+		msg = e.Err.Error()
+	}
+	return json.Marshal(struct {
+		Code        string        `json:"code"`
+		Message     string        `json:"message"`
+		Severity    SeverityLevel `json:"severity,omitempty"`
+		Hints       []string      `json:"hints,omitempty"`
+		Recoverable bool          `json:"recoverable,omitempty"`
+	}{e.Code(), msg, e.Severity, e.Hints, e.Recoverable})
+}
+
+// ErrorCodeOf returns the error code of one command line's outcome, as ExitCode
+// does for exit codes: "" on success.
+func ErrorCodeOf(result CommandResult, err error) string {
+	if err != nil {
+		return errorCode(err)
+	}
+	if result.Error != nil && (result.Status == StatusFailed || result.Error.Severity == SeverityError) {
+		return result.Error.Code()
+	}
+	if result.Status == StatusFailed {
+		return ErrCodeFailed
+	}
+	return ""
+}
+
+// ErrorCode returns the error code of the most recent command line run through
+// Execute, RunPlain, Run, or RunScript, or "" when it succeeded.
+func (s *Session) ErrorCode() string {
+	code, _ := s.exit.errCode.Load().(string)
+	return code
+}
+
+// errorCode maps the framework's error types and sentinels to codes.
+func errorCode(err error) string {
+	var usage *UsageError
+	var parse *ParseError
+	var resolution *ResolutionError
+	switch {
+	case err == nil:
+		return ErrCodeFailed
+	case errors.Is(err, ErrPermissionDenied):
+		return ErrCodePermissionDenied
+	case errors.As(err, &resolution):
+		switch {
+		case errors.Is(resolution.Kind, ErrUnknownContext):
+			return ErrCodeUnknownContext
+		case errors.Is(resolution.Kind, ErrAmbiguousCommand):
+			return ErrCodeAmbiguousCommand
+		}
+		return ErrCodeUnknownCommand
+	case errors.As(err, &usage), errors.As(err, &parse), errors.Is(err, ErrMissingArgument):
+		return ErrCodeUsage
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrCodeCanceled
+	}
+	return ErrCodeFailed
+}
@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors for name resolution failures. Match them with errors.Is.
+var (
+	ErrUnknownCommand   = errors.New("unknown command")
+	ErrUnknownContext   = errors.New("unknown context")
+	ErrAmbiguousCommand = errors.New("ambiguous command")
+)
+
+// ResolutionError carries machine-readable details about a failed command or context lookup.
+type ResolutionError struct {
+	// Kind is one of ErrUnknownCommand, ErrUnknownContext, or ErrAmbiguousCommand.
+	Kind error
+	// Name is the token that failed to resolve.
+	Name string
+	// Context is the context the lookup ran in ("" for root).
+	Context string
+	// Candidates lists matching names for ambiguous lookups.
+	Candidates []string
+}
+
+// Error implements error.
+func (e *ResolutionError) Error() string {
+	if errors.Is(e.Kind, ErrAmbiguousCommand) && len(e.Candidates) > 0 {
+		return fmt.Sprintf("%v: %s (could be %s)", e.Kind, e.Name, strings.Join(e.Candidates, ", "))
+	}
+	return fmt.Sprintf("%v: %s", e.Kind, e.Name)
+}
+
+// Unwrap exposes the sentinel kind to errors.Is.
+func (e *ResolutionError) Unwrap() error { return e.Kind }
+
+func unknownCommandError(ctx, name string) error {
+	return &ResolutionError{Kind: ErrUnknownCommand, Name: name, Context: ctx}
+}
+
+func unknownContextError(name string) error {
+	return &ResolutionError{Kind: ErrUnknownContext, Name: name}
+}
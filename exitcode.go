@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrPermissionDenied reports that the operator may not run a command. Return
+// it (or wrap it in CommandError.Err) so batch runs exit with the
+// permission-denied code.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// ExitCodes maps command outcomes to process exit codes for batch runs.
+type ExitCodes struct {
+	Success          int
+	Failure          int
+	Usage            int
+	PermissionDenied int
+}
+
+// DefaultExitCodes returns 0 for success, 1 for failure, 2 for parse and
+// usage errors, and 77 (EX_NOPERM) for permission denied.
+func DefaultExitCodes() ExitCodes {
+	return ExitCodes{Success: 0, Failure: 1, Usage: 2, PermissionDenied: 77}
+}
+
+// WithExitCodes replaces the exit code mapping.
+func WithExitCodes(codes ExitCodes) Option {
+	return func(e *Engine) { e.exitCodes = codes }
+}
+
+// ExitCodes returns the engine's exit code mapping.
+func (e *Engine) ExitCodes() ExitCodes { return e.exitCodes }
+
+// ExitCode maps the outcome of one command line to an exit code. err is the
+// error returned for the line, such as a parse or resolution error. Failed
+// results exit with Failure unless their error is a permission or usage error;
+// partial results fail only when their error has SeverityError.
+func (e *Engine) ExitCode(result CommandResult, err error) int {
+	codes := e.exitCodes
+	if err != nil {
+		return codes.forError(err)
+	}
+	switch result.Status {
+	case StatusFailed:
+		if result.Error != nil && result.Error.Err != nil {
+			return codes.forError(result.Error.Err)
+		}
+		return codes.Failure
+	case StatusPartial:
+		if result.Error != nil && result.Error.Severity == SeverityError {
+			return codes.Failure
+		}
+	}
+	return codes.Success
+}
+
+func (c ExitCodes) forError(err error) int {
+	var usage *UsageError
+	var parse *ParseError
+	var resolution *ResolutionError
+	switch {
+	case errors.Is(err, ErrPermissionDenied):
+		return c.PermissionDenied
+	case errors.As(err, &usage), errors.As(err, &parse), errors.As(err, &resolution), errors.Is(err, ErrMissingArgument):
+		return c.Usage
+	}
+	return c.Failure
+}
+
+// exitState holds the exit and error codes of a session's most recent command line.
+type exitState struct {
+	code    atomic.Int32
+	errCode atomic.Value
+}
+
+// ExitCode returns the exit code of the most recent command line run through
+// Execute, RunPlain, Run, or RunScript.
+func (s *Session) ExitCode() int { return int(s.exit.code.Load()) }
+
+func (s *Session) setExitCode(code int) { s.exit.code.Store(int32(code)) }
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormField is one question of a Form. Name, Type, EnumValues, Default,
+// Required, Repeatable (comma-separated answers), Prompt, Secret, Schema, and
+// DecodeAs behave as they do for arguments; Description is shown when the
+// operator answers "?".
+type FormField struct {
+	ArgSpec
+	// Validate checks the converted value; an error is shown and the field asked again.
+	Validate func(value any) error
+	// When skips the field unless it returns true for the answers so far.
+	When func(answers ValueSet) bool
+}
+
+// Form asks for each field in turn, converting and validating answers, and
+// re-asking until each one is valid. It fails with ErrNoInteractiveInput when
+// no front end is attached, and with the input error if the operator aborts.
+func (s *Session) Form(out OutputChannel, fields ...FormField) (ValueSet, error) {
+	answers := newValueSet(map[string]any{})
+	for _, field := range fields {
+		if field.When != nil && !field.When(answers) {
+			continue
+		}
+		for {
+			raw, err := s.askField(field)
+			if err != nil {
+				return ValueSet{}, err
+			}
+			if raw == "?" {
+				out.Info(fieldHelp(field))
+				continue
+			}
+			value, set, err := field.convert(raw)
+			if err == nil && set && field.Validate != nil {
+				err = field.Validate(value)
+			}
+			if err != nil {
+				out.Error(fmt.Sprintf("%s: %v", field.Name, err))
+				continue
+			}
+			if set {
+				answers.values[field.Name] = value
+			}
+			break
+		}
+	}
+	return answers, nil
+}
+
+func (s *Session) askField(field FormField) (string, error) {
+	prompt := field.Prompt
+	if prompt == "" {
+		var b strings.Builder
+		b.WriteString(field.Name)
+		if len(field.EnumValues) > 0 {
+			b.WriteString(" (" + strings.Join(field.EnumValues, "|") + ")")
+		}
+		if field.Default != nil {
+			fmt.Fprintf(&b, " [%v]", field.Default)
+		}
+		b.WriteString(": ")
+		prompt = b.String()
+	}
+	if field.secret() {
+		return s.AskSecret(prompt)
+	}
+	return s.Ask(prompt)
+}
+
+// convert turns an answer into the field's value. set is false when the field
+// was left empty and has no default.
+func (f FormField) convert(raw string) (value any, set bool, err error) {
+	if raw == "" {
+		switch {
+		case f.Default != nil:
+			return f.Default, true, nil
+		case f.Required:
+			return nil, false, fmt.Errorf("a value is required")
+		}
+		return nil, false, nil
+	}
+	flag := FlagSpec{Name: f.Name, Type: f.Type, EnumValues: f.EnumValues, Schema: f.Schema, DecodeAs: f.DecodeAs}
+	if f.secret() {
+		flag.Type = ArgTypeString
+	}
+	if !f.Repeatable {
+		value, err := castFlagValue(flag, raw)
+		return value, err == nil, err
+	}
+	var values []any
+	for _, part := range strings.Split(raw, ",") {
+		v, err := castFlagValue(flag, strings.TrimSpace(part))
+		if err != nil {
+			return nil, false, err
+		}
+		values = append(values, v)
+	}
+	return values, true, nil
+}
+
+func fieldHelp(field FormField) string {
+	help := field.Description
+	if help == "" {
+		help = "no description"
+	}
+	kind := field.Type
+	if kind == "" {
+		kind = ArgTypeString
+	}
+	return fmt.Sprintf("%s (%s): %s", field.Name, kind, help)
+}
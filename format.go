@@ -0,0 +1,253 @@
+package tui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OutputFormat selects how structured output is rendered.
+type OutputFormat string
+
+const (
+	OutputFormatTable OutputFormat = "table"
+	OutputFormatJSON  OutputFormat = "json"
+	OutputFormatYAML  OutputFormat = "yaml"
+	OutputFormatCSV   OutputFormat = "csv"
+)
+
+// outputFormats lists the values accepted by the implicit --output flag.
+var outputFormats = []string{string(OutputFormatTable), string(OutputFormatJSON), string(OutputFormatYAML), string(OutputFormatCSV)}
+
+// outputFormatOverride reports the format requested through spec's implicit --output flag, if any.
+func outputFormatOverride(spec CommandSpec, flags ValueSet) (OutputFormat, bool) {
+	for _, flag := range applicableImplicitFlags(spec) {
+		if flag.Name == "output" {
+			if v := flags.String("output"); v != "" {
+				return OutputFormat(v), true
+			}
+		}
+	}
+	return "", false
+}
+
+// renderFormatted writes v in the channel's format, falling back to JSON when a
+// value cannot be tabulated.
+func renderFormatted(out OutputChannel, v any) {
+	switch out.Format() {
+	case OutputFormatJSON:
+		out.WriteJSON(v)
+	case OutputFormatYAML:
+		out.WriteYAML(v)
+	case OutputFormatCSV:
+		if headers, rows, ok := tabulate(v); ok {
+			out.WriteCSV(headers, rows)
+		} else {
+			out.WriteJSON(v)
+		}
+	default:
+		if headers, rows, ok := tabulate(v); ok {
+			out.WriteTable(headers, rows)
+		} else {
+			renderPayload(out, v)
+		}
+	}
+}
+
+// tabulate converts tables, lists of objects, and single objects into rows.
+func tabulate(v any) ([]string, [][]string, bool) {
+	switch t := v.(type) {
+	case PipelineTable:
+		return t.Headers, t.Rows, true
+	case *PipelineTable:
+		if t == nil {
+			return nil, nil, false
+		}
+		return t.Headers, t.Rows, true
+	}
+	generic, err := toGenericJSON(v)
+	if err != nil {
+		return nil, nil, false
+	}
+	var objects []map[string]any
+	switch g := generic.(type) {
+	case map[string]any:
+		objects = []map[string]any{g}
+	case []any:
+		for _, item := range g {
+			obj, ok := item.(map[string]any)
+			if !ok {
+				return nil, nil, false
+			}
+			objects = append(objects, obj)
+		}
+	default:
+		return nil, nil, false
+	}
+	keySet := map[string]bool{}
+	for _, obj := range objects {
+		for k := range obj {
+			keySet[k] = true
+		}
+	}
+	headers := make([]string, 0, len(keySet))
+	for k := range keySet {
+		headers = append(headers, k)
+	}
+	sort.Strings(headers)
+	rows := make([][]string, len(objects))
+	for i, obj := range objects {
+		row := make([]string, len(headers))
+		for j, k := range headers {
+			row[j] = cellString(obj[k])
+		}
+		rows[i] = row
+	}
+	return headers, rows, true
+}
+
+func cellString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64, bool:
+		return fmt.Sprint(t)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// toGenericJSON round-trips v through encoding/json into maps, slices, and scalars.
+func toGenericJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	err = json.Unmarshal(data, &generic)
+	return generic, err
+}
+
+// encodeCSV renders headers and rows as CSV.
+func encodeCSV(headers []string, rows [][]string) (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := w.Write(headers); err != nil {
+		return "", err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// encodeYAML renders v as block-style YAML via its JSON representation.
+func encodeYAML(v any) (string, error) {
+	generic, err := toGenericJSON(v)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	writeYAML(&b, generic, 0)
+	return b.String(), nil
+}
+
+func writeYAML(b *strings.Builder, v any, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) == 0 {
+			b.WriteString(pad + "{}\n")
+			return
+		}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString(pad + yamlScalar(k) + ":")
+			writeYAMLValue(b, t[k], indent)
+		}
+	case []any:
+		if len(t) == 0 {
+			b.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range t {
+			if yamlNested(item) {
+				// Start the nested block on the dash line: "- key: value".
+				var inner strings.Builder
+				writeYAML(&inner, item, indent+1)
+				b.WriteString(pad + "- " + inner.String()[len(pad)+2:])
+				continue
+			}
+			b.WriteString(pad + "-")
+			writeYAMLValue(b, item, indent)
+		}
+	default:
+		b.WriteString(pad + yamlScalar(t) + "\n")
+	}
+}
+
+// writeYAMLValue writes the value following a "key:" or "-" marker.
+func writeYAMLValue(b *strings.Builder, v any, indent int) {
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, t, indent+1)
+	case []any:
+		if len(t) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, t, indent+1)
+	default:
+		b.WriteString(" " + yamlScalar(t) + "\n")
+	}
+}
+
+// yamlNested reports whether v renders as a non-empty block.
+func yamlNested(v any) bool {
+	switch t := v.(type) {
+	case map[string]any:
+		return len(t) > 0
+	case []any:
+		return len(t) > 0
+	}
+	return false
+}
+
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_ ./@-]*$`)
+
+func yamlScalar(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case string:
+		switch strings.ToLower(t) {
+		case "true", "false", "yes", "no", "on", "off", "null", "~", "y", "n":
+			return strconv.Quote(t)
+		}
+		if yamlPlain.MatchString(t) && !strings.HasSuffix(t, " ") {
+			return t
+		}
+		return strconv.Quote(t)
+	}
+	return strconv.Quote(fmt.Sprint(v))
+}
@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UsageError decorates a parse failure with the command's usage line and the
+// description of the offending argument or flag.
+type UsageError struct {
+	Err    error
+	Usage  string
+	Detail string
+}
+
+// Error implements error.
+func (e *UsageError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	b.WriteString("\nUsage: ")
+	b.WriteString(e.Usage)
+	if e.Detail != "" {
+		b.WriteString("\n  ")
+		b.WriteString(e.Detail)
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying parse error.
+func (e *UsageError) Unwrap() error { return e.Err }
+
+// withUsage wraps err in a UsageError unless the spec suppresses usage output.
+func withUsage(err error, spec CommandSpec) error {
+	if spec.SuppressUsage {
+		return err
+	}
+	usage := spec.Usage
+	if usage == "" {
+		usage = FormatUsage(spec)
+	}
+	ue := &UsageError{Err: err, Usage: usage}
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		switch {
+		case pe.Flag != "":
+			for _, flag := range spec.Flags {
+				if flag.Name == pe.Flag {
+					ue.Detail = fmt.Sprintf("--%s: %s", flag.Name, describeValue(flag.Description, flag.Type, flag.Required, flag.Default, flag.EnumValues))
+				}
+			}
+		case pe.Arg != "":
+			for _, arg := range spec.Args {
+				if arg.Name == pe.Arg {
+					ue.Detail = fmt.Sprintf("%s: %s", strings.ToUpper(arg.Name), describeValue(arg.Description, arg.Type, arg.Required, arg.Default, arg.EnumValues))
+				}
+			}
+		}
+	}
+	return ue
+}
+
+// helpRequested reports whether args ask for command help via --help or -h.
+// Tokens captured by a Passthrough argument are left alone, and -h is ignored
+// when the command defines its own flag with that shorthand.
+func helpRequested(args []string, c *compiledSpec) bool {
+	spec, flags := c.spec, c.flags
+	_, ownHelp := flags.lookup("help")
+	_, ownShort := flags.resolveShorthand("h")
+	positional := 0
+	for i := 0; i < len(args); i++ {
+		token := args[i]
+		if positional < len(spec.Args) && spec.Args[positional].Passthrough {
+			return false
+		}
+		switch {
+		case token == "--":
+			return false
+		case token == "--help" && !ownHelp, token == "-h" && !ownShort:
+			return true
+		case strings.HasPrefix(token, "-") && token != "-":
+			name := strings.TrimLeft(token, "-")
+			if !strings.HasPrefix(token, "--") {
+				if long, ok := flags.resolveShorthand(name); ok {
+					name = long
+				}
+			}
+			if flag, ok := flags.lookup(name); ok && flag.Type != ArgTypeBool && !strings.Contains(token, "=") {
+				i++
+			}
+		default:
+			if positional < len(spec.Args) && !spec.Args[positional].Repeatable {
+				positional++
+			}
+		}
+	}
+	return false
+}
+
+// renderCommandHelp writes detailed help for a single command.
+func renderCommandHelp(out OutputChannel, spec CommandSpec) {
+	usage := spec.Usage
+	if usage == "" {
+		usage = FormatUsage(spec)
+	}
+	out.Info(fmt.Sprintf("Usage: %s", usage))
+	if spec.Summary != "" {
+		out.Info("")
+		out.Info(spec.Summary)
+	}
+	if spec.Description != "" && spec.Description != spec.Summary {
+		out.Info("")
+		out.Info(spec.Description)
+	}
+	if len(spec.Args) > 0 {
+		out.Info("")
+		out.Info("Arguments:")
+		for _, arg := range spec.Args {
+			out.Info(fmt.Sprintf("  %-20s %s", strings.ToUpper(arg.Name), describeValue(arg.Description, arg.Type, arg.Required, arg.Default, arg.EnumValues)))
+		}
+	}
+	var flags []FlagSpec
+	for _, flag := range spec.Flags {
+		if !flag.Hidden {
+			flags = append(flags, flag)
+		}
+	}
+	if len(flags) > 0 {
+		out.Info("")
+		out.Info("Flags:")
+		for _, flag := range flags {
+			name := "--" + flag.Name
+			if flag.Shorthand != "" {
+				name = fmt.Sprintf("-%s, %s", flag.Shorthand, name)
+			}
+			out.Info(fmt.Sprintf("  %-20s %s", name, describeValue(flag.Description, flag.Type, flag.Required, flag.Default, flag.EnumValues)))
+		}
+		if len(spec.FlagGroups) > 0 {
+			out.Info("")
+			out.Info("Flag rules:")
+			for _, g := range spec.FlagGroups {
+				out.Info("  " + g.String())
+			}
+		}
+	}
+	if implicit := applicableImplicitFlags(spec); len(implicit) > 0 {
+		out.Info("")
+		out.Info("Global flags:")
+		for _, flag := range implicit {
+			name := "--" + flag.Name
+			if flag.Shorthand != "" {
+				name = fmt.Sprintf("-%s, %s", flag.Shorthand, name)
+			}
+			out.Info(fmt.Sprintf("  %-20s %s", name, flag.Description))
+		}
+	}
+	if len(spec.Examples) > 0 {
+		out.Info("")
+		out.Info("Examples:")
+		for _, ex := range spec.Examples {
+			if ex.Description != "" {
+				out.Info(fmt.Sprintf("  # %s", ex.Description))
+			}
+			out.Info(fmt.Sprintf("  %s", ex.Command))
+		}
+	}
+}
+
+func describeValue(desc string, kind ArgType, required bool, def any, enum []string) string {
+	var notes []string
+	if kind != "" && kind != ArgTypeString {
+		notes = append(notes, string(kind))
+	}
+	if required {
+		notes = append(notes, "required")
+	}
+	if def != nil {
+		notes = append(notes, fmt.Sprintf("default %v", def))
+	}
+	if len(enum) > 0 {
+		notes = append(notes, "one of "+strings.Join(enum, "|"))
+	}
+	if len(notes) == 0 {
+		return desc
+	}
+	if desc == "" {
+		return "(" + strings.Join(notes, ", ") + ")"
+	}
+	return fmt.Sprintf("%s (%s)", desc, strings.Join(notes, ", "))
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ErrUnterminatedHeredoc is returned when input ends before a here-document's
+// closing delimiter.
+var ErrUnterminatedHeredoc = errors.New("unterminated here-document")
+
+// editMarker, given as an argument or flag value, opens $VISUAL or $EDITOR
+// to write the value.
+const editMarker = "@edit"
+
+var heredocDelimiter = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// collectInput replaces a here-document marker in line with the text it
+// introduces, quoted as one word, so multi-line JSON reaches a flag intact:
+//
+//	apply --body <<EOF
+//	{"name": "core-1",
+//	 "mtu": 9000}
+//	EOF
+//
+// The delimiter may be quoted (<<'EOF'); the text is always taken literally,
+// single-quoted so that $ references in it are not expanded.
+// An @edit value instead opens $VISUAL or $EDITOR, falling back to a
+// here-document ended by EOF when neither is set. Lines without a marker are
+// returned unchanged.
+func (s *Session) collectInput(line string) (string, error) {
+	start, end, delim := inputMarker(line)
+	if start < 0 {
+		return line, nil
+	}
+	var body string
+	var err error
+	if delim == "" {
+		body, err = s.editValue()
+	} else {
+		body, err = s.readHeredoc(delim)
+	}
+	if err != nil {
+		return "", err
+	}
+	return line[:start] + singleQuote(body) + line[end:], nil
+}
+
+func (s *Session) editValue() (string, error) {
+	editor := editorCommand()
+	if editor == "" {
+		fmt.Fprintln(s.OutputWriter(), "No $VISUAL or $EDITOR set; enter the value and finish with a line containing only EOF.")
+		return s.readHeredoc("EOF")
+	}
+	body, err := runEditor(editor, "planetui-*", "")
+	if err != nil {
+		return "", fmt.Errorf("editor: %w", err)
+	}
+	body = strings.TrimRight(body, "\n")
+	if strings.TrimSpace(body) == "" {
+		return "", errors.New("editor: empty value, command canceled")
+	}
+	return body, nil
+}
+
+// readHeredoc reads lines verbatim up to one holding only delim.
+func (s *Session) readHeredoc(delim string) (string, error) {
+	s.mu.RLock()
+	input := s.input
+	s.mu.RUnlock()
+	if input == nil {
+		return "", ErrNoInteractiveInput
+	}
+	var lines []string
+	for {
+		line, err := input("> ")
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("%w: expected %s", ErrUnterminatedHeredoc, delim)
+		}
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(line) == delim {
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+// inputMarker locates the first unquoted word, or --flag= value, that is a
+// here-document marker or @edit, returning its bounds and the delimiter,
+// which is empty for @edit. start is -1 when there is none.
+func inputMarker(line string) (start, end int, delim string) {
+	wordStart := -1
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		if quote != 0 {
+			if ch == quote {
+				quote = 0
+			} else if ch == '\\' && quote == '"' {
+				i++
+			}
+			continue
+		}
+		if strings.IndexByte(" \t\r\n", ch) >= 0 {
+			wordStart = -1
+			continue
+		}
+		if wordStart < 0 {
+			wordStart = i
+		}
+		switch ch {
+		case '\'', '"':
+			quote = ch
+			continue
+		case '\\':
+			i++
+			continue
+		}
+		if i != wordStart && !(line[i-1] == '=' && line[wordStart] == '-') {
+			continue
+		}
+		end = strings.IndexAny(line[i:], " \t\r\n")
+		if end < 0 {
+			end = len(line)
+		} else {
+			end += i
+		}
+		word := line[i:end]
+		if word == editMarker {
+			return i, end, ""
+		}
+		if d, ok := strings.CutPrefix(word, "<<"); ok {
+			if len(d) > 2 && (d[0] == '\'' || d[0] == '"') && d[len(d)-1] == d[0] {
+				d = d[1 : len(d)-1]
+			}
+			if heredocDelimiter.MatchString(d) {
+				return i, end, d
+			}
+		}
+	}
+	return -1, -1, ""
+}
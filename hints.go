@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chzyer/readline/runes"
+)
+
+// maxHintWidth caps inline hints so they do not wrap narrow terminals.
+const maxHintWidth = 60
+
+// WithInlineHints shows the usage of the command being typed, and the next
+// expected argument, dimmed to the right of the cursor.
+func WithInlineHints() Option {
+	return func(e *Engine) { e.inlineHints = true }
+}
+
+// hintPainter implements readline.Painter, appending a hint after the input
+// and the theme's right prompt segment.
+type hintPainter struct {
+	session *Session
+}
+
+// Paint implements readline.Painter. The hint is wrapped in save/restore cursor
+// sequences so readline's cursor bookkeeping is unaffected.
+func (p *hintPainter) Paint(line []rune, pos int) (painted []rune) {
+	// Readline paints on its own goroutine, out of the read loop's reach.
+	defer func() {
+		if r := recover(); r != nil {
+			p.session.recoverCrash("hint painting", string(line), r)
+			painted = line
+		}
+	}()
+	painted, width := line, runes.WidthAll(line)
+	if p.session.engine.inlineHints && pos == len(line) {
+		if hint := []rune(p.session.Hint(string(line))); len(hint) > 0 {
+			if runes.WidthAll(hint) > maxHintWidth {
+				hint = append(truncateWidth(hint, maxHintWidth-3), '.', '.', '.')
+			}
+			painted = make([]rune, 0, len(line)+len(hint)+16)
+			painted = append(painted, line...)
+			painted = append(painted, []rune("\x1b7  "+ansiDim+string(hint)+ansiReset+"\x1b8")...)
+			width += runes.WidthAll(hint) + 2
+		}
+	}
+	return p.session.paintRightPrompt(painted, width)
+}
+
+// truncateWidth returns the longest prefix of r that fits in width cells.
+func truncateWidth(r []rune, width int) []rune {
+	used := 0
+	for i, c := range r {
+		if used += runes.Width(c); used > width {
+			return r[:i:i]
+		}
+	}
+	return r
+}
+
+// Hint returns the usage hint for a partially typed line, or "" when the line
+// does not name a known command.
+func (s *Session) Hint(line string) string {
+	tokens := tokenize(line)
+	if len(tokens) == 0 {
+		return ""
+	}
+	registry := s.engine.registry
+	ctx := s.contexts.Current().Spec.Name
+	if canonical, ok := registry.ResolveContextName(tokens[0]); ok && canonical != "" {
+		ctx = canonical
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return ""
+	}
+	entry, ok := registry.Resolve(ctx, tokens[0])
+	if !ok {
+		return ""
+	}
+	spec := entry.Spec
+	usage := spec.Usage
+
+	args := tokens[1:]
+	if len(args) > 0 && !strings.HasSuffix(line, " ") {
+		args = args[:len(args)-1]
+	}
+	positional, pending := scanArgs(entry.resolved(), args)
+	switch {
+	case pending != nil:
+		return fmt.Sprintf("--%s %s", pending.Name, describeValue(pending.Description, pending.Type, pending.Required, pending.Default, pending.EnumValues))
+	case positional < len(spec.Args):
+		arg := spec.Args[positional]
+		return fmt.Sprintf("%s  → %s %s", usage, strings.ToUpper(arg.Name), describeValue(arg.Description, arg.Type, arg.Required, arg.Default, arg.EnumValues))
+	default:
+		return usage
+	}
+}
@@ -0,0 +1,266 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize bounds the engine command history unless overridden.
+const DefaultHistorySize = 1000
+
+// HistoryEntry is one line entered at the prompt.
+type HistoryEntry struct {
+	Index   int       `json:"index"`
+	Line    string    `json:"line"`
+	Context string    `json:"context,omitempty"`
+	Session string    `json:"session,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// HistoryQuery filters history entries. Zero values match everything.
+type HistoryQuery struct {
+	// Context restricts entries to those entered in the named context.
+	Context string
+	// Grep is a regular expression matched against the line.
+	Grep string
+	// Last keeps only the N most recent matches.
+	Last int
+	// Index selects the single entry with that index.
+	Index int
+}
+
+// HistoryManager records entered lines with the context they were entered in,
+// storing them in a History backend (in memory unless configured otherwise).
+type HistoryManager struct {
+	mu      sync.RWMutex
+	backend History
+	limit   int
+}
+
+// NewHistoryManager constructs an in-memory history retaining at most limit entries.
+func NewHistoryManager(limit int) *HistoryManager {
+	if limit <= 0 {
+		limit = DefaultHistorySize
+	}
+	return &HistoryManager{backend: NewMemoryHistory(limit), limit: limit}
+}
+
+// Backend returns the storage backend.
+func (h *HistoryManager) Backend() History {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.backend
+}
+
+// SetBackend replaces the storage backend, pruning it to the manager's limit.
+func (h *HistoryManager) SetBackend(b History) error {
+	h.mu.Lock()
+	h.backend = b
+	limit := h.limit
+	h.mu.Unlock()
+	return b.Prune(context.Background(), limit)
+}
+
+// Load switches to a FileHistory at path, loading earlier entries. A missing file is not an error.
+func (h *HistoryManager) Load(path string) error {
+	h.mu.RLock()
+	limit := h.limit
+	h.mu.RUnlock()
+	fh, err := OpenFileHistory(path, limit)
+	if err != nil {
+		return err
+	}
+	return h.SetBackend(fh)
+}
+
+// Record appends a line entered in ctx.
+func (h *HistoryManager) Record(line, ctx, session string) (HistoryEntry, error) {
+	return h.Backend().Append(context.Background(), HistoryEntry{Line: line, Context: ctx, Session: session, Time: time.Now()})
+}
+
+// Get returns the entry with the given index.
+func (h *HistoryManager) Get(index int) (HistoryEntry, bool) {
+	if index <= 0 {
+		return HistoryEntry{}, false
+	}
+	entries, err := h.Query(HistoryQuery{Index: index})
+	if err != nil || len(entries) == 0 {
+		return HistoryEntry{}, false
+	}
+	return entries[0], true
+}
+
+// Last returns the most recent entry.
+func (h *HistoryManager) Last() (HistoryEntry, bool) {
+	entries, err := h.Query(HistoryQuery{Last: 1})
+	if err != nil || len(entries) == 0 {
+		return HistoryEntry{}, false
+	}
+	return entries[0], true
+}
+
+// Entries lists retained entries, oldest first.
+func (h *HistoryManager) Entries() []HistoryEntry {
+	entries, _ := h.Backend().List(context.Background())
+	return entries
+}
+
+// Query returns the entries matching q, oldest first.
+func (h *HistoryManager) Query(q HistoryQuery) ([]HistoryEntry, error) {
+	if q.Grep != "" {
+		if _, err := regexp.Compile(q.Grep); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return h.Backend().Search(context.Background(), q)
+}
+
+// Prune discards all but the keep most recent entries.
+func (h *HistoryManager) Prune(keep int) error {
+	return h.Backend().Prune(context.Background(), keep)
+}
+
+// WithHistoryFile persists command history to path, loading earlier entries.
+func WithHistoryFile(path string) Option {
+	return func(e *Engine) {
+		if err := e.history.Load(path); err != nil {
+			fmt.Fprintf(e.outputWriter, "Error loading history: %v\n", err)
+		}
+	}
+}
+
+// WithHistoryBackend stores command history in b, e.g. a SQLiteHistory or HTTPHistory
+// shared across operators for audit.
+func WithHistoryBackend(b History) Option {
+	return func(e *Engine) {
+		if err := e.history.SetBackend(b); err != nil {
+			fmt.Fprintf(e.outputWriter, "Error pruning history: %v\n", err)
+		}
+	}
+}
+
+// WithHistoryLimit sets how many history entries are retained. Apply it before
+// WithHistoryFile or WithHistoryBackend.
+func WithHistoryLimit(limit int) Option {
+	return func(e *Engine) {
+		if limit <= 0 {
+			return
+		}
+		e.history.mu.Lock()
+		e.history.limit = limit
+		e.history.mu.Unlock()
+		_ = e.history.Prune(limit)
+	}
+}
+
+// History exposes the engine's command history.
+func (e *Engine) History() *HistoryManager { return e.history }
+
+// expandHistory rewrites `!!` and `!N` to the referenced history line, echoing the result.
+func (s *Session) expandHistory(line string) (string, error) {
+	if !strings.HasPrefix(line, "!") || len(line) < 2 {
+		return line, nil
+	}
+	ref, rest, _ := strings.Cut(line[1:], " ")
+	var entry HistoryEntry
+	var ok bool
+	if ref == "!" {
+		entry, ok = s.engine.history.Last()
+	} else {
+		n, err := strconv.Atoi(ref)
+		if err != nil {
+			return line, nil
+		}
+		entry, ok = s.engine.history.Get(n)
+	}
+	if !ok {
+		return "", fmt.Errorf("%s: event not found", line[:len(ref)+1])
+	}
+	expanded := entry.Line
+	if rest != "" {
+		expanded += " " + rest
+	}
+	fmt.Fprintln(s.OutputWriter(), expanded)
+	return expanded, nil
+}
+
+// recordHistory adds an entered line to the engine history under the current context.
+func (s *Session) recordHistory(line string) {
+	if _, err := s.engine.history.Record(line, s.contexts.Current().Spec.Name, s.id); err != nil {
+		fmt.Fprintf(s.OutputWriter(), "Error saving history: %v\n", err)
+	}
+}
+
+// history command -------------------------------------------------------------
+
+type historyCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *historyCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "history",
+			Summary:     "Search command history",
+			Description: "Lists entered commands. Inside a context only that context's commands are shown unless --all or --context is given. Re-run an entry with !N, or the previous line with !!.",
+			Context:     "",
+			Flags: []FlagSpec{
+				{Name: "grep", Shorthand: "g", Type: ArgTypeString, Description: "Only show lines matching this regular expression"},
+				{Name: "last", Shorthand: "n", Type: ArgTypeInt, Description: "Only show the N most recent entries"},
+				{Name: "context", Type: ArgTypeString, Description: "Only show entries entered in this context"},
+				{Name: "all", Shorthand: "a", Type: ArgTypeBool, Description: "Show entries from every context"},
+			},
+			Examples: []Example{
+				{Description: "Find recent deploys", Command: "history --grep deploy --last 10"},
+				{Description: "Re-run entry 42", Command: "!42"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *historyCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &historyCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type historyCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *historyCommand) Spec() CommandSpec { return c.spec }
+
+func (c *historyCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	q := HistoryQuery{
+		Context: input.Flags.String("context"),
+		Grep:    input.Flags.String("grep"),
+		Last:    input.Flags.Int("last"),
+	}
+	if session, ok := sessionOf(rt); ok && q.Context == "" && !input.Flags.Bool("all") {
+		q.Context = session.contexts.Current().Spec.Name
+	}
+	entries, err := c.engine.history.Query(q)
+	if err != nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+	}
+	if len(entries) == 0 {
+		rt.Output().Info("No history.")
+		return CommandResult{Status: StatusSuccess}
+	}
+	rows := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		ctx := entry.Context
+		if ctx == "" {
+			ctx = "/"
+		}
+		rows = append(rows, []string{strconv.Itoa(entry.Index), entry.Time.Format("15:04:05"), ctx, entry.Line})
+	}
+	rt.Output().WriteTable([]string{"#", "Time", "Context", "Command"}, rows)
+	return CommandResult{Status: StatusSuccess, Payload: entries}
+}
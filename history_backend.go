@@ -0,0 +1,410 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// History stores command history entries. Implementations assign increasing
+// indices on Append and return entries oldest first.
+type History interface {
+	Append(ctx context.Context, entry HistoryEntry) (HistoryEntry, error)
+	Search(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error)
+	List(ctx context.Context) ([]HistoryEntry, error)
+	// Prune discards all but the keep most recent entries; keep <= 0 is a no-op.
+	Prune(ctx context.Context, keep int) error
+}
+
+// filterHistory applies q to entries already ordered oldest first.
+func filterHistory(entries []HistoryEntry, q HistoryQuery) ([]HistoryEntry, error) {
+	var re *regexp.Regexp
+	if q.Grep != "" {
+		var err error
+		if re, err = regexp.Compile(q.Grep); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	var out []HistoryEntry
+	for _, entry := range entries {
+		if q.Index > 0 && entry.Index != q.Index {
+			continue
+		}
+		if q.Context != "" && entry.Context != q.Context {
+			continue
+		}
+		if re != nil && !re.MatchString(entry.Line) {
+			continue
+		}
+		out = append(out, entry)
+	}
+	if q.Last > 0 && len(out) > q.Last {
+		out = out[len(out)-q.Last:]
+	}
+	return out, nil
+}
+
+// MemoryHistory keeps a bounded history in process memory.
+type MemoryHistory struct {
+	mu      sync.RWMutex
+	limit   int
+	next    int
+	entries []HistoryEntry
+}
+
+// NewMemoryHistory constructs a history retaining at most limit entries.
+func NewMemoryHistory(limit int) *MemoryHistory {
+	if limit <= 0 {
+		limit = DefaultHistorySize
+	}
+	return &MemoryHistory{limit: limit, next: 1}
+}
+
+// Append implements History.
+func (m *MemoryHistory) Append(ctx context.Context, entry HistoryEntry) (HistoryEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.appendLocked(entry)
+	return m.entries[len(m.entries)-1], nil
+}
+
+func (m *MemoryHistory) appendLocked(entry HistoryEntry) {
+	if entry.Index < m.next {
+		entry.Index = m.next
+	}
+	m.next = entry.Index + 1
+	m.entries = append(m.entries, entry)
+	m.trimLocked(m.limit)
+}
+
+func (m *MemoryHistory) trimLocked(keep int) bool {
+	over := len(m.entries) - keep
+	if keep <= 0 || over <= 0 {
+		return false
+	}
+	m.entries = append([]HistoryEntry(nil), m.entries[over:]...)
+	return true
+}
+
+// Search implements History.
+func (m *MemoryHistory) Search(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) {
+	entries, _ := m.List(ctx)
+	return filterHistory(entries, q)
+}
+
+// List implements History.
+func (m *MemoryHistory) List(ctx context.Context) ([]HistoryEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]HistoryEntry(nil), m.entries...), nil
+}
+
+// Prune implements History.
+func (m *MemoryHistory) Prune(ctx context.Context, keep int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.trimLocked(keep)
+	return nil
+}
+
+// FileHistory persists history as JSON lines, caching retained entries in memory.
+type FileHistory struct {
+	path string
+	mem  *MemoryHistory
+}
+
+// OpenFileHistory loads path (a missing file is not an error), keeping at most limit entries.
+func OpenFileHistory(path string, limit int) (*FileHistory, error) {
+	h := &FileHistory{path: path, mem: NewMemoryHistory(limit)}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	read := 0
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Line == "" {
+			continue
+		}
+		h.mem.appendLocked(entry)
+		read++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if read > len(h.mem.entries) {
+		if err := h.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Append implements History.
+func (h *FileHistory) Append(ctx context.Context, entry HistoryEntry) (HistoryEntry, error) {
+	entry, _ = h.mem.Append(ctx, entry)
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return entry, err
+	}
+	defer f.Close()
+	return entry, json.NewEncoder(f).Encode(entry)
+}
+
+// Search implements History.
+func (h *FileHistory) Search(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) {
+	return h.mem.Search(ctx, q)
+}
+
+// List implements History.
+func (h *FileHistory) List(ctx context.Context) ([]HistoryEntry, error) { return h.mem.List(ctx) }
+
+// Prune implements History, rewriting the file when entries are dropped.
+func (h *FileHistory) Prune(ctx context.Context, keep int) error {
+	h.mem.mu.Lock()
+	trimmed := h.mem.trimLocked(keep)
+	h.mem.mu.Unlock()
+	if !trimmed {
+		return nil
+	}
+	return h.rewrite()
+}
+
+// rewrite replaces the file with the retained entries.
+func (h *FileHistory) rewrite() error {
+	entries, _ := h.mem.List(context.Background())
+	tmp := h.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLiteHistory stores history in a SQL table through database/sql. The caller
+// opens db with a SQLite driver of their choice; any driver accepting `?`
+// placeholders and INTEGER PRIMARY KEY AUTOINCREMENT works.
+type SQLiteHistory struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLiteHistory creates the history table (default planetui_history) if needed.
+func NewSQLiteHistory(db *sql.DB, table string) (*SQLiteHistory, error) {
+	if table == "" {
+		table = "planetui_history"
+	}
+	if !sqlIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + table + ` (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		line TEXT NOT NULL,
+		context TEXT NOT NULL DEFAULT '',
+		session TEXT NOT NULL DEFAULT '',
+		time TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteHistory{db: db, table: table}, nil
+}
+
+// Append implements History.
+func (h *SQLiteHistory) Append(ctx context.Context, entry HistoryEntry) (HistoryEntry, error) {
+	res, err := h.db.ExecContext(ctx, `INSERT INTO `+h.table+` (line, context, session, time) VALUES (?, ?, ?, ?)`,
+		entry.Line, entry.Context, entry.Session, entry.Time.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return entry, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return entry, err
+	}
+	entry.Index = int(id)
+	return entry, nil
+}
+
+// Search implements History. Context and index filters run in SQL; Grep runs in Go.
+func (h *SQLiteHistory) Search(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) {
+	query := `SELECT id, line, context, session, time FROM ` + h.table + ` WHERE 1=1`
+	var args []any
+	if q.Index > 0 {
+		query += ` AND id = ?`
+		args = append(args, q.Index)
+	}
+	if q.Context != "" {
+		query += ` AND context = ?`
+		args = append(args, q.Context)
+	}
+	if q.Last > 0 && q.Grep == "" {
+		query = `SELECT * FROM (` + query + ` ORDER BY id DESC LIMIT ` + strconv.Itoa(q.Last) + `) AS recent`
+	}
+	entries, err := h.query(ctx, query+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return filterHistory(entries, q)
+}
+
+// List implements History.
+func (h *SQLiteHistory) List(ctx context.Context) ([]HistoryEntry, error) {
+	return h.query(ctx, `SELECT id, line, context, session, time FROM `+h.table+` ORDER BY id`)
+}
+
+// Prune implements History.
+func (h *SQLiteHistory) Prune(ctx context.Context, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	_, err := h.db.ExecContext(ctx, `DELETE FROM `+h.table+` WHERE id NOT IN (SELECT id FROM `+h.table+` ORDER BY id DESC LIMIT ?)`, keep)
+	return err
+}
+
+func (h *SQLiteHistory) query(ctx context.Context, query string, args ...any) ([]HistoryEntry, error) {
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		var ts string
+		if err := rows.Scan(&entry.Index, &entry.Line, &entry.Context, &entry.Session, &ts); err != nil {
+			return nil, err
+		}
+		entry.Time, _ = time.Parse(time.RFC3339Nano, ts)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// HTTPHistory sends history to a remote service for central audit:
+//
+//	POST   URL                 body: HistoryEntry, response: stored HistoryEntry
+//	GET    URL?context=&grep=&last=&index=   response: []HistoryEntry, oldest first
+//	DELETE URL?keep=N
+type HTTPHistory struct {
+	URL    string
+	Client *http.Client
+	// Header is added to every request, e.g. an Authorization token.
+	Header http.Header
+}
+
+// Append implements History.
+func (h HTTPHistory) Append(ctx context.Context, entry HistoryEntry) (HistoryEntry, error) {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return entry, err
+	}
+	var stored HistoryEntry
+	if err := h.do(ctx, http.MethodPost, h.URL, bytes.NewReader(body), &stored); err != nil {
+		return entry, err
+	}
+	return stored, nil
+}
+
+// Search implements History.
+func (h HTTPHistory) Search(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) {
+	params := url.Values{}
+	if q.Context != "" {
+		params.Set("context", q.Context)
+	}
+	if q.Grep != "" {
+		params.Set("grep", q.Grep)
+	}
+	if q.Last > 0 {
+		params.Set("last", strconv.Itoa(q.Last))
+	}
+	if q.Index > 0 {
+		params.Set("index", strconv.Itoa(q.Index))
+	}
+	var entries []HistoryEntry
+	err := h.do(ctx, http.MethodGet, h.withQuery(params), nil, &entries)
+	return entries, err
+}
+
+// List implements History.
+func (h HTTPHistory) List(ctx context.Context) ([]HistoryEntry, error) {
+	return h.Search(ctx, HistoryQuery{})
+}
+
+// Prune implements History.
+func (h HTTPHistory) Prune(ctx context.Context, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	return h.do(ctx, http.MethodDelete, h.withQuery(url.Values{"keep": {strconv.Itoa(keep)}}), nil, nil)
+}
+
+func (h HTTPHistory) withQuery(params url.Values) string {
+	if len(params) == 0 {
+		return h.URL
+	}
+	return h.URL + "?" + params.Encode()
+}
+
+func (h HTTPHistory) do(ctx context.Context, method, target string, body *bytes.Reader, out any) error {
+	var req *http.Request
+	var err error
+	if body != nil {
+		req, err = http.NewRequestWithContext(ctx, method, target, body)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, target, nil)
+	}
+	if err != nil {
+		return err
+	}
+	for k, v := range h.Header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: unexpected status %s", h.URL, resp.Status)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// IdleAction selects what happens when a session exceeds its idle timeout.
+type IdleAction int
+
+const (
+	// IdleLock locks the shell until the Authenticator re-verifies the operator.
+	// Without an Authenticator the session exits instead.
+	IdleLock IdleAction = iota
+	// IdleExit ends the session.
+	IdleExit
+)
+
+// IdleOptions configure the idle timeout.
+type IdleOptions struct {
+	Timeout time.Duration
+	// Warning is how long before the timeout a notice is printed; zero disables it.
+	Warning time.Duration
+	Action  IdleAction
+	// UnlockAttempts bounds failed re-authentications before the session exits (default 3).
+	UnlockAttempts int
+}
+
+// errIdleExit is returned by line readers when the idle timeout ends the session.
+var errIdleExit = errors.New("session closed after inactivity")
+
+// WithIdleTimeout locks or exits interactive sessions left idle at the prompt.
+func WithIdleTimeout(opts IdleOptions) Option {
+	return func(e *Engine) {
+		if opts.UnlockAttempts <= 0 {
+			opts.UnlockAttempts = 3
+		}
+		e.idle = opts
+	}
+}
+
+// idleMonitor tracks prompt inactivity for one running loop.
+type idleMonitor struct {
+	opts    IdleOptions
+	warn    func(remaining time.Duration)
+	expire  func()
+	mu      sync.Mutex
+	timers  []*time.Timer
+	expired bool
+}
+
+func newIdleMonitor(opts IdleOptions, warn func(time.Duration), expire func()) *idleMonitor {
+	return &idleMonitor{opts: opts, warn: warn, expire: expire}
+}
+
+// touch restarts the inactivity countdown.
+func (m *idleMonitor) touch() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopLocked()
+	if m.opts.Warning > 0 && m.opts.Warning < m.opts.Timeout {
+		remaining := m.opts.Warning
+		m.timers = append(m.timers, time.AfterFunc(m.opts.Timeout-remaining, func() { m.warn(remaining) }))
+	}
+	m.timers = append(m.timers, time.AfterFunc(m.opts.Timeout, func() {
+		m.mu.Lock()
+		m.expired = true
+		m.mu.Unlock()
+		m.expire()
+	}))
+}
+
+// pause stops the countdown while a command runs.
+func (m *idleMonitor) pause() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopLocked()
+}
+
+func (m *idleMonitor) stopLocked() {
+	for _, t := range m.timers {
+		t.Stop()
+	}
+	m.timers = nil
+}
+
+// takeExpired reports and clears the expired flag.
+func (m *idleMonitor) takeExpired() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expired := m.expired
+	m.expired = false
+	return expired
+}
+
+// startIdle returns a monitor for the session's loop, or nil when no timeout is configured.
+// closeInput is called when the session must exit and the pending read has to be interrupted.
+func (s *Session) startIdle(closeInput func()) *idleMonitor {
+	opts := s.engine.idle
+	if opts.Timeout <= 0 {
+		return nil
+	}
+	exits := opts.Action == IdleExit || s.engine.authenticator == nil
+	verb := "lock"
+	if exits {
+		verb = "close"
+	}
+	m := newIdleMonitor(opts, func(remaining time.Duration) {
+		if remaining >= time.Second {
+			remaining = remaining.Round(time.Second)
+		}
+		fmt.Fprintf(s.OutputWriter(), "\nSession idle: it will %s in %s without input.\n", verb, remaining)
+	}, func() {
+		if exits {
+			fmt.Fprintln(s.OutputWriter(), "\nSession closed after inactivity.")
+			closeInput()
+			return
+		}
+		fmt.Fprintln(s.OutputWriter(), "\nSession locked due to inactivity. Press Enter to unlock.")
+	})
+	m.touch()
+	return m
+}
+
+// unlock re-verifies the operator after an idle lock, reporting whether the session may continue.
+func (s *Session) unlock(readLine, readPassword func(prompt string) (string, error)) bool {
+	auth := s.engine.authenticator
+	if auth == nil {
+		return false
+	}
+	username := ""
+	if p, ok := PrincipalFromSession(s.store); ok {
+		username = p.Name
+	}
+	for attempt := 0; attempt < s.engine.idle.UnlockAttempts; attempt++ {
+		if username == "" {
+			name, err := readLine("Username: ")
+			if err != nil {
+				return false
+			}
+			username = name
+		}
+		password, err := readPassword(fmt.Sprintf("Password for %s: ", username))
+		if err != nil {
+			return false
+		}
+		principal, err := auth.Authenticate(context.Background(), Credentials{Username: username, Password: password})
+		if err == nil && principal != nil {
+			if principal.Name == "" {
+				principal.Name = username
+			}
+			principal.AuthenticatedAt = time.Now()
+			s.store.Set(SessionKeyPrincipal, principal)
+			s.engine.emitAuthEvent(AuthEvent{Type: AuthLogin, Username: principal.Name, Principal: principal})
+			fmt.Fprintln(s.OutputWriter(), "Session unlocked.")
+			return true
+		}
+		if err == nil {
+			err = ErrInvalidCredentials
+		}
+		s.engine.emitAuthEvent(AuthEvent{Type: AuthLoginFailed, Username: username, Err: err})
+		fmt.Fprintln(s.OutputWriter(), "Unlock failed.")
+	}
+	fmt.Fprintln(s.OutputWriter(), "Too many failed attempts; closing session.")
+	return false
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// WithInterruptTasks makes Ctrl-C also cancel the tasks started by the
+// command it interrupts, rather than leaving them running in the background.
+func WithInterruptTasks(enabled bool) Option {
+	return func(e *Engine) { e.interruptTasks = enabled }
+}
+
+// runningInvocations tracks the cancel functions of a session's running commands.
+type runningInvocations struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	// foreground holds the ids of the invocations running at the prompt
+	// through invokeForeground, as opposed to background jobs.
+	foreground map[string]bool
+	// suspend receives the terminal's suspend key while catchInterrupts is
+	// active; nil otherwise.
+	suspend chan struct{}
+}
+
+// trackInvocation registers the running invocation id until the returned
+// function is called.
+func (s *Session) trackInvocation(id string, cancel context.CancelFunc) func() {
+	r := &s.running
+	r.mu.Lock()
+	if r.cancels == nil {
+		r.cancels = map[string]context.CancelFunc{}
+	}
+	r.cancels[id] = cancel
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		delete(r.cancels, id)
+		r.mu.Unlock()
+	}
+}
+
+// markForeground records the invocation id as running at the prompt until
+// the returned function is called.
+func (s *Session) markForeground(id string) func() {
+	r := &s.running
+	r.mu.Lock()
+	if r.foreground == nil {
+		r.foreground = map[string]bool{}
+	}
+	r.foreground[id] = true
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		delete(r.foreground, id)
+		r.mu.Unlock()
+	}
+}
+
+// Interrupt cancels the context of the command running in the foreground of
+// the session, and with WithInterruptTasks the tasks it started. Background
+// jobs, including commands suspended with Ctrl-Z, keep running. It reports
+// whether a foreground command was running.
+func (s *Session) Interrupt() bool {
+	r := &s.running
+	r.mu.Lock()
+	ids := make(map[string]bool, len(r.foreground))
+	for id := range r.foreground {
+		if cancel, ok := r.cancels[id]; ok {
+			cancel()
+			ids[id] = true
+		}
+	}
+	r.mu.Unlock()
+	if s.engine.interruptTasks && len(ids) > 0 {
+		for _, task := range s.tasks.Tasks() {
+			if ids[task.InvocationID] && (task.Status == TaskPending || task.Status == TaskRunning) {
+				s.tasks.Cancel(task.ID)
+			}
+		}
+	}
+	return len(ids) > 0
+}
+
+// dispatchForeground runs a line typed at the prompt with Ctrl-C and Ctrl-Z
+// handled as described on catchInterrupts.
+func (s *Session) dispatchForeground(tokens []token) {
+	release := s.catchInterrupts()
+	defer release()
+	if err := s.dispatch(tokens); err != nil {
+		s.reportError(err)
+	}
+}
+
+// catchInterrupts handles SIGINT while a command line runs on the default
+// session, which owns the process terminal: the first Ctrl-C interrupts the
+// running command and the second exits the process with status 130, as shells
+// do. Ctrl-Z moves the running command to the background; see
+// invokeForeground. Other sessions are left alone since the signals are not
+// theirs. The returned function restores the default handling.
+func (s *Session) catchInterrupts() func() {
+	if s != s.engine.defaultSession {
+		return func() {}
+	}
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	suspend := make(chan struct{}, 1)
+	signal.Notify(signals, append([]os.Signal{os.Interrupt}, suspendSignals...)...)
+	s.running.mu.Lock()
+	s.running.suspend = suspend
+	s.running.mu.Unlock()
+	go func() {
+		interrupted := false
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-signals:
+				if sig != os.Interrupt {
+					select {
+					case suspend <- struct{}{}:
+					default:
+					}
+					continue
+				}
+				if interrupted {
+					fmt.Fprintln(s.OutputWriter(), "\nExiting.")
+					os.Exit(130)
+				}
+				interrupted = true
+				if s.Interrupt() {
+					fmt.Fprintln(s.OutputWriter(), "\nInterrupted; press Ctrl-C again to exit.")
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		s.running.mu.Lock()
+		s.running.suspend = nil
+		s.running.mu.Unlock()
+	}
+}
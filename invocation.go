@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+)
+
+// InvocationSource says where a command line came from.
+type InvocationSource string
+
+const (
+	SourceInteractive InvocationSource = "interactive"
+	SourceScript      InvocationSource = "script"
+	SourceSSH         InvocationSource = "ssh"
+	SourceAPI         InvocationSource = "api"
+)
+
+// InvocationMeta describes one command invocation, giving audit, RBAC, and
+// logging middleware consistent request data.
+type InvocationMeta struct {
+	// ID is unique per invocation, e.g. inv-01HZX3M5Q8K2W7YV9T4N6R0BCD.
+	ID         string
+	Source     InvocationSource
+	ClientAddr string
+	SessionID  string
+	Start      time.Time
+}
+
+type invocationKey struct{}
+
+func withInvocationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, invocationKey{}, id)
+}
+
+// InvocationID returns the ID of the command invocation ctx belongs to: the
+// command's input context and Cancellation carry it, and so do the contexts of
+// tasks the command spawns. Pass it to backends to correlate their logs.
+func InvocationID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(invocationKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithInvocationSource sets the source reported for the session's commands.
+// Sessions default to SourceInteractive; lines run by RunScript report SourceScript.
+func WithInvocationSource(source InvocationSource) SessionOption {
+	return func(s *Session) {
+		if source != "" {
+			s.source = source
+		}
+	}
+}
+
+// WithClientAddr records the address of the session's remote client.
+func WithClientAddr(addr string) SessionOption {
+	return func(s *Session) { s.clientAddr = addr }
+}
+
+// invocationMeta describes an invocation starting now.
+func (s *Session) invocationMeta(start time.Time) InvocationMeta {
+	source := s.source
+	if s.scripting.Load() > 0 {
+		source = SourceScript
+	}
+	return InvocationMeta{ID: newInvocationID(start), Source: source, ClientAddr: s.clientAddr, SessionID: s.id, Start: start}
+}
+
+// crockford is the base32 alphabet of ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newInvocationID returns "inv-" and a ULID: 48 bits of millisecond time then
+// 80 random bits, so IDs sort by start time.
+func newInvocationID(at time.Time) string {
+	var random [10]byte
+	_, _ = rand.Read(random[:])
+	buf := make([]byte, 0, 30)
+	buf = append(buf, "inv-"...)
+	ms := uint64(at.UnixMilli())
+	for shift := 45; shift >= 0; shift -= 5 {
+		buf = append(buf, crockford[(ms>>uint(shift))&31])
+	}
+	var acc uint64
+	bits := 0
+	for _, b := range random {
+		acc = acc<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			buf = append(buf, crockford[(acc>>uint(bits))&31])
+		}
+	}
+	return string(buf)
+}
@@ -0,0 +1,446 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// job is a command line running as a background task, started with a
+// trailing "&".
+type job struct {
+	id     string
+	line   string
+	output *jobWriter
+
+	mu     sync.Mutex
+	result CommandResult
+	err    error
+}
+
+// jobWriter records a job's output and copies it to the terminals attached
+// with fg or bg.
+type jobWriter struct {
+	mu       sync.Mutex
+	capture  *ringBuffer
+	task     io.Writer
+	attached map[*io.Writer]io.Writer
+}
+
+// newJobWriter keeps up to size bytes of output, DefaultTaskOutputSize when size <= 0.
+func newJobWriter(size int) *jobWriter {
+	if size <= 0 {
+		size = DefaultTaskOutputSize
+	}
+	return &jobWriter{capture: newRingBuffer(size), attached: map[*io.Writer]io.Writer{}}
+}
+
+func (w *jobWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.capture.Write(p)
+	if w.task != nil {
+		w.task.Write(p)
+	}
+	for _, dst := range w.attached {
+		dst.Write(p)
+	}
+	return len(p), nil
+}
+
+// setTask also copies output, including what was captured before, to the job
+// task's own capture, for `task output`.
+func (w *jobWriter) setTask(task io.Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.task = task
+	io.WriteString(task, w.capture.String())
+}
+
+// attach replays the output captured so far to dst and copies later output to
+// it until the returned func is called.
+func (w *jobWriter) attach(dst io.Writer) func() {
+	key := &dst
+	w.mu.Lock()
+	io.WriteString(dst, w.capture.String())
+	w.attached[key] = dst
+	w.mu.Unlock()
+	return func() {
+		w.mu.Lock()
+		delete(w.attached, key)
+		w.mu.Unlock()
+	}
+}
+
+// jobTable holds a session's jobs.
+type jobTable struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+	// order lists job IDs, most recent last.
+	order []string
+	// echoes holds the funcs ending the bg echo of each job.
+	echoes map[string]func()
+}
+
+func (t *jobTable) add(j *job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.jobs == nil {
+		t.jobs = map[string]*job{}
+	}
+	t.jobs[j.id] = j
+	t.order = append(t.order, j.id)
+}
+
+// lookup returns the job id, or the most recent job when id is empty.
+func (t *jobTable) lookup(id string) (*job, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if id == "" && len(t.order) > 0 {
+		id = t.order[len(t.order)-1]
+	}
+	j, ok := t.jobs[id]
+	return j, ok
+}
+
+// echo starts echoing j's output to w, reporting false if it already is.
+func (t *jobTable) echo(j *job, w *prefixWriter) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.echoes[j.id]; ok {
+		return false
+	}
+	if t.echoes == nil {
+		t.echoes = map[string]func(){}
+	}
+	detach := j.output.attach(w)
+	t.echoes[j.id] = func() {
+		detach()
+		w.Flush()
+	}
+	return true
+}
+
+// stopEcho ends the echo of job id.
+func (t *jobTable) stopEcho(id string) {
+	t.mu.Lock()
+	stop, ok := t.echoes[id]
+	delete(t.echoes, id)
+	t.mu.Unlock()
+	if ok {
+		stop()
+	}
+}
+
+func (t *jobTable) list() []*job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := make([]*job, 0, len(t.order))
+	for _, id := range t.order {
+		list = append(list, t.jobs[id])
+	}
+	return list
+}
+
+// backgroundOperator ends a command line that runs as a job, as in `backup &`.
+const backgroundOperator = "&"
+
+// backgroundLine reports whether tokens end with an unquoted "&" word,
+// returning them without it. A quoted '&', or one inside a word as in
+// 'Q&A', is an ordinary argument.
+func backgroundLine(tokens []token) ([]token, bool) {
+	last := tokens[len(tokens)-1]
+	if last.op && last.text == backgroundOperator {
+		return tokens[:len(tokens)-1], len(tokens) > 1
+	}
+	return tokens, false
+}
+
+// runBackground starts tokens as a job. Its output is captured rather than
+// shown; fg and bg attach to it.
+func (s *Session) runBackground(tokens []string) (CommandResult, error) {
+	entry, args, err := s.resolveCommand(tokens)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	line := QuoteCommandLine(append([]string{entry.Spec.Name}, redactArgs(entry.resolved(), args)...))
+	j := &job{line: line, output: newJobWriter(s.engine.MemoryBudget().TaskOutput)}
+	meta := s.invocationMeta(time.Now())
+	handle := s.tasks.scoped("", meta.ID).Spawn(line, func(ctx context.Context, output OutputChannel) error {
+		j.output.setTask(output.Writer())
+		stop := context.AfterFunc(ctx, func() { s.cancelInvocation(meta.ID) })
+		defer stop()
+		result, err := s.invokeAs(meta, entry, args, j.output)
+		j.mu.Lock()
+		j.result, j.err = result, err
+		j.mu.Unlock()
+		if err != nil {
+			fmt.Fprintln(j.output, "Error:", err)
+		}
+		return j.failure()
+	}, TaskOptions{Silent: true})
+	j.id = handle.ID
+	s.jobs.add(j)
+	fmt.Fprintf(s.OutputWriter(), "[%s] %s\n", handle.ID, line)
+	return CommandResult{Status: StatusSuccess, Payload: handle}, nil
+}
+
+// invokeForeground runs an invocation at the prompt, writing to w; in and
+// piped are as for invokePiped. While catchInterrupts
+// listens for the suspend key, the command runs on its own goroutine with its
+// output passing through a jobWriter, so that Ctrl-Z can hand it, still
+// running with its context and output so far, to a background job and return
+// to the prompt; suspended is then true. fg detaches instead of suspending.
+func (s *Session) invokeForeground(ctx context.Context, meta InvocationMeta, entry CommandEntry, args []string, w io.Writer, in any, piped bool) (result CommandResult, err error, suspended bool) {
+	s.running.mu.Lock()
+	suspend := s.running.suspend
+	s.running.mu.Unlock()
+	unmark := s.markForeground(meta.ID)
+	if suspend == nil {
+		defer unmark()
+		result, err = s.invokePiped(ctx, meta, entry, args, w, in, piped)
+		return result, err, false
+	}
+	j := &job{output: newJobWriter(s.engine.MemoryBudget().TaskOutput)}
+	detach := j.output.attach(w)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r, e := s.invokePiped(ctx, meta, entry, args, j.output, in, piped)
+		j.mu.Lock()
+		j.result, j.err = r, e
+		j.mu.Unlock()
+	}()
+wait:
+	for {
+		select {
+		case <-done:
+			detach()
+			unmark()
+			return j.result, j.err, false
+		case <-suspend:
+			if _, ok := entry.Factory.(*fgCommandFactory); !ok {
+				break wait
+			}
+			s.cancelInvocation(meta.ID)
+		}
+	}
+	detach()
+	unmark()
+	j.line = QuoteCommandLine(append([]string{entry.Spec.Name}, redactArgs(entry.resolved(), args)...))
+	handle := s.tasks.scoped("", meta.ID).Spawn(j.line, func(ctx context.Context, output OutputChannel) error {
+		j.output.setTask(output.Writer())
+		stop := context.AfterFunc(ctx, func() { s.cancelInvocation(meta.ID) })
+		defer stop()
+		<-done
+		return j.failure()
+	}, TaskOptions{Silent: true})
+	j.id = handle.ID
+	s.jobs.add(j)
+	fmt.Fprintf(s.OutputWriter(), "\n[%s] suspended to the background: %s (fg %s to resume)\n", handle.ID, j.line, handle.ID)
+	return CommandResult{Status: StatusSuccess, Payload: handle}, nil, true
+}
+
+// failure returns the error a job's task finishes with.
+func (j *job) failure() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch {
+	case j.err != nil:
+		return j.err
+	case j.result.Status == StatusFailed:
+		if j.result.Error != nil {
+			return errors.New(j.result.Error.Message)
+		}
+		return errors.New("command failed")
+	}
+	return nil
+}
+
+// cancelInvocation cancels one running invocation of the session.
+func (s *Session) cancelInvocation(id string) {
+	s.running.mu.Lock()
+	cancel := s.running.cancels[id]
+	s.running.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func completeJobIDs(prefix string, rt CommandRuntime) []string {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return nil
+	}
+	var ids []string
+	for _, j := range session.jobs.list() {
+		if strings.HasPrefix(j.id, prefix) {
+			ids = append(ids, j.id)
+		}
+	}
+	return ids
+}
+
+// fg command ------------------------------------------------------------------
+
+type fgCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *fgCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "fg",
+			Summary:     "Bring a background command to the foreground",
+			Description: "Shows the output of a command started with a trailing & and follows it until it finishes. Ctrl-C detaches again and leaves it running. Without an ID, the most recent one is used.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "id", Type: ArgTypeString, Description: "Task ID", Complete: completeJobIDs},
+			},
+			Examples: []Example{{Description: "Reattach to a diagnostic", Command: "fg task-3"}},
+		}
+	}
+	return f.spec
+}
+
+func (f *fgCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &fgCommand{spec: f.Spec()}, nil
+}
+
+type fgCommand struct {
+	spec CommandSpec
+}
+
+func (c *fgCommand) Spec() CommandSpec { return c.spec }
+
+func (c *fgCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, j, failed := lookupJob(rt, input.Args.String("id"))
+	if j == nil {
+		return failed
+	}
+	_, events, stop, _ := session.tasks.Follow(j.id)
+	defer stop()
+	detach := j.output.attach(rt.Output().Writer())
+	finished := followTask(rt.Cancellation(), events, func(TaskEvent) {})
+	detach()
+	if !finished {
+		rt.Output().Info(fmt.Sprintf("\nDetached from %s; it keeps running in the background.", j.id))
+		return CommandResult{Status: StatusSuccess}
+	}
+	j.mu.Lock()
+	result, err := j.result, j.err
+	j.mu.Unlock()
+	if err != nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+	}
+	if result.Status == "" {
+		// The job was cancelled before its command returned.
+		handle, _ := session.tasks.DescribeTask(j.id)
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("%s %s", j.id, handle.Status), Severity: SeverityError}}
+	}
+	return result
+}
+
+// lookupJob finds the job named id, or the latest one, returning a failed
+// result when there is none.
+func lookupJob(rt CommandRuntime, id string) (*Session, *job, CommandResult) {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return nil, nil, CommandResult{Status: StatusFailed, Error: &CommandError{Message: "job control requires an engine session", Severity: SeverityError}}
+	}
+	j, ok := session.jobs.lookup(id)
+	if !ok {
+		msg := "no background commands; end a command line with & to start one"
+		if id != "" {
+			msg = fmt.Sprintf("%s is not a background command", id)
+		}
+		return session, nil, CommandResult{Status: StatusFailed, Error: &CommandError{Message: msg, Severity: SeverityError}}
+	}
+	return session, j, CommandResult{}
+}
+
+// bg command ------------------------------------------------------------------
+
+type bgCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *bgCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "bg",
+			Summary:     "List background commands or show their output as it arrives",
+			Description: "Without an ID, lists the commands started with a trailing &. With one, prints that command's output so far and keeps echoing new output, prefixed with its task ID, while the prompt stays free; --stop ends the echo.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "id", Type: ArgTypeString, Description: "Task ID", Complete: completeJobIDs},
+			},
+			Flags: []FlagSpec{
+				{Name: "stop", Type: ArgTypeBool, Description: "Stop echoing the command's output"},
+			},
+			Examples: []Example{
+				{Description: "List background commands", Command: "bg"},
+				{Description: "Echo a command's output", Command: "bg task-3"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *bgCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &bgCommand{spec: f.Spec()}, nil
+}
+
+type bgCommand struct {
+	spec CommandSpec
+}
+
+func (c *bgCommand) Spec() CommandSpec { return c.spec }
+
+func (c *bgCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	id := input.Args.String("id")
+	if id == "" {
+		session, ok := sessionOf(rt)
+		if !ok {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "job control requires an engine session", Severity: SeverityError}}
+		}
+		jobs := session.jobs.list()
+		if len(jobs) == 0 {
+			rt.Output().Info("No background commands.")
+			return CommandResult{Status: StatusSuccess}
+		}
+		rows := make([][]string, 0, len(jobs))
+		for _, j := range jobs {
+			status := "unknown"
+			if handle, ok := session.tasks.DescribeTask(j.id); ok {
+				status = string(handle.Status)
+			}
+			rows = append(rows, []string{j.id, status, j.line})
+		}
+		rt.Output().WriteTable([]string{"ID", "Status", "Command"}, rows)
+		return CommandResult{Status: StatusSuccess}
+	}
+	session, j, failed := lookupJob(rt, id)
+	if j == nil {
+		return failed
+	}
+	if input.Flags.Bool("stop") {
+		session.jobs.stopEcho(j.id)
+		return CommandResult{Status: StatusSuccess}
+	}
+	emit := echoLine(session.tasks.output)
+	echo := &prefixWriter{prefix: "[" + j.id + "] ", emit: func(line []byte) {
+		if len(bytes.TrimSpace(line)) > len(j.id)+2 {
+			emit(line)
+		}
+	}}
+	if !session.jobs.echo(j, echo) {
+		rt.Output().Info(fmt.Sprintf("%s is already echoing its output.", j.id))
+	}
+	return CommandResult{Status: StatusSuccess}
+}
@@ -0,0 +1,187 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// repairJSONFlag offers to fix an invalid ArgTypeJSON flag value interactively,
+// in $VISUAL/$EDITOR or an inline multi-line editor, re-validating until the
+// value parses or the operator gives up. It returns args with the corrected value.
+func (s *Session) repairJSONFlag(args []string, spec CommandSpec, err error) ([]string, bool) {
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Flag == "" {
+		return nil, false
+	}
+	var problem error
+	var raw string
+	var je *JSONValueError
+	var se *SchemaError
+	switch {
+	case errors.As(err, &je):
+		problem, raw = je, je.Value
+	case errors.As(err, &se):
+		problem, raw = se, se.Value
+	default:
+		return nil, false
+	}
+	var flag FlagSpec
+	for _, f := range spec.Flags {
+		if f.Name == pe.Flag {
+			flag = f
+		}
+	}
+	if flag.Type != ArgTypeJSON {
+		return nil, false
+	}
+	idx, inline := flagValueIndex(args, flag)
+	if idx < 0 {
+		return nil, false
+	}
+
+	question := fmt.Sprintf("--%s: %v. Edit the value?", flag.Name, problem)
+	for {
+		ok, cerr := s.Confirm(question)
+		if cerr != nil || !ok {
+			return nil, false
+		}
+		edited, eerr := s.editJSON(raw, problem)
+		if eerr != nil {
+			fmt.Fprintf(s.OutputWriter(), "Editor failed: %v\n", eerr)
+			return nil, false
+		}
+		if strings.TrimSpace(edited) == "" {
+			return nil, false
+		}
+		if _, verr := decodeJSONValue(edited, flag.Schema, flag.DecodeAs); verr != nil {
+			problem, raw = verr, edited
+			question = fmt.Sprintf("--%s: %v. Edit again?", flag.Name, problem)
+			continue
+		}
+		var compact bytes.Buffer
+		if cerr := json.Compact(&compact, []byte(edited)); cerr == nil {
+			edited = compact.String()
+		}
+		fixed := append([]string(nil), args...)
+		if inline {
+			fixed[idx] = fixed[idx][:strings.Index(fixed[idx], "=")+1] + edited
+		} else {
+			fixed[idx] = edited
+		}
+		return fixed, true
+	}
+}
+
+// flagValueIndex locates the token holding flag's value, reporting whether it is inline (--name=value).
+func flagValueIndex(args []string, flag FlagSpec) (int, bool) {
+	for i, tok := range args {
+		if tok == "--"+flag.Name || (flag.Shorthand != "" && tok == "-"+flag.Shorthand) {
+			if i+1 < len(args) {
+				return i + 1, false
+			}
+			return -1, false
+		}
+		if strings.HasPrefix(tok, "--"+flag.Name+"=") {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// editJSON returns a corrected JSON document for raw, which failed with problem.
+func (s *Session) editJSON(raw string, problem error) (string, error) {
+	editor := editorCommand()
+	if editor == "" {
+		return s.editJSONInline(raw, problem)
+	}
+	content := fmt.Sprintf("// %v\n// Lines starting with // are ignored. Save an empty document to cancel.\n%s\n", problem, indentJSON(raw))
+	data, err := runEditor(editor, "planetui-*.json", content)
+	if err != nil {
+		return "", err
+	}
+	var kept []string
+	for _, line := range strings.Split(data, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n"), nil
+}
+
+// editorCommand returns $VISUAL, or $EDITOR when that is unset.
+func editorCommand() string {
+	if editor := os.Getenv("VISUAL"); editor != "" {
+		return editor
+	}
+	return os.Getenv("EDITOR")
+}
+
+// runEditor opens content in a temporary file named after pattern with editor
+// and returns the saved file.
+func runEditor(editor, pattern, content string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(f.Name())
+	return string(data), err
+}
+
+// editJSONInline shows the invalid value, marking a syntax error's location, and reads a replacement.
+func (s *Session) editJSONInline(raw string, problem error) (string, error) {
+	w := s.OutputWriter()
+	je, syntax := problem.(*JSONValueError)
+	if !syntax {
+		raw = indentJSON(raw)
+	}
+	for i, line := range strings.Split(raw, "\n") {
+		fmt.Fprintf(w, "  %s\n", line)
+		if syntax && i+1 == je.Line {
+			fmt.Fprintf(w, "  %s^ %v\n", strings.Repeat(" ", je.Column-1), je.Err)
+		}
+	}
+	if !syntax {
+		fmt.Fprintf(w, "  %v\n", problem)
+	}
+	fmt.Fprintln(w, "Enter corrected JSON; finish with an empty line.")
+	var lines []string
+	for {
+		line, err := s.Ask("json> ")
+		if err != nil {
+			return "", err
+		}
+		if line == "" {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// indentJSON pretty-prints raw when it is valid enough to re-indent, otherwise returns it unchanged.
+func indentJSON(raw string) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(raw), "", "  "); err != nil {
+		return raw
+	}
+	return out.String()
+}
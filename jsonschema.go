@@ -0,0 +1,243 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// JSONSchema is the subset of JSON Schema used to validate ArgTypeJSON values:
+// type, enum, object properties/required/additionalProperties, array items and
+// bounds, string length and pattern, and numeric bounds.
+type JSONSchema struct {
+	Type                 string                 `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+}
+
+// ParseJSONSchema decodes a JSON Schema document.
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	var schema JSONSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// MustParseJSONSchema is ParseJSONSchema for schemas known to be valid, e.g. in a CommandSpec literal.
+func MustParseJSONSchema(data string) *JSONSchema {
+	schema, err := ParseJSONSchema([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// SchemaViolation is one field-level validation failure; Path is like $.spec.ports[0].
+type SchemaViolation struct {
+	Path    string
+	Message string
+}
+
+// SchemaError reports every violation found in a JSON value.
+type SchemaError struct {
+	Value      string
+	Violations []SchemaViolation
+}
+
+// Error implements error.
+func (e *SchemaError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Path + ": " + v.Message
+	}
+	return "schema validation failed: " + strings.Join(parts, "; ")
+}
+
+// Validate checks a decoded JSON value (as produced by json.Unmarshal into any).
+func (s *JSONSchema) Validate(v any) error {
+	var violations []SchemaViolation
+	s.validate("$", v, &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	return &SchemaError{Violations: violations}
+}
+
+func (s *JSONSchema) validate(path string, v any, out *[]SchemaViolation) {
+	if s == nil {
+		return
+	}
+	fail := func(format string, args ...any) {
+		*out = append(*out, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.Type != "" && !jsonTypeMatches(s.Type, v) {
+		fail("expected %s, got %s", s.Type, jsonTypeName(v))
+		return
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, candidate := range s.Enum {
+			if reflect.DeepEqual(candidate, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %s is not one of %s", compactJSON(v), compactJSON(s.Enum))
+		}
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				*out = append(*out, SchemaViolation{Path: path + "." + name, Message: "required field is missing"})
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				prop.validate(path+"."+k, val[k], out)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*out = append(*out, SchemaViolation{Path: path + "." + k, Message: "unknown field"})
+			}
+		}
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			fail("expected at least %d items, got %d", *s.MinItems, len(val))
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			fail("expected at most %d items, got %d", *s.MaxItems, len(val))
+		}
+		for i, item := range val {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, out)
+		}
+	case string:
+		n := len([]rune(val))
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("expected at least %d characters, got %d", *s.MinLength, n)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("expected at most %d characters, got %d", *s.MaxLength, n)
+		}
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				fail("invalid schema pattern %q: %v", s.Pattern, err)
+			} else if !re.MatchString(val) {
+				fail("value %q does not match %s", val, s.Pattern)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			fail("must be >= %v, got %v", *s.Minimum, val)
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			fail("must be <= %v, got %v", *s.Maximum, val)
+		}
+	}
+}
+
+func jsonTypeMatches(want string, v any) bool {
+	switch want {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	}
+	return jsonTypeName(v) == want
+}
+
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func compactJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// decodeJSONValue parses raw, validating it against schema and, when decodeAs is
+// set, strictly decoding it into a new value of decodeAs's type, which is returned.
+func decodeJSONValue(raw string, schema *JSONSchema, decodeAs any) (any, error) {
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return nil, newJSONValueError(raw, err)
+	}
+	if schema != nil {
+		if err := schema.Validate(v); err != nil {
+			err.(*SchemaError).Value = raw
+			return nil, err
+		}
+	}
+	if decodeAs == nil {
+		return v, nil
+	}
+	t := reflect.TypeOf(decodeAs)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	target := reflect.New(t)
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target.Interface()); err != nil {
+		return nil, &SchemaError{Value: raw, Violations: []SchemaViolation{strictDecodeViolation(err)}}
+	}
+	return target.Elem().Interface(), nil
+}
+
+// strictDecodeViolation converts an encoding/json decode error into a field-level violation.
+func strictDecodeViolation(err error) SchemaViolation {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		path := "$"
+		if typeErr.Field != "" {
+			path += "." + typeErr.Field
+		}
+		return SchemaViolation{Path: path, Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return SchemaViolation{Path: "$." + strings.Trim(field, `"`), Message: "unknown field"}
+	}
+	return SchemaViolation{Path: "$", Message: err.Error()}
+}
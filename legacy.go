@@ -1,5 +1,14 @@
 package tui
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
 // LegacyCommand describes the original interface from the minimal framework.
 type LegacyCommand interface {
 	Name() string
@@ -7,6 +16,13 @@ type LegacyCommand interface {
 	Exec(args []string)
 }
 
+// LegacyErrorCommand is an optional extension letting legacy commands report failure,
+// the equivalent of a non-zero exit status.
+type LegacyErrorCommand interface {
+	LegacyCommand
+	ExecErr(args []string) error
+}
+
 // LegacyAdapter wraps a LegacyCommand into the new Command interface.
 type LegacyAdapter struct {
 	legacy  LegacyCommand
@@ -23,11 +39,7 @@ type legacyFactory struct {
 }
 
 func (f *legacyFactory) Spec() CommandSpec {
-	return CommandSpec{
-		Name:    f.adapter.legacy.Name(),
-		Context: f.adapter.context,
-		Summary: f.adapter.legacy.Help(),
-	}
+	return f.adapter.Spec()
 }
 
 func (f *legacyFactory) New(rt CommandRuntime) (Command, error) {
@@ -35,12 +47,95 @@ func (f *legacyFactory) New(rt CommandRuntime) (Command, error) {
 }
 
 // Spec returns metadata for the wrapped command.
+// Arguments pass through verbatim since legacy commands parse their own.
 func (a *LegacyAdapter) Spec() CommandSpec {
-	return CommandSpec{Name: a.legacy.Name(), Summary: a.legacy.Help(), Context: a.context}
+	return CommandSpec{
+		Name:            a.legacy.Name(),
+		Summary:         a.legacy.Help(),
+		Context:         a.context,
+		Args:            []ArgSpec{{Name: "args", Type: ArgTypeString, Repeatable: true, Passthrough: true}},
+		NoImplicitFlags: true,
+	}
 }
 
-// Execute delegates to the legacy command, capturing output.
+// Execute delegates to the legacy command, capturing stdout/stderr into the output channel.
+// Panics and errors from LegacyErrorCommand map to StatusFailed.
 func (a *LegacyAdapter) Execute(rt CommandRuntime, input CommandInput) CommandResult {
-	a.legacy.Exec(input.Raw)
+	var execErr error
+	stdout, stderr, panicked, err := captureStdio(func() {
+		if ec, ok := a.legacy.(LegacyErrorCommand); ok {
+			execErr = ec.ExecErr(input.Raw)
+			return
+		}
+		a.legacy.Exec(input.Raw)
+	})
+	if err != nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: "failed to capture legacy output", Severity: SeverityError}}
+	}
+	for _, line := range splitOutputLines(stdout) {
+		rt.Output().Info(line)
+	}
+	for _, line := range splitOutputLines(stderr) {
+		rt.Output().Error(line)
+	}
+	if panicked != nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{
+			Err:      fmt.Errorf("legacy command panicked: %v", panicked),
+			Message:  fmt.Sprintf("command %s panicked: %v", a.legacy.Name(), panicked),
+			Severity: SeverityError,
+		}}
+	}
+	if execErr != nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: execErr, Severity: SeverityError}}
+	}
 	return CommandResult{Status: StatusSuccess}
 }
+
+// stdioMu serialises redirection of the process-wide os.Stdout/os.Stderr.
+var stdioMu sync.Mutex
+
+// captureStdio runs fn with os.Stdout and os.Stderr redirected to pipes.
+func captureStdio(fn func()) (stdout, stderr []byte, panicked any, err error) {
+	stdioMu.Lock()
+	defer stdioMu.Unlock()
+
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		outR.Close()
+		outW.Close()
+		return nil, nil, nil, err
+	}
+
+	var outBuf, errBuf bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); io.Copy(&outBuf, outR) }()
+	go func() { defer wg.Done(); io.Copy(&errBuf, errR) }()
+
+	origOut, origErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outW, errW
+	func() {
+		defer func() { panicked = recover() }()
+		fn()
+	}()
+	os.Stdout, os.Stderr = origOut, origErr
+
+	outW.Close()
+	errW.Close()
+	wg.Wait()
+	outR.Close()
+	errR.Close()
+	return outBuf.Bytes(), errBuf.Bytes(), panicked, nil
+}
+
+func splitOutputLines(data []byte) []string {
+	text := strings.TrimRight(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,35 @@
+// Package legacy provides the original minimal planetui surface as a thin shim
+// over the Engine-based API, so existing applications can switch imports without
+// rewriting commands. New code should use package tui directly.
+package legacy
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chzyer/readline"
+	tui "github.com/network-plane/planetui"
+)
+
+// Command is the original command interface.
+type Command = tui.LegacyCommand
+
+// RegisterContext registers a context on the default engine.
+func RegisterContext(name, description string) {
+	tui.RegisterContext(name, description)
+}
+
+// RegisterCommand registers a legacy command in ctx on the default engine.
+func RegisterCommand(ctx string, cmd Command) {
+	tui.RegisterLegacyCommand(ctx, cmd)
+}
+
+// Run starts the interactive loop, reporting errors on stderr as the original did.
+func Run(rl *readline.Instance) {
+	if err := tui.Run(rl); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// Engine returns the engine backing the shim, easing incremental migration to factories.
+func Engine() *tui.Engine { return tui.DefaultEngine() }
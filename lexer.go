@@ -0,0 +1,211 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUnterminatedQuote is returned by SplitCommandLine for a line that ends
+// inside quotes or after a lone backslash.
+var ErrUnterminatedQuote = errors.New("unterminated quote")
+
+// SplitCommandLine splits line into words like a POSIX shell, without
+// expansion: whitespace separates words, single quotes keep everything up to
+// the next single quote literal, double quotes keep whitespace and honour \",
+// \\, and \$ escapes, and elsewhere a backslash escapes the next character.
+// Quotes may join parts of one word, as in --label="core router". A "--" word
+// is kept; the args parser treats the words after it as positional.
+func SplitCommandLine(line string) ([]string, error) {
+	tokens, _, err := lexTokens(line)
+	return words(tokens), err
+}
+
+// QuoteCommandLine joins words into a line that SplitCommandLine splits back
+// into the same words, quoting only the words that need it.
+func QuoteCommandLine(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = quoteWord(w)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteWord(w string) string {
+	if w == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(w, " \t\r\n'\"\\") && !isOperator(w) {
+		return w
+	}
+	return singleQuote(w)
+}
+
+// singleQuote quotes w so that it lexes back as one word taken literally, with
+// no $ expansion.
+func singleQuote(w string) string {
+	return "'" + strings.ReplaceAll(w, "'", `'\''`) + "'"
+}
+
+// tokenize splits a command line leniently: an unterminated quote runs to the
+// end of the line. Interactive loops use SplitCommandLine to report it instead.
+func tokenize(input string) []string {
+	tokens, _, _ := lexTokens(input)
+	return words(tokens)
+}
+
+// token is one word of a command line. op marks an unquoted, unescaped "|"
+// or "&" word, which separates pipeline stages or runs the line in the
+// background; quoted, the same text is an ordinary argument.
+type token struct {
+	text string
+	op   bool
+}
+
+// isOperator reports whether w is the text of an operator token.
+func isOperator(w string) bool { return w == pipeOperator || w == backgroundOperator }
+
+// words returns the text of tokens.
+func words(tokens []token) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.text
+	}
+	return out
+}
+
+// literalTokens wraps already split words, such as process arguments, as
+// tokens without operators.
+func literalTokens(words []string) []token {
+	out := make([]token, len(words))
+	for i, w := range words {
+		out[i] = token{text: w}
+	}
+	return out
+}
+
+// joinTokens renders tokens as a command line, leaving operators bare.
+func joinTokens(tokens []token) string {
+	quoted := make([]string, len(tokens))
+	for i, t := range tokens {
+		quoted[i] = t.text
+		if !t.op {
+			quoted[i] = quoteWord(t.text)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// expander resolves the reference after a $ at the start of rest, returning
+// its value and how many bytes of rest it spans; zero width leaves the $ literal.
+type expander func(rest string) (value string, width int, err error)
+
+// lexTokens splits line into tokens, reporting whether it ends outside a
+// word, which completion uses to tell "node " from "node".
+func lexTokens(line string) (tokens []token, trailingSpace bool, err error) {
+	return lexExpanded(line, nil)
+}
+
+// lexExpanded is lexTokens expanding $ references outside single quotes
+// through expand, when set. A value becomes part of the word it appears in:
+// it is never split at whitespace or read as quotes or operators, and an
+// unquoted reference to an empty value adds no word.
+func lexExpanded(line string, expand expander) (tokens []token, trailingSpace bool, err error) {
+	var word strings.Builder
+	inWord := false
+	// plain is false once the current word has quoted or escaped text.
+	plain := true
+	flush := func() {
+		text := word.String()
+		tokens = append(tokens, token{text: text, op: plain && isOperator(text)})
+		word.Reset()
+		inWord, plain = false, true
+	}
+	const (
+		none = iota
+		single
+		double
+	)
+	quote := none
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch quote {
+		case single:
+			if ch == '\'' {
+				quote = none
+			} else {
+				word.WriteByte(ch)
+			}
+			continue
+		case double:
+			switch {
+			case ch == '"':
+				quote = none
+			case ch == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$", line[i+1]) >= 0:
+				i++
+				word.WriteByte(line[i])
+			case ch == '$' && expand != nil:
+				value, width, xerr := expand(line[i+1:])
+				if xerr != nil {
+					return nil, false, xerr
+				}
+				if width == 0 {
+					word.WriteByte(ch)
+				}
+				word.WriteString(value)
+				i += width
+			default:
+				word.WriteByte(ch)
+			}
+			continue
+		}
+		switch ch {
+		case ' ', '\t', '\r', '\n':
+			if inWord {
+				flush()
+			}
+			continue
+		case '\'':
+			quote, plain = single, false
+		case '"':
+			quote, plain = double, false
+		case '\\':
+			if i+1 == len(line) {
+				err = ErrUnterminatedQuote
+				continue
+			}
+			i++
+			word.WriteByte(line[i])
+			plain = false
+		case '$':
+			if expand == nil {
+				word.WriteByte(ch)
+				break
+			}
+			value, width, xerr := expand(line[i+1:])
+			if xerr != nil {
+				return nil, false, xerr
+			}
+			if width == 0 {
+				word.WriteByte(ch)
+				break
+			}
+			i += width
+			if value == "" {
+				continue
+			}
+			word.WriteString(value)
+			plain = false
+		default:
+			word.WriteByte(ch)
+		}
+		inWord = true
+	}
+	if quote != none {
+		err = ErrUnterminatedQuote
+	}
+	trailingSpace = !inWord && len(line) > 0
+	if inWord {
+		flush()
+	}
+	return tokens, trailingSpace, err
+}
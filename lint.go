@@ -0,0 +1,252 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Lint rule names reported in LintFinding.Rule.
+const (
+	LintDuplicateFlag      = "duplicate-flag"
+	LintDuplicateShorthand = "duplicate-shorthand"
+	LintEnumDefault        = "enum-default"
+	LintRequiredDefault    = "required-default"
+	LintMissingSummary     = "missing-summary"
+	LintAliasConflict      = "alias-conflict"
+	LintFlagGroup          = "flag-group"
+)
+
+// LintFinding is one problem found in a registered command spec.
+type LintFinding struct {
+	Context  string
+	Command  string
+	Rule     string
+	Severity SeverityLevel
+	Message  string
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", f.Severity, commandPath(f.Context, f.Command), f.Rule, f.Message)
+}
+
+// Lint checks every registered command spec for mistakes such as duplicate
+// flag shorthands, enum defaults outside EnumValues, required flags with
+// defaults, flag groups naming unknown flags, missing summaries, and aliases claimed by another command or a
+// context. Findings are sorted by context, command, and rule; rules that make
+// a command behave wrongly are SeverityError, the rest SeverityWarning.
+func (r *CommandRegistry) Lint() []LintFinding {
+	r.materialize()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var findings []LintFinding
+	for ctx, commands := range r.commands {
+		seen := map[string]bool{}
+		for _, entry := range commands {
+			spec := entry.Spec
+			if seen[spec.Name] {
+				continue
+			}
+			seen[spec.Name] = true
+			report := func(rule string, severity SeverityLevel, format string, args ...any) {
+				findings = append(findings, LintFinding{Context: ctx, Command: spec.Name, Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+			}
+			lintFlags(spec, report)
+			lintArgs(spec, report)
+			if spec.Summary == "" && !spec.Hidden {
+				report(LintMissingSummary, SeverityWarning, "command has no summary")
+			}
+			for _, name := range append([]string{spec.Name}, spec.Aliases...) {
+				if target, ok := r.contextNameLocked(name); ok {
+					report(LintAliasConflict, SeverityError, "%q resolves to context %s first", name, target)
+				}
+			}
+		}
+	}
+	for _, c := range r.conflicts {
+		findings = append(findings, LintFinding{Context: c.context, Command: c.loser, Rule: LintAliasConflict, Severity: SeverityError, Message: fmt.Sprintf("%q is taken by command %s", c.name, c.owner)})
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Context != b.Context {
+			return a.Context < b.Context
+		}
+		if a.Command != b.Command {
+			return a.Command < b.Command
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Message < b.Message
+	})
+	return findings
+}
+
+// nameConflict records that loser registered name in context but owner got it.
+type nameConflict struct {
+	context, name string
+	owner, loser  string
+}
+
+// contextNameLocked is ResolveContextName for callers holding r.mu.
+func (r *CommandRegistry) contextNameLocked(name string) (string, bool) {
+	if canonical, ok := r.aliases[name]; ok {
+		return canonical, true
+	}
+	if _, ok := r.contexts[name]; ok && name != "" {
+		return name, true
+	}
+	return "", false
+}
+
+type lintReporter func(rule string, severity SeverityLevel, format string, args ...any)
+
+func lintFlags(spec CommandSpec, report lintReporter) {
+	names := map[string]bool{}
+	shorthands := map[string]string{}
+	for _, flag := range spec.Flags {
+		if names[flag.Name] {
+			report(LintDuplicateFlag, SeverityError, "flag --%s is declared more than once", flag.Name)
+		}
+		names[flag.Name] = true
+		if flag.Shorthand != "" {
+			if other, ok := shorthands[flag.Shorthand]; ok {
+				report(LintDuplicateShorthand, SeverityError, "-%s is the shorthand of both --%s and --%s", flag.Shorthand, other, flag.Name)
+			} else {
+				shorthands[flag.Shorthand] = flag.Name
+			}
+		}
+		if flag.Required && flag.Default != nil {
+			report(LintRequiredDefault, SeverityWarning, "flag --%s is required but has a default", flag.Name)
+		}
+		if msg, ok := enumDefaultProblem(flag.Type, flag.Default, flag.EnumValues); !ok {
+			report(LintEnumDefault, SeverityError, "flag --%s %s", flag.Name, msg)
+		}
+	}
+	for _, g := range spec.FlagGroups {
+		switch g.Kind {
+		case FlagGroupExactlyOne, FlagGroupAtMostOne, FlagGroupRequires:
+		default:
+			report(LintFlagGroup, SeverityError, "flag group has unknown kind %q", g.Kind)
+		}
+		if len(g.Flags) < 2 {
+			report(LintFlagGroup, SeverityError, "flag group %q needs at least two flags", g)
+		}
+		for _, name := range g.Flags {
+			if !names[name] {
+				report(LintFlagGroup, SeverityError, "flag group %q names undeclared flag --%s", g, name)
+			}
+		}
+	}
+}
+
+func lintArgs(spec CommandSpec, report lintReporter) {
+	for _, arg := range spec.Args {
+		if arg.Required && arg.Default != nil {
+			report(LintRequiredDefault, SeverityWarning, "argument %s is required but has a default", arg.Name)
+		}
+		if msg, ok := enumDefaultProblem(arg.Type, arg.Default, arg.EnumValues); !ok {
+			report(LintEnumDefault, SeverityError, "argument %s %s", arg.Name, msg)
+		}
+	}
+}
+
+// enumDefaultProblem reports whether an enum's default is one of its values.
+func enumDefaultProblem(typ ArgType, def any, values []string) (string, bool) {
+	if typ != ArgTypeEnum || def == nil {
+		return "", true
+	}
+	s := fmt.Sprint(def)
+	for _, v := range values {
+		if v == s {
+			return "", true
+		}
+	}
+	return fmt.Sprintf("default %q is not one of %s", s, strings.Join(values, ", ")), false
+}
+
+func commandPath(ctx, name string) string {
+	if ctx == "" {
+		return name
+	}
+	return ctx + " " + name
+}
+
+// debug command ---------------------------------------------------------------
+
+type debugCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *debugCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "debug",
+			Summary:     "Diagnostics for application developers",
+			Description: "lint checks every registered command spec and fails when any finding is an error. errors lists the documented error codes. pprof start|stop serves net/http/pprof for live profiling. memory reports the heap and what each session holds against its budget.",
+			Context:     "",
+			Hidden:      true,
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"lint", "errors", "pprof", "memory"}, Required: true, Description: "Diagnostic to run"},
+				{Name: "op", Type: ArgTypeEnum, EnumValues: []string{"start", "stop", "status"}, Default: "status", Description: "pprof listener operation"},
+			},
+			Flags: []FlagSpec{
+				{Name: "addr", Type: ArgTypeString, Default: DefaultPprofAddr, Description: "pprof listen address"},
+			},
+			Examples: []Example{
+				{Description: "Check command specs", Command: "debug lint"},
+				{Description: "List error codes", Command: "debug errors"},
+				{Description: "Serve profiles on another local port", Command: "debug pprof start --addr localhost:6061"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *debugCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &debugCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type debugCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *debugCommand) Spec() CommandSpec { return c.spec }
+
+func (c *debugCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	switch input.Args.String("action") {
+	case "pprof":
+		return c.debugPprof(rt, input)
+	case "memory":
+		return c.debugMemory(rt)
+	}
+	if input.Args.String("action") == "errors" {
+		codes := c.engine.ErrorCodes()
+		rows := make([][]string, 0, len(codes))
+		for _, info := range codes {
+			rows = append(rows, []string{info.Code, info.Description})
+		}
+		rt.Output().WriteTable([]string{"Code", "Description"}, rows)
+		return CommandResult{Status: StatusSuccess, Payload: codes}
+	}
+	findings := c.engine.registry.Lint()
+	if len(findings) == 0 {
+		rt.Output().Info("No problems found.")
+		return CommandResult{Status: StatusSuccess, Payload: findings}
+	}
+	rows := make([][]string, 0, len(findings))
+	errs := 0
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			errs++
+		}
+		rows = append(rows, []string{string(f.Severity), commandPath(f.Context, f.Command), f.Rule, f.Message})
+	}
+	rt.Output().WriteTable([]string{"Severity", "Command", "Rule", "Message"}, rows)
+	if errs > 0 {
+		return CommandResult{Status: StatusFailed, Payload: findings, Error: &CommandError{Message: fmt.Sprintf("%d of %d finding(s) are errors", errs, len(findings)), Severity: SeverityError}}
+	}
+	return CommandResult{Status: StatusSuccess, Payload: findings}
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strconv"
+)
+
+// MemoryBudget bounds what each session keeps in memory, so sessions left
+// open for days do not grow without limit. Sizes are estimated from JSON
+// encodings. A zero field leaves that part unbounded, except TaskOutput,
+// which defaults to DefaultTaskOutputSize.
+type MemoryBudget struct {
+	// SessionStore bounds the values in a MemorySessionStore; the oldest
+	// written keys are evicted first, never the engine's own auth, prompt, and
+	// target keys.
+	SessionStore int64
+	// Results bounds the payloads in the result history; the oldest records
+	// are evicted first, always keeping the latest.
+	Results int64
+	// TaskOutput bounds the output captured per task.
+	TaskOutput int
+	// TaskOutputTotal bounds the output captured across a session's tasks; the
+	// output of the oldest finished tasks is dropped first.
+	TaskOutputTotal int64
+}
+
+// WithMemoryBudget sets the memory budget of every session.
+func WithMemoryBudget(budget MemoryBudget) Option {
+	return func(e *Engine) { e.memory = budget }
+}
+
+// MemoryBudget returns the budget sessions are created with.
+func (e *Engine) MemoryBudget() MemoryBudget {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.memory
+}
+
+// pinnedSessionKeys are never evicted from a session store.
+var pinnedSessionKeys = []string{SessionKeyPrincipal, SessionKeyPermissions, SessionKeyConnection, SessionKeyReadOnly, SessionKeyTargets}
+
+// estimateSize approximates the memory held by v by its JSON encoding, or its
+// printed form when it has none.
+func estimateSize(v any) int64 {
+	switch t := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(t))
+	case []byte:
+		return int64(len(t))
+	}
+	if data, err := json.Marshal(v); err == nil {
+		return int64(len(data))
+	}
+	return int64(len(fmt.Sprintf("%v", v)))
+}
+
+// formatBytes renders n as B, KiB, MiB, or GiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 2; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMG"[exp])
+}
+
+// MemoryUsage is one line of the `debug memory` report.
+type MemoryUsage struct {
+	Item   string `json:"item"`
+	Count  int    `json:"count"`
+	Bytes  int64  `json:"bytes"`
+	Budget int64  `json:"budget,omitempty"`
+}
+
+// MemoryReport describes the process heap and what each session holds.
+func (e *Engine) MemoryReport() []MemoryUsage {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	report := []MemoryUsage{
+		{Item: "heap in use", Count: int(ms.HeapObjects), Bytes: int64(ms.HeapInuse)},
+		{Item: "runtime total", Bytes: int64(ms.Sys)},
+		{Item: "goroutines", Count: runtime.NumGoroutine()},
+	}
+	budget := e.MemoryBudget()
+	for _, s := range e.Sessions() {
+		keys := s.store.Keys()
+		var storeBytes int64
+		if m, ok := s.store.(*MemorySessionStore); ok {
+			storeBytes = m.Size()
+		}
+		report = append(report,
+			MemoryUsage{Item: s.id + " store", Count: len(keys), Bytes: storeBytes, Budget: budget.SessionStore},
+			MemoryUsage{Item: s.id + " results", Count: len(s.results.Records()), Bytes: s.results.Size(), Budget: budget.Results},
+			MemoryUsage{Item: s.id + " task output", Count: len(s.tasks.Tasks()), Bytes: s.tasks.OutputSize(), Budget: budget.TaskOutputTotal},
+		)
+	}
+	return report
+}
+
+// debugMemory runs `debug memory`.
+func (c *debugCommand) debugMemory(rt CommandRuntime) CommandResult {
+	report := c.engine.MemoryReport()
+	rows := make([][]string, 0, len(report))
+	for _, u := range report {
+		count, size, budget := "", "", ""
+		if u.Count > 0 {
+			count = strconv.Itoa(u.Count)
+		}
+		if u.Bytes > 0 {
+			size = formatBytes(u.Bytes)
+		}
+		if u.Budget > 0 {
+			budget = formatBytes(u.Budget)
+		}
+		rows = append(rows, []string{u.Item, count, size, budget})
+	}
+	rt.Output().WriteTable([]string{"Item", "Count", "Size", "Budget"}, rows)
+	return CommandResult{Status: StatusSuccess, Payload: report}
+}
@@ -0,0 +1,355 @@
+package tui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency
+// histogram kept per command.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// CommandMetrics is a snapshot of one command's invocation metrics.
+type CommandMetrics struct {
+	Context  string
+	Command  string
+	Count    uint64
+	Failures uint64
+	Total    time.Duration
+	Max      time.Duration
+	// Buckets holds the number of invocations at or below each bound of the
+	// registry's latency buckets; it is cumulative, as in Prometheus.
+	Buckets []uint64
+}
+
+// FailureRate returns the fraction of invocations that failed.
+func (m CommandMetrics) FailureRate() float64 {
+	if m.Count == 0 {
+		return 0
+	}
+	return float64(m.Failures) / float64(m.Count)
+}
+
+// Mean returns the mean invocation latency.
+func (m CommandMetrics) Mean() time.Duration {
+	if m.Count == 0 {
+		return 0
+	}
+	return m.Total / time.Duration(m.Count)
+}
+
+// MetricsRegistry aggregates command metrics in memory. It is safe for
+// concurrent use.
+type MetricsRegistry struct {
+	mu       sync.Mutex
+	bounds   []float64
+	start    time.Time
+	commands map[string]*CommandMetrics
+}
+
+// NewMetricsRegistry returns an empty registry using bounds, in seconds, as
+// latency histogram buckets, or DefaultLatencyBuckets when none are given.
+func NewMetricsRegistry(bounds ...float64) *MetricsRegistry {
+	if len(bounds) == 0 {
+		bounds = DefaultLatencyBuckets
+	}
+	bounds = append([]float64(nil), bounds...)
+	sort.Float64s(bounds)
+	return &MetricsRegistry{bounds: bounds, start: time.Now(), commands: map[string]*CommandMetrics{}}
+}
+
+// Bounds returns the latency bucket bounds in seconds.
+func (m *MetricsRegistry) Bounds() []float64 { return append([]float64(nil), m.bounds...) }
+
+// Observe records one invocation of the command.
+func (m *MetricsRegistry) Observe(ctx, command string, status CommandStatus, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := ctx + "\x00" + command
+	agg, ok := m.commands[key]
+	if !ok {
+		agg = &CommandMetrics{Context: ctx, Command: command, Buckets: make([]uint64, len(m.bounds))}
+		m.commands[key] = agg
+	}
+	agg.Count++
+	if status == StatusFailed {
+		agg.Failures++
+	}
+	agg.Total += d
+	if d > agg.Max {
+		agg.Max = d
+	}
+	for i, bound := range m.bounds {
+		if d.Seconds() <= bound {
+			agg.Buckets[i]++
+		}
+	}
+}
+
+// Snapshot returns the metrics of every command observed, sorted by context and
+// command.
+func (m *MetricsRegistry) Snapshot() []CommandMetrics {
+	m.mu.Lock()
+	list := make([]CommandMetrics, 0, len(m.commands))
+	for _, agg := range m.commands {
+		c := *agg
+		c.Buckets = append([]uint64(nil), agg.Buckets...)
+		list = append(list, c)
+	}
+	m.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Context != list[j].Context {
+			return list[i].Context < list[j].Context
+		}
+		return list[i].Command < list[j].Command
+	})
+	return list
+}
+
+// Reset discards all observations.
+func (m *MetricsRegistry) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commands = map[string]*CommandMetrics{}
+	m.start = time.Now()
+}
+
+// MetricsMiddleware records the count, latency, and outcome of every command
+// invocation in registry.
+func MetricsMiddleware(registry *MetricsRegistry) Middleware {
+	return func(rt CommandRuntime, input CommandInput, entry CommandEntry, next NextFunc) CommandResult {
+		start := time.Now()
+		result := next(rt, input)
+		registry.Observe(entry.Spec.Context, entry.Spec.Name, result.Status, time.Since(start))
+		return result
+	}
+}
+
+// WithMetrics records command metrics in registry with MetricsMiddleware and
+// makes them available to the stats command.
+func WithMetrics(registry *MetricsRegistry) Option {
+	return func(e *Engine) {
+		if registry == nil {
+			return
+		}
+		e.metrics = registry
+		e.middleware = append(e.middleware, MetricsMiddleware(registry))
+	}
+}
+
+// MetricsExporter writes a registry's metrics in a monitoring system's format.
+type MetricsExporter interface {
+	ExportMetrics(w io.Writer, registry *MetricsRegistry) error
+}
+
+// promLabel escapes a Prometheus label value.
+var promLabel = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// PrometheusExporter writes metrics in the Prometheus text exposition format,
+// e.g. for serving on a /metrics endpoint.
+type PrometheusExporter struct {
+	// Namespace prefixes metric names; the default is "planetui".
+	Namespace string
+}
+
+// ExportMetrics implements MetricsExporter.
+func (p PrometheusExporter) ExportMetrics(w io.Writer, registry *MetricsRegistry) error {
+	ns := p.Namespace
+	if ns == "" {
+		ns = "planetui"
+	}
+	snapshot := registry.Snapshot()
+	bw := bufio.NewWriter(w)
+	labels := func(c CommandMetrics) string {
+		return fmt.Sprintf(`context="%s",command="%s"`, promLabel.Replace(c.Context), promLabel.Replace(c.Command))
+	}
+	fmt.Fprintf(bw, "# HELP %s_command_invocations_total Command invocations.\n# TYPE %s_command_invocations_total counter\n", ns, ns)
+	for _, c := range snapshot {
+		fmt.Fprintf(bw, "%s_command_invocations_total{%s} %d\n", ns, labels(c), c.Count)
+	}
+	fmt.Fprintf(bw, "# HELP %s_command_failures_total Command invocations that failed.\n# TYPE %s_command_failures_total counter\n", ns, ns)
+	for _, c := range snapshot {
+		fmt.Fprintf(bw, "%s_command_failures_total{%s} %d\n", ns, labels(c), c.Failures)
+	}
+	fmt.Fprintf(bw, "# HELP %s_command_duration_seconds Command latency.\n# TYPE %s_command_duration_seconds histogram\n", ns, ns)
+	bounds := registry.Bounds()
+	for _, c := range snapshot {
+		for i, bound := range bounds {
+			fmt.Fprintf(bw, "%s_command_duration_seconds_bucket{%s,le=\"%s\"} %d\n", ns, labels(c), strconv.FormatFloat(bound, 'g', -1, 64), c.Buckets[i])
+		}
+		fmt.Fprintf(bw, "%s_command_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", ns, labels(c), c.Count)
+		fmt.Fprintf(bw, "%s_command_duration_seconds_sum{%s} %s\n", ns, labels(c), strconv.FormatFloat(c.Total.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(bw, "%s_command_duration_seconds_count{%s} %d\n", ns, labels(c), c.Count)
+	}
+	return bw.Flush()
+}
+
+// OTLPExporter writes metrics as an OpenTelemetry OTLP/JSON
+// ExportMetricsServiceRequest, which an OpenTelemetry Collector accepts on its
+// OTLP/HTTP /v1/metrics endpoint.
+type OTLPExporter struct {
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+func otlpAttributes(pairs ...string) []otlpAttribute {
+	attrs := make([]otlpAttribute, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		var a otlpAttribute
+		a.Key, a.Value.StringValue = pairs[i], pairs[i+1]
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// ExportMetrics implements MetricsExporter.
+func (o OTLPExporter) ExportMetrics(w io.Writer, registry *MetricsRegistry) error {
+	service := o.ServiceName
+	if service == "" {
+		service = "planetui"
+	}
+	registry.mu.Lock()
+	start := registry.start
+	registry.mu.Unlock()
+	startNanos, nowNanos := strconv.FormatInt(start.UnixNano(), 10), strconv.FormatInt(time.Now().UnixNano(), 10)
+	bounds := registry.Bounds()
+
+	var invocations, failures, durations []map[string]any
+	for _, c := range registry.Snapshot() {
+		attrs := otlpAttributes("context", c.Context, "command", c.Command)
+		point := func(v uint64) map[string]any {
+			return map[string]any{"attributes": attrs, "startTimeUnixNano": startNanos, "timeUnixNano": nowNanos, "asInt": strconv.FormatUint(v, 10)}
+		}
+		invocations = append(invocations, point(c.Count))
+		failures = append(failures, point(c.Failures))
+		// OTLP bucket counts are per bucket, not cumulative, with a final overflow bucket.
+		counts := make([]string, len(bounds)+1)
+		prev := uint64(0)
+		for i, cum := range c.Buckets {
+			counts[i] = strconv.FormatUint(cum-prev, 10)
+			prev = cum
+		}
+		counts[len(bounds)] = strconv.FormatUint(c.Count-prev, 10)
+		durations = append(durations, map[string]any{
+			"attributes": attrs, "startTimeUnixNano": startNanos, "timeUnixNano": nowNanos,
+			"count": strconv.FormatUint(c.Count, 10), "sum": c.Total.Seconds(), "max": c.Max.Seconds(),
+			"bucketCounts": counts, "explicitBounds": bounds,
+		})
+	}
+	const cumulative = 2 // AGGREGATION_TEMPORALITY_CUMULATIVE
+	sum := func(name, description string, points []map[string]any) map[string]any {
+		return map[string]any{"name": name, "description": description, "unit": "1",
+			"sum": map[string]any{"dataPoints": points, "aggregationTemporality": cumulative, "isMonotonic": true}}
+	}
+	request := map[string]any{"resourceMetrics": []any{map[string]any{
+		"resource": map[string]any{"attributes": otlpAttributes("service.name", service)},
+		"scopeMetrics": []any{map[string]any{
+			"scope": map[string]any{"name": "github.com/network-plane/planetui"},
+			"metrics": []any{
+				sum("command.invocations", "Command invocations.", invocations),
+				sum("command.failures", "Command invocations that failed.", failures),
+				map[string]any{"name": "command.duration", "description": "Command latency.", "unit": "s",
+					"histogram": map[string]any{"dataPoints": durations, "aggregationTemporality": cumulative}},
+			},
+		}},
+	}}}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(request)
+}
+
+// stats command ---------------------------------------------------------------
+
+type statsCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *statsCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "stats",
+			Summary:     "Show command usage metrics",
+			Description: "Lists invocation counts, latencies, and failure rates per command. export writes them in Prometheus text or OTLP/JSON format; reset clears them.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"show", "export", "reset"}, Default: "show", Description: "Action to perform"},
+				{Name: "format", Type: ArgTypeEnum, EnumValues: []string{"prometheus", "otlp"}, Default: "prometheus", Description: "Export format"},
+			},
+			Examples: []Example{
+				{Description: "Show metrics", Command: "stats"},
+				{Description: "Export for Prometheus", Command: "stats export prometheus"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *statsCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &statsCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type statsCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *statsCommand) Spec() CommandSpec { return c.spec }
+
+func (c *statsCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	registry := c.engine.metrics
+	if registry == nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{
+			Message:  "metrics are not being collected",
+			Severity: SeverityError,
+			Hints:    []string{"create the engine with tui.WithMetrics(tui.NewMetricsRegistry())"},
+		}}
+	}
+	switch input.Args.String("action") {
+	case "export":
+		var exporter MetricsExporter = PrometheusExporter{}
+		if input.Args.String("format") == "otlp" {
+			exporter = OTLPExporter{}
+		}
+		if err := exporter.ExportMetrics(rt.Output().Writer(), registry); err != nil {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+		}
+		return CommandResult{Status: StatusSuccess}
+	case "reset":
+		registry.Reset()
+		rt.Output().Info("Metrics cleared.")
+		return CommandResult{Status: StatusSuccess}
+	}
+	snapshot := registry.Snapshot()
+	if len(snapshot) == 0 {
+		rt.Output().Info("No commands recorded yet.")
+		return CommandResult{Status: StatusSuccess, Payload: snapshot}
+	}
+	rows := make([][]string, 0, len(snapshot))
+	for _, m := range snapshot {
+		rows = append(rows, []string{
+			commandPath(m.Context, m.Command),
+			strconv.FormatUint(m.Count, 10),
+			strconv.FormatUint(m.Failures, 10),
+			strconv.FormatFloat(m.FailureRate()*100, 'f', 1, 64) + "%",
+			m.Mean().Round(time.Microsecond).String(),
+			m.Max.Round(time.Microsecond).String(),
+		})
+	}
+	rt.Output().WriteTable([]string{"Command", "Count", "Failures", "Failure rate", "Mean", "Max"}, rows)
+	return CommandResult{Status: StatusSuccess, Payload: snapshot}
+}
@@ -0,0 +1,46 @@
+package tui
+
+// InputMiddleware returns middleware that passes the input returned by rewrite
+// on to the command, e.g. to inject defaults or rewrite arguments. When rewrite
+// fails the command does not run and the error is reported as a failure.
+func InputMiddleware(rewrite func(rt CommandRuntime, input CommandInput, entry CommandEntry) (CommandInput, error)) Middleware {
+	return func(rt CommandRuntime, input CommandInput, entry CommandEntry, next NextFunc) CommandResult {
+		rewritten, err := rewrite(rt, input, entry)
+		if err != nil {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+		}
+		return next(rt, rewritten)
+	}
+}
+
+// ResultMiddleware returns middleware that hands the command's result to
+// inspect, returning whatever inspect returns, e.g. to add messages or a
+// summary, or to audit outcomes.
+func ResultMiddleware(inspect func(rt CommandRuntime, input CommandInput, entry CommandEntry, result CommandResult) CommandResult) Middleware {
+	return func(rt CommandRuntime, input CommandInput, entry CommandEntry, next NextFunc) CommandResult {
+		return inspect(rt, input, entry, next(rt, input))
+	}
+}
+
+// FlagDefaults returns middleware that sets flags the command declares but
+// the invocation left empty, taking values from defaults by flag name. A
+// func(CommandRuntime) any value is called per invocation, e.g. to read the
+// session store.
+func FlagDefaults(defaults map[string]any) Middleware {
+	return InputMiddleware(func(rt CommandRuntime, input CommandInput, entry CommandEntry) (CommandInput, error) {
+		for _, flag := range entry.Spec.Flags {
+			value, ok := defaults[flag.Name]
+			if !ok {
+				continue
+			}
+			if current, set := input.Flags.Raw(flag.Name); set && current != nil && current != "" {
+				continue
+			}
+			if fn, ok := value.(func(CommandRuntime) any); ok {
+				value = fn(rt)
+			}
+			input.Flags = input.Flags.With(flag.Name, value)
+		}
+		return input, nil
+	})
+}
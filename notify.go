@@ -0,0 +1,88 @@
+package tui
+
+import "fmt"
+
+// NotificationSink receives asynchronous notices for a session, such as
+// background task completions.
+type NotificationSink interface {
+	Notify(s *Session, message string)
+}
+
+// NotificationSinkFunc adapts a function into a NotificationSink.
+type NotificationSinkFunc func(s *Session, message string)
+
+// Notify implements NotificationSink.
+func (f NotificationSinkFunc) Notify(s *Session, message string) { f(s, message) }
+
+// WithNotificationSink replaces where notices are delivered. By default they are
+// printed above the interactive prompt. A nil sink disables notices.
+func WithNotificationSink(sink NotificationSink) Option {
+	return func(e *Engine) {
+		e.notifier = sink
+		e.notifierSet = true
+	}
+}
+
+// Notify delivers message to the engine's notification sink.
+func (s *Session) Notify(message string) {
+	s.engine.mu.RLock()
+	sink, set := s.engine.notifier, s.engine.notifierSet
+	s.engine.mu.RUnlock()
+	if !set {
+		sink = NotificationSinkFunc(printNotice)
+	}
+	if sink != nil {
+		sink.Notify(s, message)
+	}
+}
+
+// printNotice writes message above the prompt when a front end is attached,
+// or to the session output otherwise.
+func printNotice(s *Session, message string) {
+	s.mu.RLock()
+	notice := s.notice
+	s.mu.RUnlock()
+	if notice != nil {
+		notice(message)
+		return
+	}
+	fmt.Fprintln(s.OutputWriter(), message)
+}
+
+// attachNotice sets how notices reach the operator while a front end is
+// running, returning its detach func.
+func (s *Session) attachNotice(fn func(message string)) func() {
+	s.mu.Lock()
+	s.notice = fn
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		s.notice = nil
+		s.mu.Unlock()
+	}
+}
+
+// notifyTaskDone announces a finished background task.
+func (s *Session) notifyTaskDone(h *TaskHandle) {
+	if h.noNotify {
+		return
+	}
+	status := Colorize(string(h.Status), StatusColor(taskCommandStatus(h.Status)))
+	if h.Status == TaskFailed && h.Error != nil {
+		status += ": " + firstLine(h.Error.Error())
+	}
+	s.Notify(fmt.Sprintf("[%s finished: %s]", h.ID, status))
+}
+
+// taskCommandStatus maps a task status onto the command status used for colouring.
+func taskCommandStatus(status TaskStatus) CommandStatus {
+	switch status {
+	case TaskSucceeded:
+		return StatusSuccess
+	case TaskFailed:
+		return StatusFailed
+	case TaskCancelled:
+		return StatusPartial
+	}
+	return StatusPending
+}
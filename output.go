@@ -2,11 +2,15 @@ package tui
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // OutputChannel controls command output levels and formats.
@@ -18,6 +22,22 @@ type OutputChannel interface {
 	Error(msg string)
 	WriteJSON(v any)
 	WriteTable(headers []string, rows [][]string)
+	WriteYAML(v any)
+	WriteCSV(headers []string, rows [][]string)
+	// InfoCtx, WarnCtx, and ErrorCtx write unless ctx is cancelled and return
+	// ctx's error or the write error, so long-running loops can stop.
+	InfoCtx(ctx context.Context, msg string) error
+	WarnCtx(ctx context.Context, msg string) error
+	ErrorCtx(ctx context.Context, msg string) error
+	// Flush flushes a buffering destination writer.
+	Flush() error
+	// Format is the structured output format; WriteTable and WriteJSON follow it.
+	Format() OutputFormat
+	SetFormat(format OutputFormat)
+	// Progress starts a progress bar, or a spinner when total is not positive.
+	Progress(id string, total int) ProgressReporter
+	// StreamTable writes table rows as they arrive, with fixed column widths.
+	StreamTable(headers []string, opts TableStreamOptions) TableStream
 	Writer() io.Writer
 	Buffer() *bytes.Buffer
 }
@@ -32,21 +52,105 @@ const (
 	OutputDebug
 )
 
+// implicitFlags are accepted by every command unless its spec opts out or declares a clashing flag.
+var implicitFlags = []FlagSpec{
+	{Name: "verbose", Shorthand: "v", Type: ArgTypeBool, Hidden: true, Description: "Verbose output for this command"},
+	{Name: "quiet", Shorthand: "q", Type: ArgTypeBool, Hidden: true, Description: "Show only the results and errors of this command"},
+	{Name: "output", Shorthand: "o", Type: ArgTypeEnum, EnumValues: outputFormats, Hidden: true, Description: "Output format: table, json, yaml, or csv"},
+	{Name: "dry-run", Type: ArgTypeBool, Hidden: true, Description: "Show what the command would change without changing it"},
+}
+
+// applicableImplicitFlags lists the implicit flags spec accepts: none when it opts out,
+// and never a name or shorthand that would shadow one the spec declares itself.
+func applicableImplicitFlags(spec CommandSpec) []FlagSpec {
+	if spec.NoImplicitFlags {
+		return nil
+	}
+	var out []FlagSpec
+	for _, implicit := range implicitFlags {
+		clash := false
+		for _, flag := range spec.Flags {
+			if flag.Name == implicit.Name {
+				clash = true
+				break
+			}
+			if flag.Shorthand == implicit.Shorthand {
+				implicit.Shorthand = ""
+			}
+		}
+		if !clash {
+			out = append(out, implicit)
+		}
+	}
+	return out
+}
+
+// withImplicitFlags returns spec extended with its applicable implicit flags.
+func withImplicitFlags(spec CommandSpec) CommandSpec {
+	if extra := applicableImplicitFlags(spec); len(extra) > 0 {
+		spec.Flags = append(append([]FlagSpec(nil), spec.Flags...), extra...)
+	}
+	return spec
+}
+
+// outputLevelOverride reports the level requested through spec's implicit flags, if any.
+func outputLevelOverride(spec CommandSpec, flags ValueSet) (OutputLevel, bool, error) {
+	var verbose, quiet bool
+	for _, flag := range applicableImplicitFlags(spec) {
+		switch flag.Name {
+		case "verbose":
+			verbose = flags.Bool("verbose")
+		case "quiet":
+			quiet = flags.Bool("quiet")
+		}
+	}
+	switch {
+	case verbose && quiet:
+		return 0, false, &ParseError{Err: errors.New("--verbose and --quiet are mutually exclusive"), Flag: "quiet"}
+	case verbose:
+		return OutputVerbose, true, nil
+	case quiet:
+		return OutputQuiet, true, nil
+	}
+	return 0, false, nil
+}
+
 // DefaultOutputChannel is an in-memory channel writing to io.Writer.
+// It is safe for concurrent use, so background tasks may share one channel.
 type DefaultOutputChannel struct {
-	level   OutputLevel
-	writer  io.Writer
-	buf     *bytes.Buffer
-	started bool
+	level  atomic.Int32
+	mu     sync.Mutex
+	writer io.Writer
+	buf    *bytes.Buffer
+	// dest is the destination behind writer; ctx, when bound, lets writes to
+	// it give up after cancellation.
+	dest io.Writer
+	ctx  context.Context
+	slot *writeSlot
+	// router holds the sinks that mirror this channel's output.
+	router     *OutputRouter
+	started    bool
+	format     OutputFormat
+	structured bool
+	theme      Theme
+
+	bars          []*progressBar
+	progressDrawn bool
+	// lineProgress prints progress as lines instead of redrawing in place, for
+	// channels written to while the prompt is shown.
+	lineProgress bool
 }
 
 // NewOutputChannel builds an OutputChannel targeting provided writer.
 func NewOutputChannel(w io.Writer) *DefaultOutputChannel {
 	buf := &bytes.Buffer{}
-	mw := io.MultiWriter(w, buf)
-	return &DefaultOutputChannel{level: OutputNormal, writer: mw, buf: buf}
+	mw := io.MultiWriter(buf, w)
+	c := &DefaultOutputChannel{writer: mw, buf: buf, dest: w, slot: newWriteSlot()}
+	c.level.Store(int32(OutputNormal))
+	return c
 }
 
+// ensureLead must be called with c.mu held.
 func (c *DefaultOutputChannel) ensureLead() {
 	if c == nil || c.started {
 		return
@@ -56,56 +160,180 @@ func (c *DefaultOutputChannel) ensureLead() {
 }
 
 // Level returns current verbosity.
-func (c *DefaultOutputChannel) Level() OutputLevel { return c.level }
+func (c *DefaultOutputChannel) Level() OutputLevel { return OutputLevel(c.level.Load()) }
 
 // SetLevel updates verbosity.
-func (c *DefaultOutputChannel) SetLevel(level OutputLevel) { c.level = level }
+func (c *DefaultOutputChannel) SetLevel(level OutputLevel) { c.level.Store(int32(level)) }
+
+// SetTheme sets the colours applied to messages and table headers.
+func (c *DefaultOutputChannel) SetTheme(theme Theme) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.theme = theme
+}
 
 // Info writes an informational message.
 func (c *DefaultOutputChannel) Info(msg string) {
-	if c.level >= OutputQuiet {
+	c.writeInfo(msg)
+	c.forward(func(m *DefaultOutputChannel) { m.writeInfo(msg) })
+}
+
+func (c *DefaultOutputChannel) writeInfo(msg string) {
+	if c.Level() > OutputQuiet {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		defer c.suspendProgress()()
 		c.ensureLead()
-		fmt.Fprintln(c.writer, msg)
+		fmt.Fprintln(c.writer, Colorize(msg, c.theme.Info))
 	}
 }
 
 // Warn writes a warning message.
 func (c *DefaultOutputChannel) Warn(msg string) {
-	if c.level >= OutputQuiet {
+	c.writeWarn(msg)
+	c.forward(func(m *DefaultOutputChannel) { m.writeWarn(msg) })
+}
+
+func (c *DefaultOutputChannel) writeWarn(msg string) {
+	if c.Level() > OutputQuiet {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		defer c.suspendProgress()()
 		c.ensureLead()
-		fmt.Fprintf(c.writer, "WARNING: %s\n", msg)
+		fmt.Fprintln(c.writer, Colorize("WARNING: "+msg, c.theme.Warning))
 	}
 }
 
 // Error writes an error message.
 func (c *DefaultOutputChannel) Error(msg string) {
+	c.writeError(msg)
+	c.forward(func(m *DefaultOutputChannel) { m.writeError(msg) })
+}
+
+func (c *DefaultOutputChannel) writeError(msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.suspendProgress()()
 	c.ensureLead()
-	fmt.Fprintf(c.writer, "ERROR: %s\n", msg)
+	fmt.Fprintln(c.writer, Colorize("ERROR: "+msg, c.theme.Error))
+}
+
+// Format returns the structured output format (table unless set).
+func (c *DefaultOutputChannel) Format() OutputFormat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.format == "" {
+		return OutputFormatTable
+	}
+	return c.format
 }
 
-// WriteJSON renders JSON output respecting verbosity.
+// SetFormat changes the structured output format.
+func (c *DefaultOutputChannel) SetFormat(format OutputFormat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.format = format
+}
+
+// wroteStructured reports whether any table, JSON, YAML, or CSV output was written.
+func (c *DefaultOutputChannel) wroteStructured() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.structured
+}
+
+// emitStructured writes rendered structured output.
+func (c *DefaultOutputChannel) emitStructured(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.suspendProgress()()
+	c.ensureLead()
+	c.structured = true
+	fmt.Fprint(c.writer, text)
+}
+
+// WriteJSON renders JSON output, or YAML/CSV when that format is selected.
 func (c *DefaultOutputChannel) WriteJSON(v any) {
-	if c.level < OutputNormal {
+	c.writeJSON(v)
+	c.forward(func(m *DefaultOutputChannel) { m.writeJSON(v) })
+}
+
+func (c *DefaultOutputChannel) writeJSON(v any) {
+	switch c.Format() {
+	case OutputFormatYAML:
+		c.writeYAML(v)
 		return
+	case OutputFormatCSV:
+		if headers, rows, ok := tabulate(v); ok {
+			c.writeCSV(headers, rows)
+			return
+		}
 	}
-	c.ensureLead()
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
-		c.Error(fmt.Sprintf("failed to encode json: %v", err))
+		c.writeError(fmt.Sprintf("failed to encode json: %v", err))
 		return
 	}
-	fmt.Fprintln(c.writer, string(data))
+	c.emitStructured(string(data) + "\n")
 }
 
-// WriteTable renders tabular output without border markers.
-func (c *DefaultOutputChannel) WriteTable(headers []string, rows [][]string) {
-	if c.level < OutputNormal {
+// WriteYAML renders YAML output.
+func (c *DefaultOutputChannel) WriteYAML(v any) {
+	c.writeYAML(v)
+	c.forward(func(m *DefaultOutputChannel) { m.writeYAML(v) })
+}
+
+func (c *DefaultOutputChannel) writeYAML(v any) {
+	text, err := encodeYAML(v)
+	if err != nil {
+		c.writeError(fmt.Sprintf("failed to encode yaml: %v", err))
+		return
+	}
+	c.emitStructured(text)
+}
+
+// WriteCSV renders CSV output.
+func (c *DefaultOutputChannel) WriteCSV(headers []string, rows [][]string) {
+	c.writeCSV(headers, rows)
+	c.forward(func(m *DefaultOutputChannel) { m.writeCSV(headers, rows) })
+}
+
+func (c *DefaultOutputChannel) writeCSV(headers []string, rows [][]string) {
+	text, err := encodeCSV(headers, rows)
+	if err != nil {
+		c.writeError(fmt.Sprintf("failed to encode csv: %v", err))
 		return
 	}
+	c.emitStructured(text)
+}
+
+// WriteTable renders tabular output without border markers, or as records in
+// the JSON, YAML, or CSV format when one is selected.
+func (c *DefaultOutputChannel) WriteTable(headers []string, rows [][]string) {
+	c.writeTable(headers, rows)
+	c.forward(func(m *DefaultOutputChannel) { m.writeTable(headers, rows) })
+}
+
+func (c *DefaultOutputChannel) writeTable(headers []string, rows [][]string) {
 	if len(headers) == 0 {
 		return
 	}
+	switch c.Format() {
+	case OutputFormatJSON:
+		c.writeJSON(tableRecords(headers, rows))
+		return
+	case OutputFormatYAML:
+		c.writeYAML(tableRecords(headers, rows))
+		return
+	case OutputFormatCSV:
+		c.writeCSV(headers, rows)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.suspendProgress()()
 	c.ensureLead()
+	c.structured = true
 	widths := make([]int, len(headers))
 	for i, h := range headers {
 		widths[i] = len(strings.TrimSpace(h))
@@ -120,11 +348,29 @@ func (c *DefaultOutputChannel) WriteTable(headers []string, rows [][]string) {
 			}
 		}
 	}
-	fmt.Fprintln(c.writer, formatHeader(headers, widths))
+	fmt.Fprintln(c.writer, Colorize(formatHeader(headers, widths), c.theme.TableHeader))
 	for _, row := range rows {
 		fmt.Fprintln(c.writer, formatRow(row, widths))
 	}
 }
+
+// tableRecords converts table rows into objects keyed by header.
+func tableRecords(headers []string, rows [][]string) []map[string]string {
+	records := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		rec := make(map[string]string, len(headers))
+		for j, h := range headers {
+			if j < len(row) {
+				rec[strings.TrimSpace(h)] = row[j]
+			} else {
+				rec[strings.TrimSpace(h)] = ""
+			}
+		}
+		records[i] = rec
+	}
+	return records
+}
+
 func formatHeader(headers []string, widths []int) string {
 	if len(widths) == 0 {
 		return ""
@@ -168,6 +414,8 @@ func EnsureLineBreak(out OutputChannel) {
 	buf := out.Buffer()
 	needNewline := false
 	if dc, ok := out.(*DefaultOutputChannel); ok {
+		dc.mu.Lock()
+		defer dc.mu.Unlock()
 		if dc.started {
 			needNewline = true
 			dc.started = false
@@ -183,7 +431,12 @@ func EnsureLineBreak(out OutputChannel) {
 		buf.Reset()
 	}
 	if needNewline {
-		fmt.Fprintln(out.Writer())
+		w := out.Writer()
+		if dc, ok := out.(*DefaultOutputChannel); ok {
+			// The break is for the prompt, not the sinks.
+			w = dc.writer
+		}
+		fmt.Fprintln(w)
 	}
 }
 
@@ -212,8 +465,40 @@ func AggregateMessages(out OutputChannel, messages []OutputMessage) {
 	}
 }
 
-// Writer returns the underlying writer.
-func (c *DefaultOutputChannel) Writer() io.Writer { return c.writer }
+// Writer returns the underlying writer. Bytes written to it also reach the
+// engine's output sinks unchanged.
+func (c *DefaultOutputChannel) Writer() io.Writer {
+	if c.router == nil {
+		return c.writer
+	}
+	return routedWriter{c}
+}
 
 // Buffer exposes captured output, useful in tests.
 func (c *DefaultOutputChannel) Buffer() *bytes.Buffer { return c.buf }
+
+// swapWriter forwards writes to a destination that can be replaced atomically.
+// Swap waits for in-flight writes to finish before switching.
+type swapWriter struct {
+	mu sync.RWMutex
+	w  io.Writer
+}
+
+func newSwapWriter(w io.Writer) *swapWriter {
+	return &swapWriter{w: w}
+}
+
+func (s *swapWriter) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.w.Write(p)
+}
+
+// Swap replaces the destination, returning the previous one.
+func (s *swapWriter) Swap(w io.Writer) io.Writer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.w
+	s.w = w
+	return prev
+}
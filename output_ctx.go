@@ -0,0 +1,248 @@
+package tui
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// cancelledWriteGrace is how long a write issued after cancellation may still
+// take before it is abandoned, so a healthy writer keeps the final messages.
+const cancelledWriteGrace = 250 * time.Millisecond
+
+// writeSlot serialises a channel's writes to its destination. They run in
+// order on one goroutine, started while writes are pending, so a stuck
+// destination holds up at most that goroutine and never reorders output.
+type writeSlot struct {
+	mu      sync.Mutex
+	pending []*writeOp
+	running bool
+	// stalled is set while a write that was given up on is still blocked.
+	stalled bool
+}
+
+// writeOp states; a write given up on before it starts is skipped.
+const (
+	writeQueued = iota
+	writeRunning
+	writeDone
+	writeAbandoned
+)
+
+// writeOp is a write queued on a slot. state is guarded by the slot's mutex.
+type writeOp struct {
+	fn    func() error
+	state int
+	done  chan error
+}
+
+func newWriteSlot() *writeSlot {
+	return &writeSlot{}
+}
+
+// submit queues fn, starting the slot's goroutine when it is idle.
+func (s *writeSlot) submit(fn func() error) *writeOp {
+	op := &writeOp{fn: fn, done: make(chan error, 1)}
+	s.mu.Lock()
+	s.pending = append(s.pending, op)
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+	s.mu.Unlock()
+	return op
+}
+
+// direct runs fn on the calling goroutine when the slot is idle, which is
+// all that writes that cannot be cancelled need.
+func (s *writeSlot) direct(fn func() error) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return <-s.submit(fn).done
+	}
+	s.running = true
+	s.mu.Unlock()
+	err := fn()
+	s.mu.Lock()
+	if len(s.pending) > 0 {
+		go s.run()
+	} else {
+		s.running = false
+	}
+	s.mu.Unlock()
+	return err
+}
+
+// run performs pending writes until none are left.
+func (s *writeSlot) run() {
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		op := s.pending[0]
+		s.pending = s.pending[1:]
+		if op.state == writeAbandoned {
+			s.mu.Unlock()
+			continue
+		}
+		op.state = writeRunning
+		s.mu.Unlock()
+		err := op.fn()
+		s.mu.Lock()
+		if op.state == writeAbandoned {
+			s.stalled = false
+		}
+		op.state = writeDone
+		s.mu.Unlock()
+		op.done <- err
+	}
+}
+
+// abandon gives up on op, returning err, or op's result if it already finished.
+func (s *writeSlot) abandon(op *writeOp, err error) error {
+	s.mu.Lock()
+	switch op.state {
+	case writeDone:
+		s.mu.Unlock()
+		return <-op.done
+	case writeRunning:
+		s.stalled = true
+	}
+	op.state = writeAbandoned
+	s.mu.Unlock()
+	return err
+}
+
+func (s *writeSlot) isStalled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stalled
+}
+
+// cancelWriter writes to w through its slot so callers stop waiting when ctx
+// is cancelled and w is stuck, e.g. on a dead remote client.
+type cancelWriter struct {
+	w    io.Writer
+	ctx  context.Context
+	slot *writeSlot
+}
+
+func (cw *cancelWriter) Write(p []byte) (int, error) {
+	buf := append([]byte(nil), p...)
+	err := cw.do(func() error {
+		_, err := cw.w.Write(buf)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// do runs fn on the slot. Once ctx is cancelled it waits at most a grace
+// period for fn, and not at all while an earlier abandoned call is blocked.
+func (cw *cancelWriter) do(fn func() error) error {
+	slot := cw.slot
+	if cw.ctx.Done() == nil {
+		return slot.direct(fn)
+	}
+	if cw.ctx.Err() != nil && slot.isStalled() {
+		return cw.ctx.Err()
+	}
+	op := slot.submit(fn)
+	select {
+	case err := <-op.done:
+		return err
+	case <-cw.ctx.Done():
+	}
+	select {
+	case err := <-op.done:
+		return err
+	case <-time.After(cancelledWriteGrace):
+	}
+	return slot.abandon(op, cw.ctx.Err())
+}
+
+// bindContext makes the channel's writes give up on its destination once ctx
+// is cancelled. invoke binds each command's channel to its invocation.
+func (c *DefaultOutputChannel) bindContext(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ctx = ctx
+	c.writer = c.writerFor(ctx)
+}
+
+// writerFor must be called with c.mu held.
+func (c *DefaultOutputChannel) writerFor(ctx context.Context) io.Writer {
+	if c.dest == nil {
+		return c.writer
+	}
+	return io.MultiWriter(c.buf, &cancelWriter{w: c.dest, ctx: ctx, slot: c.slot})
+}
+
+// InfoCtx writes an informational message unless ctx is cancelled or the
+// channel is quiet, returning ctx's error or the write error so loops can stop.
+func (c *DefaultOutputChannel) InfoCtx(ctx context.Context, msg string) error {
+	if c.Level() <= OutputQuiet {
+		return ctx.Err()
+	}
+	return c.printCtx(ctx, "", msg, func(t Theme) string { return t.Info }, (*DefaultOutputChannel).writeInfo)
+}
+
+// WarnCtx writes a warning message; see InfoCtx.
+func (c *DefaultOutputChannel) WarnCtx(ctx context.Context, msg string) error {
+	if c.Level() <= OutputQuiet {
+		return ctx.Err()
+	}
+	return c.printCtx(ctx, "WARNING: ", msg, func(t Theme) string { return t.Warning }, (*DefaultOutputChannel).writeWarn)
+}
+
+// ErrorCtx writes an error message; see InfoCtx.
+func (c *DefaultOutputChannel) ErrorCtx(ctx context.Context, msg string) error {
+	return c.printCtx(ctx, "ERROR: ", msg, func(t Theme) string { return t.Error }, (*DefaultOutputChannel).writeError)
+}
+
+// printCtx writes prefix+msg to the channel and, once that succeeds, hands msg
+// to mirror for each sink.
+func (c *DefaultOutputChannel) printCtx(ctx context.Context, prefix, msg string, color func(Theme) string, mirror func(*DefaultOutputChannel, string)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	restore := c.suspendProgress()
+	c.ensureLead()
+	_, err := io.WriteString(c.writerFor(ctx), Colorize(prefix+msg, color(c.theme))+"\n")
+	restore()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	c.forward(func(m *DefaultOutputChannel) { mirror(m, msg) })
+	return nil
+}
+
+// Flush flushes the destination writer when it buffers, such as a
+// bufio.Writer, giving up like other writes if the command is cancelled.
+func (c *DefaultOutputChannel) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var fn func() error
+	switch f := c.dest.(type) {
+	case interface{ Flush() error }:
+		fn = f.Flush
+	case interface{ Flush() }:
+		fn = func() error { f.Flush(); return nil }
+	default:
+		return nil
+	}
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return (&cancelWriter{ctx: ctx, slot: c.slot}).do(fn)
+}
@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// OutputSink is an extra destination for command output, such as a log file
+// or a remote viewer. Output is rendered for each sink with its own level and
+// format and without colour. The zero Level is OutputQuiet, which receives
+// results and errors but no informational messages, warnings, or progress.
+type OutputSink struct {
+	Name   string
+	Writer io.Writer
+	Level  OutputLevel
+	Format OutputFormat
+}
+
+// OutputRouter mirrors command and task output to the attached sinks.
+type OutputRouter struct {
+	mu    sync.RWMutex
+	sinks []routedSink
+}
+
+type routedSink struct {
+	sink    OutputSink
+	channel *DefaultOutputChannel
+}
+
+// NewOutputRouter constructs an OutputRouter without sinks.
+func NewOutputRouter() *OutputRouter {
+	return &OutputRouter{}
+}
+
+// WithOutputSink attaches sink to the engine's OutputRouter. Sinks without a
+// name or writer are ignored.
+func WithOutputSink(sink OutputSink) Option {
+	return func(e *Engine) { _ = e.router.Attach(sink) }
+}
+
+// OutputRouter returns the router distributing output to extra sinks.
+func (e *Engine) OutputRouter() *OutputRouter { return e.router }
+
+// Attach adds sink, replacing any sink of the same name. Output written from
+// then on, including by running commands and tasks, reaches it.
+func (r *OutputRouter) Attach(sink OutputSink) error {
+	if sink.Name == "" {
+		return errors.New("output sink requires a name")
+	}
+	if sink.Writer == nil {
+		return errors.New("output sink requires a writer")
+	}
+	c := &DefaultOutputChannel{writer: plainWriter{sink.Writer}, started: true, format: sink.Format}
+	c.level.Store(int32(sink.Level))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.sinks {
+		if s.sink.Name == sink.Name {
+			r.sinks[i] = routedSink{sink: sink, channel: c}
+			return nil
+		}
+	}
+	r.sinks = append(r.sinks, routedSink{sink: sink, channel: c})
+	return nil
+}
+
+// Detach removes the named sink, reporting whether it was attached.
+func (r *OutputRouter) Detach(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.sinks {
+		if s.sink.Name == name {
+			r.sinks = append(r.sinks[:i:i], r.sinks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Sinks lists the attached sinks in attachment order.
+func (r *OutputRouter) Sinks() []OutputSink {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]OutputSink, len(r.sinks))
+	for i, s := range r.sinks {
+		list[i] = s.sink
+	}
+	return list
+}
+
+func (r *OutputRouter) channels() []*DefaultOutputChannel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*DefaultOutputChannel, len(r.sinks))
+	for i, s := range r.sinks {
+		list[i] = s.channel
+	}
+	return list
+}
+
+// forward repeats a write on the channel of every attached sink.
+func (c *DefaultOutputChannel) forward(fn func(m *DefaultOutputChannel)) {
+	if c.router == nil {
+		return
+	}
+	for _, m := range c.router.channels() {
+		fn(m)
+	}
+}
+
+// writeRaw copies bytes written through Writer to a sink.
+func (c *DefaultOutputChannel) writeRaw(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writer.Write(p)
+}
+
+// routedWriter sends raw bytes to a channel's writer and its sinks.
+type routedWriter struct {
+	c *DefaultOutputChannel
+}
+
+func (w routedWriter) Write(p []byte) (int, error) {
+	n, err := w.c.writer.Write(p)
+	w.c.forward(func(m *DefaultOutputChannel) { m.writeRaw(p) })
+	return n, err
+}
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+
+// plainWriter removes ANSI escapes, such as colours already applied to a
+// message, before writing to a sink.
+type plainWriter struct {
+	w io.Writer
+}
+
+func (w plainWriter) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(ansiEscape.ReplaceAll(p, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// multiTableStream fans a streamed table out to several channels.
+type multiTableStream []TableStream
+
+func (s multiTableStream) WriteRow(row []string) {
+	for _, t := range s {
+		t.WriteRow(row)
+	}
+}
+
+func (s multiTableStream) Close() {
+	for _, t := range s {
+		t.Close()
+	}
+}
@@ -0,0 +1,249 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parallelPlaceholder matches `{}` and named placeholders such as `{host}`.
+var parallelPlaceholder = regexp.MustCompile(`\{\w*\}`)
+
+// parallel command ------------------------------------------------------------
+
+type parallelCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *parallelCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "parallel",
+			Summary:     "Fan a command out over a list of inputs",
+			Description: "Runs a command template once per input item on the task pool and aggregates the results into one table. Placeholders such as {} or {host} are replaced by each item; without one the item is appended. Items follow `::`, either inline or as a single file with one item per line; without `::` the session's selected targets are used and {address} or tag placeholders resolve per target. Only commands marked Concurrent may run in parallel.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "template", Type: ArgTypeString, Required: true, Repeatable: true, Passthrough: true, Description: "Command template, then :: and the items"},
+			},
+			Flags: []FlagSpec{
+				{Name: "limit", Shorthand: "j", Type: ArgTypeInt, Default: 4, Description: "Maximum concurrent executions"},
+			},
+			Examples: []Example{
+				{Description: "Ping every host in a file, ten at a time", Command: "parallel --limit 10 -- ping {host} :: hosts.txt"},
+				{Description: "Inline items", Command: "parallel -- show {} :: edge-1 edge-2"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *parallelCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &parallelCommand{spec: f.Spec()}, nil
+}
+
+type parallelCommand struct {
+	spec CommandSpec
+}
+
+func (c *parallelCommand) Spec() CommandSpec { return c.spec }
+
+type parallelItem struct {
+	item     string
+	status   CommandStatus
+	duration time.Duration
+	summary  string
+}
+
+func (c *parallelCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "parallel requires an engine session", Severity: SeverityError}}
+	}
+	limit := input.Flags.Int("limit")
+	if limit <= 0 {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "limit must be a positive integer", Severity: SeverityError}}
+	}
+	template, items, err := splitParallelArgs(input.Args.Strings("template"))
+	var targets []Target
+	if errors.Is(err, errNoParallelItems) {
+		if targets = SelectedTargets(rt); len(targets) > 0 {
+			items, err = make([]string, len(targets)), nil
+			for i, t := range targets {
+				items[i] = t.Name
+			}
+		}
+	}
+	if err != nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError, Hints: []string{"parallel [--limit N] -- <command> {item} [:: <items...|file>]"}}}
+	}
+
+	// Resolve every item up front so nothing runs if the template is invalid.
+	type job struct {
+		entry CommandEntry
+		args  []string
+	}
+	jobs := make([]job, len(items))
+	lines := make([][]string, len(items))
+	for i, item := range items {
+		var lookup func(string) (string, bool)
+		if targets != nil {
+			lookup = targets[i].Field
+		}
+		lines[i] = expandParallelTemplate(template, item, lookup)
+		entry, args, err := session.resolveCommand(lines[i])
+		if err != nil {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+		}
+		if entry.Spec.Name == c.spec.Name {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "parallel cannot run itself", Severity: SeverityError}}
+		}
+		if !entry.Spec.Concurrent {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("%s is not marked safe for concurrent execution", entry.Spec.Name), Severity: SeverityError}}
+		}
+		jobs[i] = job{entry: entry, args: args}
+	}
+
+	outcomes := make([]parallelItem, len(items))
+	for i, item := range items {
+		outcomes[i] = parallelItem{item: item, status: StatusPending, summary: "skipped"}
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+launch:
+	for i := range jobs {
+		select {
+		case sem <- struct{}{}:
+		case <-rt.Cancellation().Done():
+			break launch
+		}
+		wg.Add(1)
+		i, j := i, jobs[i]
+		name := fmt.Sprintf("parallel: %s", strings.Join(lines[i], " "))
+		rt.TaskManager().Spawn(name, func(_ context.Context, _ OutputChannel) error {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var buf bytes.Buffer
+			start := time.Now()
+			result, err := session.invoke(j.entry, j.args, &buf)
+			outcome := &outcomes[i]
+			outcome.duration = time.Since(start)
+			switch {
+			case err != nil:
+				outcome.status, outcome.summary = StatusFailed, firstLine(err.Error())
+				return err
+			case result.Status == StatusFailed:
+				outcome.status, outcome.summary = StatusFailed, firstLine(buf.String())
+				return errors.New(outcome.summary)
+			default:
+				outcome.status, outcome.summary = result.Status, firstLine(buf.String())
+				return nil
+			}
+		}, TaskOptions{Metadata: map[string]any{"item": items[i]}, NoNotify: true})
+	}
+	wg.Wait()
+
+	table := PipelineTable{Headers: []string{"Item", "Status", "Duration", "Output"}}
+	failed := 0
+	for _, o := range outcomes {
+		if o.status != StatusSuccess {
+			failed++
+		}
+		duration := "-"
+		if o.duration > 0 {
+			duration = o.duration.Round(time.Microsecond).String()
+		}
+		table.Rows = append(table.Rows, []string{o.item, string(o.status), duration, o.summary})
+	}
+	rt.Output().WriteTable(table.Headers, table.Rows)
+
+	status := StatusSuccess
+	switch {
+	case failed == len(outcomes):
+		status = StatusFailed
+	case failed > 0:
+		status = StatusPartial
+	}
+	result := CommandResult{Status: status, Payload: table, Pipeline: table, Summary: &ResultSummary{Counts: map[string]int{"succeeded": len(outcomes) - failed, "failed": failed}}}
+	if status == StatusFailed {
+		result.Error = &CommandError{Message: fmt.Sprintf("all %d items failed", failed), Severity: SeverityError}
+	}
+	return result
+}
+
+// errNoParallelItems reports a template given without `::` items.
+var errNoParallelItems = errors.New("no input items and no targets selected")
+
+// splitParallelArgs separates the command template from the items after `::`.
+func splitParallelArgs(tokens []string) ([]string, []string, error) {
+	sep := -1
+	for i, tok := range tokens {
+		if tok == "::" {
+			sep = i
+			break
+		}
+	}
+	if sep == 0 {
+		return nil, nil, errors.New("missing command template")
+	}
+	if sep < 0 {
+		return tokens, nil, errNoParallelItems
+	}
+	template, rest := tokens[:sep], tokens[sep+1:]
+	if len(rest) == 1 {
+		if data, err := os.ReadFile(rest[0]); err == nil {
+			rest = nil
+			for _, line := range strings.Split(string(data), "\n") {
+				line = strings.TrimSpace(line)
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+				rest = append(rest, line)
+			}
+		}
+	}
+	if len(rest) == 0 {
+		return nil, nil, errors.New("no input items after ::")
+	}
+	return template, rest, nil
+}
+
+// expandParallelTemplate substitutes item into template placeholders, appending it when there are none.
+// When lookup is set, named placeholders it resolves (such as {address}) take its value instead.
+func expandParallelTemplate(template []string, item string, lookup func(string) (string, bool)) []string {
+	out := make([]string, 0, len(template)+1)
+	replaced := false
+	for _, tok := range template {
+		expanded := parallelPlaceholder.ReplaceAllStringFunc(tok, func(m string) string {
+			if lookup != nil {
+				if v, ok := lookup(m[1 : len(m)-1]); ok {
+					return v
+				}
+			}
+			return item
+		})
+		if expanded != tok {
+			replaced = true
+		}
+		out = append(out, expanded)
+	}
+	if !replaced {
+		out = append(out, item)
+	}
+	return out
+}
+
+// firstLine returns the first non-empty line of s.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,282 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPayloadLimit bounds the named payloads kept per session unless overridden.
+const DefaultPayloadLimit = 32
+
+// PayloadRefPrefix marks a token as a reference to a named payload, as in `diff @before @after`.
+const PayloadRefPrefix = "@"
+
+var payloadNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ErrUnknownPayload is returned when a reference names no stored payload.
+var ErrUnknownPayload = errors.New("unknown payload")
+
+// StoredPayload is a named command payload kept for later reference.
+type StoredPayload struct {
+	Name     string
+	Type     string
+	Value    any
+	Source   string
+	Created  time.Time
+	LastUsed time.Time
+}
+
+// PayloadStore holds a session's named payloads. When full, the least recently
+// used payload is evicted.
+type PayloadStore struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[string]*StoredPayload
+}
+
+// NewPayloadStore constructs a store retaining at most limit payloads.
+func NewPayloadStore(limit int) *PayloadStore {
+	if limit <= 0 {
+		limit = DefaultPayloadLimit
+	}
+	return &PayloadStore{limit: limit, entries: map[string]*StoredPayload{}}
+}
+
+// WithPayloadLimit sets how many named payloads each session retains.
+func WithPayloadLimit(limit int) Option {
+	return func(e *Engine) { e.payloadLimit = limit }
+}
+
+// Put stores value under name, replacing any payload of that name. source
+// describes where the value came from, typically the command line.
+func (p *PayloadStore) Put(name string, value any, source string) error {
+	name = strings.TrimPrefix(name, PayloadRefPrefix)
+	if !payloadNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid payload name %q", name)
+	}
+	if value == nil {
+		return fmt.Errorf("payload %s is empty", name)
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries[name] = &StoredPayload{Name: name, Type: payloadKind(value), Value: value, Source: source, Created: now, LastUsed: now}
+	for len(p.entries) > p.limit {
+		var oldest *StoredPayload
+		for _, e := range p.entries {
+			if oldest == nil || e.LastUsed.Before(oldest.LastUsed) {
+				oldest = e
+			}
+		}
+		delete(p.entries, oldest.Name)
+	}
+	return nil
+}
+
+// Get returns the payload stored under name, with or without the @ prefix.
+func (p *PayloadStore) Get(name string) (StoredPayload, bool) {
+	name = strings.TrimPrefix(name, PayloadRefPrefix)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[name]
+	if !ok {
+		return StoredPayload{}, false
+	}
+	e.LastUsed = time.Now()
+	return *e, true
+}
+
+// Delete removes a payload, reporting whether it existed.
+func (p *PayloadStore) Delete(name string) bool {
+	name = strings.TrimPrefix(name, PayloadRefPrefix)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.entries[name]
+	delete(p.entries, name)
+	return ok
+}
+
+// List returns stored payloads sorted by name.
+func (p *PayloadStore) List() []StoredPayload {
+	p.mu.Lock()
+	list := make([]StoredPayload, 0, len(p.entries))
+	for _, e := range p.entries {
+		list = append(list, *e)
+	}
+	p.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Collect removes payloads unused for longer than idle and returns how many were removed.
+func (p *PayloadStore) Collect(idle time.Duration) int {
+	cutoff := time.Now().Add(-idle)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	removed := 0
+	for name, e := range p.entries {
+		if e.LastUsed.Before(cutoff) {
+			delete(p.entries, name)
+			removed++
+		}
+	}
+	return removed
+}
+
+// SavePayload stores v under name in the session running rt, so later commands
+// can reference it as @name.
+func SavePayload(rt CommandRuntime, name string, v any) error {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return errors.New("payloads require an engine session")
+	}
+	return session.payloads.Put(name, v, "")
+}
+
+// resolvePayloadRefs replaces the raw @name values of payload-typed args and
+// flags with the stored payloads.
+func (s *Session) resolvePayloadRefs(spec CommandSpec, args, flags ValueSet) error {
+	resolve := func(raw any) (any, error) {
+		switch t := raw.(type) {
+		case string:
+			stored, ok := s.payloads.Get(t)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownPayload, t)
+			}
+			return stored.Value, nil
+		case []string:
+			values := make([]any, 0, len(t))
+			for _, name := range t {
+				stored, ok := s.payloads.Get(name)
+				if !ok {
+					return nil, fmt.Errorf("%w: %s", ErrUnknownPayload, name)
+				}
+				values = append(values, stored.Value)
+			}
+			return values, nil
+		}
+		return raw, nil
+	}
+	for _, arg := range spec.Args {
+		if raw, ok := args.values[arg.Name]; ok && arg.Type == ArgTypePayload {
+			value, err := resolve(raw)
+			if err != nil {
+				return &ParseError{Err: err, Arg: arg.Name}
+			}
+			args.values[arg.Name] = value
+		}
+	}
+	for _, flag := range spec.Flags {
+		if raw, ok := flags.values[flag.Name]; ok && flag.Type == ArgTypePayload {
+			value, err := resolve(raw)
+			if err != nil {
+				return &ParseError{Err: err, Flag: flag.Name}
+			}
+			flags.values[flag.Name] = value
+		}
+	}
+	return nil
+}
+
+// payloadCandidateNames lists stored payload references for completion.
+func (s *Session) payloadCandidateNames() []string {
+	list := s.payloads.List()
+	names := make([]string, 0, len(list))
+	for _, p := range list {
+		names = append(names, PayloadRefPrefix+p.Name)
+	}
+	return names
+}
+
+// payload command -------------------------------------------------------------
+
+type payloadCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *payloadCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "payload",
+			Summary:     "Name command results for later reference",
+			Description: "save stores a result's payload (the last one unless N is given) under NAME so later commands can take it as @NAME. show renders a stored payload and drop removes it. The least recently used payload is evicted when the store is full.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"list", "save", "show", "drop"}, Default: "list", Description: "Action to perform"},
+				{Name: "name", Type: ArgTypeString, Description: "Payload name"},
+				{Name: "n", Type: ArgTypeInt, Description: "Result index to save (default: last)"},
+			},
+			Examples: []Example{
+				{Description: "Name the last result", Command: "payload save routes1"},
+				{Description: "Compare two stored payloads", Command: "diff @routes1 @routes2"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *payloadCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &payloadCommand{spec: f.Spec()}, nil
+}
+
+type payloadCommand struct {
+	spec CommandSpec
+}
+
+func (c *payloadCommand) Spec() CommandSpec { return c.spec }
+
+func (c *payloadCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "payload requires an engine session", Severity: SeverityError}}
+	}
+	store := session.payloads
+	name := input.Args.String("name")
+	action := input.Args.String("action")
+	if action != "list" && name == "" {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("payload %s requires a name", action), Severity: SeverityError}}
+	}
+	switch action {
+	case "save":
+		rec, ok := session.Results().Last()
+		if _, set := input.Args.Raw("n"); set {
+			rec, ok = session.Results().Get(input.Args.Int("n"))
+		}
+		if !ok || rec.Payload == nil {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "no result payload to save", Severity: SeverityError, Hints: []string{"run `result` to list retained results"}}}
+		}
+		if err := store.Put(name, rec.Payload, rec.Line); err != nil {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+		}
+		rt.Output().Info(fmt.Sprintf("Saved result #%d as %s%s", rec.Index, PayloadRefPrefix, strings.TrimPrefix(name, PayloadRefPrefix)))
+		return CommandResult{Status: StatusSuccess}
+	case "show":
+		stored, ok := store.Get(name)
+		if !ok {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("%v: %s", ErrUnknownPayload, name), Severity: SeverityError}}
+		}
+		renderPayload(rt.Output(), stored.Value)
+		return CommandResult{Status: StatusSuccess, Payload: stored.Value}
+	case "drop":
+		if !store.Delete(name) {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("%v: %s", ErrUnknownPayload, name), Severity: SeverityError}}
+		}
+		return CommandResult{Status: StatusSuccess}
+	}
+	list := store.List()
+	if len(list) == 0 {
+		rt.Output().Info("No payloads stored.")
+		return CommandResult{Status: StatusSuccess}
+	}
+	rows := make([][]string, 0, len(list))
+	for _, p := range list {
+		rows = append(rows, []string{PayloadRefPrefix + p.Name, p.Type, p.Source, p.Created.Format("15:04:05")})
+	}
+	rt.Output().WriteTable([]string{"Name", "Type", "Source", "Created"}, rows)
+	return CommandResult{Status: StatusSuccess}
+}
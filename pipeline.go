@@ -0,0 +1,260 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PipelineType names the shape of a pipeline payload.
+type PipelineType string
+
+const (
+	// PipelineTypeTable identifies PipelineTable payloads.
+	PipelineTypeTable PipelineType = "table"
+	// PipelineTypeJSON identifies JSON-compatible payloads ([]map[string]any, map[string]any, etc.).
+	PipelineTypeJSON PipelineType = "json"
+)
+
+// PipelineTyped lets payloads declare their own pipeline type.
+type PipelineTyped interface {
+	PipelineType() PipelineType
+}
+
+// PipelineTable is a generic tabular payload used as an interchange format.
+type PipelineTable struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// PipelineType implements PipelineTyped.
+func (PipelineTable) PipelineType() PipelineType { return PipelineTypeTable }
+
+// PipelineConverter adapts a payload from one pipeline type to another.
+type PipelineConverter func(v any) (any, error)
+
+// PipelineConverters stores converters between pipeline types.
+type PipelineConverters struct {
+	mu         sync.RWMutex
+	converters map[PipelineType]map[PipelineType]PipelineConverter
+}
+
+// NewPipelineConverters constructs a registry seeded with built-in converters.
+func NewPipelineConverters() *PipelineConverters {
+	c := &PipelineConverters{converters: map[PipelineType]map[PipelineType]PipelineConverter{}}
+	c.Register(PipelineTypeTable, PipelineTypeJSON, tableToJSON)
+	return c
+}
+
+// Register adds or replaces a converter between two pipeline types.
+func (c *PipelineConverters) Register(from, to PipelineType, fn PipelineConverter) {
+	if fn == nil || from == "" || to == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.converters[from]; !ok {
+		c.converters[from] = map[PipelineType]PipelineConverter{}
+	}
+	c.converters[from][to] = fn
+}
+
+// Convert adapts v from one type to another, chaining converters when no direct path exists.
+func (c *PipelineConverters) Convert(v any, from, to PipelineType) (any, error) {
+	if from == to {
+		return v, nil
+	}
+	path := c.path(from, to)
+	if path == nil {
+		return nil, fmt.Errorf("no pipeline converter from %s to %s", from, to)
+	}
+	current := v
+	for _, fn := range path {
+		next, err := fn(current)
+		if err != nil {
+			return nil, err
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// CanConvert reports whether a conversion path exists.
+func (c *PipelineConverters) CanConvert(from, to PipelineType) bool {
+	return from == to || c.path(from, to) != nil
+}
+
+// path performs a breadth-first search for the shortest converter chain.
+func (c *PipelineConverters) path(from, to PipelineType) []PipelineConverter {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	type step struct {
+		node  PipelineType
+		chain []PipelineConverter
+	}
+	visited := map[PipelineType]bool{from: true}
+	queue := []step{{node: from}}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for next, fn := range c.converters[cur.node] {
+			if visited[next] {
+				continue
+			}
+			chain := append(append([]PipelineConverter(nil), cur.chain...), fn)
+			if next == to {
+				return chain
+			}
+			visited[next] = true
+			queue = append(queue, step{node: next, chain: chain})
+		}
+	}
+	return nil
+}
+
+// PipelineTypeOf returns the declared or inferred pipeline type of a payload.
+func PipelineTypeOf(v any) PipelineType {
+	if v == nil {
+		return ""
+	}
+	if typed, ok := v.(PipelineTyped); ok {
+		return typed.PipelineType()
+	}
+	return PipelineType(fmt.Sprintf("%T", v))
+}
+
+// negotiatePipeline adapts payload to one of the accepted types.
+func negotiatePipeline(converters *PipelineConverters, payload any, accepts []PipelineType) (any, error) {
+	if payload == nil || len(accepts) == 0 {
+		return payload, nil
+	}
+	from := PipelineTypeOf(payload)
+	for _, want := range accepts {
+		if want == from {
+			return payload, nil
+		}
+	}
+	for _, want := range accepts {
+		if converters.CanConvert(from, want) {
+			return converters.Convert(payload, from, want)
+		}
+	}
+	return nil, fmt.Errorf("pipeline payload of type %s is not accepted (want %v)", from, accepts)
+}
+
+func tableToJSON(v any) (any, error) {
+	table, ok := v.(PipelineTable)
+	if !ok {
+		ptr, ok := v.(*PipelineTable)
+		if !ok || ptr == nil {
+			return nil, fmt.Errorf("expected PipelineTable, got %T", v)
+		}
+		table = *ptr
+	}
+	records := make([]map[string]any, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		record := make(map[string]any, len(table.Headers))
+		for i, header := range table.Headers {
+			if i < len(row) {
+				record[header] = row[i]
+			} else {
+				record[header] = ""
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// pipeOperator separates the stages of a pipeline, as in `list nodes | filter --status up`.
+const pipeOperator = "|"
+
+// pipeStages splits tokens at each "|" operator into the words of the stages
+// of a pipeline; a quoted '|' is an ordinary argument.
+func pipeStages(tokens []token) [][]string {
+	var stages [][]string
+	stage := []string{}
+	for _, tok := range tokens {
+		if tok.op && tok.text == pipeOperator {
+			stages = append(stages, stage)
+			stage = []string{}
+			continue
+		}
+		stage = append(stage, tok.text)
+	}
+	return append(stages, stage)
+}
+
+// runPipeline runs the stages of a pipeline in order, each as a foreground
+// command as execute runs one. Each stage after the first must set AllowPipes
+// or PipelineAccepts; it receives the previous stage's CommandResult.Pipeline,
+// or its Payload when that is nil, as CommandInput.Pipeline, converted to an
+// accepted type. Output of the earlier stages is shown only when they fail,
+// which stops the pipeline, as does moving a stage to the background with Ctrl-Z.
+func (s *Session) runPipeline(ctx context.Context, stages [][]string) (CommandResult, error) {
+	entries := make([]CommandEntry, len(stages))
+	args := make([][]string, len(stages))
+	for i, stage := range stages {
+		if len(stage) == 0 {
+			return CommandResult{}, errors.New("empty pipeline stage")
+		}
+		entry, rest, err := s.resolveCommand(stage)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		if i > 0 && !entry.Spec.AllowPipes && len(entry.Spec.PipelineAccepts) == 0 {
+			return CommandResult{}, fmt.Errorf("%s does not accept piped input", entry.Spec.Name)
+		}
+		entries[i], args[i] = entry, rest
+	}
+
+	start := time.Now()
+	var result CommandResult
+	var in any
+	var lines []string
+	for i, entry := range entries {
+		last := i == len(entries)-1
+		var buf bytes.Buffer
+		w := s.OutputWriter()
+		if !last {
+			w = &buf
+		}
+		run := s.runCommand(ctx, entry, append([]string{entry.Spec.Name}, args[i]...), w, in, i > 0)
+		result = run.result
+		if run.suspended {
+			return result, nil
+		}
+		lines = append(lines, run.line)
+		if run.err != nil || result.Status == StatusFailed {
+			if !last {
+				s.OutputWriter().Write(buf.Bytes())
+			}
+			if run.err != nil {
+				return result, fmt.Errorf("pipeline stage %d: %w", i+1, run.err)
+			}
+			return result, nil
+		}
+		in = result.Pipeline
+		if in == nil {
+			in = result.Payload
+		}
+		if last {
+			s.results.Record(ResultRecord{
+				Command:      entry.Spec.Name,
+				Line:         strings.Join(lines, " | "),
+				Status:       result.Status,
+				Payload:      result.Payload,
+				Error:        result.Error,
+				Summary:      result.Summary,
+				Duration:     time.Since(start),
+				Time:         start,
+				InvocationID: run.meta.ID,
+			})
+		}
+	}
+	return result, nil
+}
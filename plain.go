@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/chzyer/readline"
+)
+
+// WithPlainMode forces the line-oriented stdio loop instead of readline.
+func WithPlainMode() Option {
+	return func(e *Engine) { e.plainMode = true }
+}
+
+// TerminalAvailable reports whether stdin and stdout are interactive terminals with usable termcap.
+func TerminalAvailable() bool {
+	if term := os.Getenv("TERM"); term == "" || term == "dumb" {
+		return false
+	}
+	return readline.DefaultIsTerminal()
+}
+
+// RunPlain runs the interactive loop reading lines from r without editing or completion.
+// Prompts are still printed so transcripts in CI logs stay readable.
+func (s *Session) RunPlain(r io.Reader) error {
+	if r == nil {
+		r = os.Stdin
+	}
+	in := newPlainReader(r)
+	idle := s.startIdle(in.close)
+	defer idle.pause()
+	readLine := func(prompt string) (string, error) {
+		fmt.Fprint(s.OutputWriter(), prompt)
+		return in.readLine()
+	}
+	defer s.attachInput(readLine)()
+	current := ""
+	return s.crashBarrier(func() string { return current }, func() error {
+		// Restarting after a crash resumes the idle timer the crash left paused.
+		idle.touch()
+		for {
+			s.announceAdvisories(s.OutputWriter())
+			line, err := readLine(s.promptString())
+			if err != nil {
+				if errors.Is(err, errIdleExit) {
+					return nil
+				}
+				if errors.Is(err, io.EOF) {
+					fmt.Fprintln(s.OutputWriter())
+					return nil
+				}
+				return err
+			}
+			if idle.takeExpired() {
+				idle.pause()
+				if !s.unlock(readLine, readLine) {
+					return nil
+				}
+				idle.touch()
+				continue
+			}
+			idle.touch()
+			line = strings.TrimSpace(line)
+			current = line
+			if line == "" {
+				continue
+			}
+			if line, err = s.expandHistory(line); err != nil {
+				s.reportError(err)
+				continue
+			}
+			tokens, err := SplitCommandLine(line)
+			if err != nil {
+				s.reportError(err)
+				continue
+			}
+			if len(tokens) == 0 {
+				continue
+			}
+			if exitRequested(tokens[0]) {
+				fmt.Fprintf(s.OutputWriter(), "\nShutting down.\n")
+				return nil
+			}
+			if saved, ok := s.historyLine(tokens); ok {
+				s.recordHistory(saved)
+			}
+			if line, err = s.collectInput(line); err != nil {
+				s.reportError(err)
+				continue
+			}
+			lexed, err := s.splitLine(line)
+			if err != nil {
+				s.reportError(err)
+				continue
+			}
+			if len(lexed) == 0 {
+				continue
+			}
+			idle.pause()
+			s.dispatchForeground(lexed)
+			current = ""
+			idle.touch()
+		}
+	})
+}
+
+// plainReader scans lines on a goroutine so a pending read can be abandoned on idle exit.
+type plainReader struct {
+	lines  chan string
+	err    error
+	done   chan struct{}
+	closed sync.Once
+}
+
+func newPlainReader(r io.Reader) *plainReader {
+	p := &plainReader{lines: make(chan string), done: make(chan struct{})}
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			select {
+			case p.lines <- scanner.Text():
+			case <-p.done:
+				return
+			}
+		}
+		p.err = scanner.Err()
+		close(p.lines)
+	}()
+	return p
+}
+
+func (p *plainReader) readLine() (string, error) {
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			if p.err != nil {
+				return "", p.err
+			}
+			return "", io.EOF
+		}
+		return line, nil
+	case <-p.done:
+		return "", errIdleExit
+	}
+}
+
+func (p *plainReader) close() {
+	p.closed.Do(func() { close(p.done) })
+}
+
+// RunPlain starts the plain stdio loop on the default session.
+func (e *Engine) RunPlain(r io.Reader) error {
+	return e.defaultSession.RunPlain(r)
+}
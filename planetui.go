@@ -47,6 +47,11 @@ func WithContextAliases(aliases ...string) ContextOption {
 	return func(spec *ContextSpec) { spec.Aliases = append(spec.Aliases, aliases...) }
 }
 
+// WithContextPayloadCompletion sets the provider of payload candidates for the context.
+func WithContextPayloadCompletion(fn CompleteFunc) ContextOption {
+	return func(spec *ContextSpec) { spec.CompletePayload = fn }
+}
+
 // WithContextTags assigns tags to a context.
 func WithContextTags(tags ...string) ContextOption {
 	return func(spec *ContextSpec) { spec.Tags = append(spec.Tags, tags...) }
@@ -57,6 +62,11 @@ func RegisterCommand(factory CommandFactory) {
 	defaultEngine.RegisterCommand(factory)
 }
 
+// RegisterFunc registers fn as a command described by spec with the default engine.
+func RegisterFunc(spec CommandSpec, fn func(CommandRuntime, CommandInput) CommandResult) {
+	defaultEngine.RegisterFunc(spec, fn)
+}
+
 // RegisterLegacyCommand adapts a legacy command into the new runtime.
 func RegisterLegacyCommand(ctx string, cmd LegacyCommand) {
 	defaultEngine.RegisterCommand(NewLegacyAdapter(cmd, ctx))
@@ -64,7 +74,12 @@ func RegisterLegacyCommand(ctx string, cmd LegacyCommand) {
 
 // UseMiddleware appends middleware to the default engine.
 func UseMiddleware(mw ...Middleware) {
-	WithMiddleware(mw...)(defaultEngine)
+	defaultEngine.Use(mw...)
+}
+
+// RegisterPipelineConverter registers a pipeline converter with the default engine.
+func RegisterPipelineConverter(from, to PipelineType, fn PipelineConverter) {
+	defaultEngine.Converters().Register(from, to, fn)
 }
 
 // SetOutputWriter sets the writer used for command output, returning the previous writer.
@@ -76,3 +91,18 @@ func SetOutputWriter(w io.Writer) io.Writer {
 func Run(rl *readline.Instance) error {
 	return defaultEngine.Run(rl)
 }
+
+// RunPlain starts the readline-free loop using the default engine.
+func RunPlain(r io.Reader) error {
+	return defaultEngine.RunPlain(r)
+}
+
+// ExecuteLine runs one command line on the default engine and returns its result.
+func ExecuteLine(line string) (CommandResult, error) {
+	return defaultEngine.ExecuteLine(line)
+}
+
+// RunOnce runs args as a single CLI invocation on the default engine, returning its exit code.
+func RunOnce(args []string) int {
+	return defaultEngine.RunOnce(args)
+}
@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+	"time"
+)
+
+// DefaultPprofAddr is where `debug pprof start` listens without --addr.
+const DefaultPprofAddr = "localhost:6060"
+
+// PermissionPprof is the permission `debug pprof start` and `debug pprof stop`
+// require under WithAuthorization.
+const PermissionPprof = "debug.pprof"
+
+// pprofServer is the engine's optional net/http/pprof listener.
+type pprofServer struct {
+	mu   sync.Mutex
+	srv  *http.Server
+	addr string
+	// remote allows addresses other than loopback ones; see WithPprofRemote.
+	remote bool
+	// startAddr is the address WithPprof listens on once the options are applied.
+	startAddr string
+}
+
+// StartPprof serves the net/http/pprof endpoints under /debug/pprof/ on addr
+// and returns the address it listens on. Only one listener runs at a time,
+// and only on a loopback address unless WithPprofRemote is set.
+func (e *Engine) StartPprof(addr string) (string, error) {
+	p := &e.pprof
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.srv != nil {
+		return p.addr, fmt.Errorf("pprof already listening on %s", p.addr)
+	}
+	if addr == "" {
+		addr = DefaultPprofAddr
+	}
+	if !p.remote {
+		if err := loopbackOnly(addr); err != nil {
+			return "", err
+		}
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	p.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	p.addr = ln.Addr().String()
+	go func(srv *http.Server) { _ = srv.Serve(ln) }(p.srv)
+	return p.addr, nil
+}
+
+// loopbackOnly fails unless addr's host is localhost or a loopback IP; an
+// empty host, as in ":6060", listens on every interface.
+func loopbackOnly(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+	return fmt.Errorf("pprof address %s is not a loopback address (WithPprofRemote allows others)", addr)
+}
+
+// StopPprof closes the listener started by StartPprof.
+func (e *Engine) StopPprof() error {
+	p := &e.pprof
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.srv == nil {
+		return errors.New("pprof is not running")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := p.srv.Shutdown(ctx)
+	p.srv, p.addr = nil, ""
+	return err
+}
+
+// PprofAddr returns the address of the running pprof listener, or "".
+func (e *Engine) PprofAddr() string {
+	e.pprof.mu.Lock()
+	defer e.pprof.mu.Unlock()
+	return e.pprof.addr
+}
+
+// WithPprof starts the pprof listener on addr when the engine is built.
+func WithPprof(addr string) Option {
+	return func(e *Engine) {
+		e.pprof.startAddr = addr
+		if e.pprof.startAddr == "" {
+			e.pprof.startAddr = DefaultPprofAddr
+		}
+	}
+}
+
+// WithPprofRemote lets WithPprof and `debug pprof start` listen on addresses
+// other than loopback ones. The profiles expose the process' command line and
+// internals to anyone who can reach the address.
+func WithPprofRemote() Option {
+	return func(e *Engine) { e.pprof.remote = true }
+}
+
+// startConfiguredPprof starts the listener WithPprof asked for.
+func (e *Engine) startConfiguredPprof() {
+	if e.pprof.startAddr == "" {
+		return
+	}
+	if _, err := e.StartPprof(e.pprof.startAddr); err != nil {
+		fmt.Fprintf(e.outputWriter, "Error starting pprof: %v\n", err)
+	}
+}
+
+// debugPprof runs `debug pprof [start|stop|status]`.
+func (c *debugCommand) debugPprof(rt CommandRuntime, input CommandInput) CommandResult {
+	op := input.Args.String("op")
+	if op == "start" || op == "stop" {
+		if _, missing := missingPermission(c.engine.permissions, rt.Session(), CommandSpec{Permissions: []string{PermissionPprof}}); missing {
+			return permissionFailure(rt, "debug pprof "+op, PermissionPprof)
+		}
+	}
+	var err error
+	switch op {
+	case "start":
+		var addr string
+		if addr, err = c.engine.StartPprof(input.Flags.String("addr")); err == nil {
+			rt.Output().Info(fmt.Sprintf("pprof listening on http://%s/debug/pprof/", addr))
+		}
+	case "stop":
+		if err = c.engine.StopPprof(); err == nil {
+			rt.Output().Info("pprof stopped.")
+		}
+	default:
+		if addr := c.engine.PprofAddr(); addr != "" {
+			rt.Output().Info(fmt.Sprintf("pprof listening on http://%s/debug/pprof/", addr))
+		} else {
+			rt.Output().Info("pprof is not running.")
+		}
+	}
+	if err != nil {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+	}
+	return CommandResult{Status: StatusSuccess, Payload: map[string]string{"addr": c.engine.PprofAddr()}}
+}
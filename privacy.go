@@ -0,0 +1,96 @@
+package tui
+
+import "strings"
+
+// redactedMask replaces secret values in lines kept after execution.
+const redactedMask = "****"
+
+// historyLine returns the line to keep in command history, with secret values
+// masked, or false when the command opts out with NoHistory.
+func (s *Session) historyLine(tokens []string) (string, bool) {
+	if entry, _, err := s.resolveCommand(tokens); err == nil && entry.Spec.NoHistory {
+		return "", false
+	}
+	return s.redactLine(tokens), true
+}
+
+// redactLine masks secret values in tokens for display, e.g. in queue notices.
+func (s *Session) redactLine(tokens []string) string {
+	entry, args, err := s.resolveCommand(tokens)
+	if err != nil {
+		return QuoteCommandLine(tokens)
+	}
+	prefix := tokens[:len(tokens)-len(args)]
+	return QuoteCommandLine(append(append([]string(nil), prefix...), redactArgs(entry.resolved(), args)...))
+}
+
+// redactArgs returns a copy of args with ArgTypeSecret argument and flag values replaced.
+func redactArgs(c *compiledSpec, args []string) []string {
+	out := append([]string(nil), args...)
+	spec, flags := c.spec, c.flags
+	positional := 0
+	endOfFlags := false
+	for i := 0; i < len(out); i++ {
+		token := out[i]
+		if positional < len(spec.Args) && spec.Args[positional].Passthrough && !flags.declared(token) {
+			if spec.Args[positional].secret() {
+				if token == "--" {
+					i++
+				}
+				for ; i < len(out); i++ {
+					out[i] = redactedMask
+				}
+			}
+			break
+		}
+		if token == "--" && !endOfFlags {
+			endOfFlags = true
+			continue
+		}
+		if strings.HasPrefix(token, "-") && token != "-" && !endOfFlags {
+			name := strings.TrimLeft(token, "-")
+			value, inline := "", false
+			if idx := strings.Index(name, "="); idx >= 0 {
+				name, value, inline = name[:idx], name[idx+1:], true
+			}
+			if !strings.HasPrefix(token, "--") {
+				if long, ok := flags.resolveShorthand(name); ok {
+					name = long
+				}
+			}
+			flag, ok := flags.lookup(name)
+			if !ok {
+				continue
+			}
+			if inline {
+				if flag.Type == ArgTypeSecret && value != "" {
+					out[i] = token[:len(token)-len(value)] + redactedMask
+				}
+				continue
+			}
+			if flag.Type != ArgTypeBool && i+1 < len(out) {
+				i++
+				if flag.Type == ArgTypeSecret {
+					out[i] = redactedMask
+				}
+			}
+			continue
+		}
+		idx := positional
+		if idx >= len(spec.Args) {
+			if len(spec.Args) == 0 || !spec.Args[len(spec.Args)-1].Repeatable {
+				continue
+			}
+			idx = len(spec.Args) - 1
+		}
+		if spec.Args[idx].secret() {
+			out[i] = redactedMask
+		}
+		if !spec.Args[idx].Repeatable {
+			positional++
+		}
+	}
+	return out
+}
+
+func (a ArgSpec) secret() bool { return a.Secret || a.Type == ArgTypeSecret }
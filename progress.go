@@ -0,0 +1,228 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProgressReporter reports incremental progress of a long-running operation.
+// It is safe for concurrent use.
+type ProgressReporter interface {
+	// Add advances progress by n units.
+	Add(n int)
+	// Set moves progress to current.
+	Set(current int)
+	// Message sets a short status shown after the bar.
+	Message(msg string)
+	// Done finishes the bar and prints its final state on its own line.
+	Done()
+}
+
+const (
+	progressWidth    = 30
+	progressInterval = 100 * time.Millisecond
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// Progress starts a progress bar identified by id, or a spinner when total is
+// not positive. On a terminal the bar is redrawn in place; on other writers and
+// for background tasks a line is printed at each quarter and on completion.
+// Progress is not shown in quiet mode or when a structured format is selected.
+func (c *DefaultOutputChannel) Progress(id string, total int) ProgressReporter {
+	if c.Level() < OutputNormal || c.Format() != OutputFormatTable {
+		return nopProgress{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := &progressBar{
+		c:           c,
+		id:          id,
+		total:       total,
+		started:     time.Now(),
+		interactive: !c.lineProgress && TerminalAvailable(),
+	}
+	c.bars = append(c.bars, p)
+	if p.interactive {
+		c.drawProgress()
+		if total <= 0 {
+			p.stop = make(chan struct{})
+			go p.spin()
+		}
+	}
+	return p
+}
+
+// suspendProgress clears drawn progress bars and returns a func that redraws
+// them. It must be called with c.mu held.
+func (c *DefaultOutputChannel) suspendProgress() func() {
+	if !c.progressDrawn {
+		return func() {}
+	}
+	fmt.Fprint(c.writer, "\r\x1b[K")
+	c.progressDrawn = false
+	return c.drawProgress
+}
+
+// drawProgress renders the active interactive bars on one line. It must be
+// called with c.mu held.
+func (c *DefaultOutputChannel) drawProgress() {
+	var parts []string
+	for _, p := range c.bars {
+		if p.interactive {
+			parts = append(parts, p.render())
+		}
+	}
+	if len(parts) == 0 {
+		return
+	}
+	c.ensureLead()
+	fmt.Fprint(c.writer, "\r\x1b[K"+strings.Join(parts, "  "))
+	c.progressDrawn = true
+}
+
+type progressBar struct {
+	c           *DefaultOutputChannel
+	id          string
+	total       int
+	current     int
+	msg         string
+	frame       int
+	started     time.Time
+	lastDraw    time.Time
+	quarter     int
+	interactive bool
+	done        bool
+	stop        chan struct{}
+}
+
+func (p *progressBar) Add(n int) {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+	p.update(p.current + n)
+}
+
+func (p *progressBar) Set(current int) {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+	p.update(current)
+}
+
+func (p *progressBar) Message(msg string) {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+	p.msg = msg
+	if p.interactive && !p.done {
+		p.c.drawProgress()
+	}
+}
+
+func (p *progressBar) Done() {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+	if p.done {
+		return
+	}
+	p.done = true
+	if p.stop != nil {
+		close(p.stop)
+	}
+	if p.total > 0 && p.current < p.total {
+		p.current = p.total
+	}
+	for i, bar := range p.c.bars {
+		if bar == p {
+			p.c.bars = append(p.c.bars[:i], p.c.bars[i+1:]...)
+			break
+		}
+	}
+	redraw := p.c.suspendProgress()
+	p.c.ensureLead()
+	fmt.Fprintln(p.c.writer, p.summary())
+	redraw()
+}
+
+// update must be called with c.mu held.
+func (p *progressBar) update(current int) {
+	if p.done {
+		return
+	}
+	if p.total > 0 && current > p.total {
+		current = p.total
+	}
+	p.current = current
+	if p.interactive {
+		if time.Since(p.lastDraw) >= progressInterval || current == p.total {
+			p.lastDraw = time.Now()
+			p.c.drawProgress()
+		}
+		return
+	}
+	if p.total <= 0 {
+		return
+	}
+	if q := current * 4 / p.total; q > p.quarter && q < 4 {
+		p.quarter = q
+		p.c.ensureLead()
+		fmt.Fprintf(p.c.writer, "%s: %d%% (%d/%d)%s\n", p.id, q*25, current, p.total, p.suffix())
+	}
+}
+
+func (p *progressBar) spin() {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.c.mu.Lock()
+			if !p.done {
+				p.frame++
+				p.c.drawProgress()
+			}
+			p.c.mu.Unlock()
+		}
+	}
+}
+
+// render formats the in-place bar or spinner.
+func (p *progressBar) render() string {
+	if p.total <= 0 {
+		frame := spinnerFrames[p.frame%len(spinnerFrames)]
+		if p.current > 0 {
+			return fmt.Sprintf("%s %s %d%s", p.id, frame, p.current, p.suffix())
+		}
+		return fmt.Sprintf("%s %s%s", p.id, frame, p.suffix())
+	}
+	filled := p.current * progressWidth / p.total
+	bar := strings.Repeat("=", filled)
+	if filled < progressWidth {
+		bar += ">" + strings.Repeat(" ", progressWidth-filled-1)
+	}
+	return fmt.Sprintf("%s [%s] %d/%d %3d%%%s", p.id, bar, p.current, p.total, p.current*100/p.total, p.suffix())
+}
+
+// summary formats the final line printed by Done.
+func (p *progressBar) summary() string {
+	elapsed := time.Since(p.started).Round(time.Millisecond)
+	if p.total > 0 {
+		return fmt.Sprintf("%s: done (%d/%d) in %s%s", p.id, p.current, p.total, elapsed, p.suffix())
+	}
+	return fmt.Sprintf("%s: done in %s%s", p.id, elapsed, p.suffix())
+}
+
+func (p *progressBar) suffix() string {
+	if p.msg == "" {
+		return ""
+	}
+	return " " + p.msg
+}
+
+type nopProgress struct{}
+
+func (nopProgress) Add(int)        {}
+func (nopProgress) Set(int)        {}
+func (nopProgress) Message(string) {}
+func (nopProgress) Done()          {}
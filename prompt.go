@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// WithArgPrompting controls whether interactive sessions prompt for missing
+// required arguments instead of failing. It is enabled by default.
+func WithArgPrompting(enabled bool) Option {
+	return func(e *Engine) { e.noArgPrompt = !enabled }
+}
+
+// promptMissingArgs asks the operator for the positional arguments missing from
+// args, up to the last required one, and returns args with the answers appended.
+func (s *Session) promptMissingArgs(entry CommandEntry, args []string, err error) ([]string, bool) {
+	if s.engine.noArgPrompt || !errors.Is(err, ErrMissingArgument) {
+		return nil, false
+	}
+	spec := entry.Spec
+	positional, pending := scanArgs(entry.resolved(), args)
+	if pending != nil {
+		return nil, false
+	}
+	last := -1
+	for i := positional; i < len(spec.Args); i++ {
+		arg := spec.Args[i]
+		if arg.Required && arg.Default == nil && !arg.Repeatable {
+			last = i
+		}
+	}
+	if last < 0 {
+		return nil, false
+	}
+	filled := append([]string(nil), args...)
+	for i := positional; i <= last; i++ {
+		arg := spec.Args[i]
+		prompt := arg.Prompt
+		if prompt == "" {
+			prompt = fmt.Sprintf("value for %s: ", strings.ToUpper(arg.Name))
+		}
+		ask := s.Ask
+		switch {
+		case arg.Secret:
+			ask = s.AskSecret
+		case arg.Type == ArgTypeEnum && len(arg.EnumValues) > 0 && arg.Default == nil:
+			ask = func(prompt string) (string, error) {
+				return s.selectEnum(strings.TrimSuffix(strings.TrimSpace(prompt), ":"), arg.EnumValues)
+			}
+		}
+		value, aerr := ask(prompt)
+		if aerr != nil {
+			return nil, false
+		}
+		if value == "" {
+			if arg.Default == nil {
+				return nil, false
+			}
+			value = fmt.Sprint(arg.Default)
+		}
+		filled = append(filled, value)
+	}
+	return filled, true
+}
+
+// selectEnum offers an enum argument's values through Select.
+func (s *Session) selectEnum(title string, values []string) (string, error) {
+	options := make([]SelectOption, len(values))
+	for i, v := range values {
+		options[i] = SelectOption{Label: v, Value: v}
+	}
+	choice, err := s.Select(s.engine.newOutputChannel(s.OutputWriter()), title, options)
+	return choice.Label, err
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+)
+
+// commandQueue serialises command execution for a session in FIFO order so
+// concurrent submissions never interleave over shared session state.
+type commandQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	next    uint64
+	serving uint64
+	running string
+}
+
+func newCommandQueue() *commandQueue {
+	q := &commandQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// acquire blocks until it is the caller's turn, invoking notify once if it had to wait.
+// The returned func must be called to release the slot.
+func (q *commandQueue) acquire(line string, notify func(ahead int, running string)) func() {
+	q.mu.Lock()
+	ticket := q.next
+	q.next++
+	if ticket != q.serving && notify != nil {
+		ahead := int(ticket - q.serving)
+		running := q.running
+		q.mu.Unlock()
+		notify(ahead, running)
+		q.mu.Lock()
+	}
+	for ticket != q.serving {
+		q.cond.Wait()
+	}
+	q.running = line
+	q.mu.Unlock()
+	return func() {
+		q.mu.Lock()
+		q.serving++
+		q.running = ""
+		q.mu.Unlock()
+		q.cond.Broadcast()
+	}
+}
+
+// Pending returns the number of submissions waiting or running.
+func (q *commandQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int(q.next - q.serving)
+}
+
+// dispatch runs tokens through the session queue, printing a notice when the line must wait.
+func (s *Session) dispatch(tokens []token) error {
+	_, err := s.dispatchResult(tokens)
+	return err
+}
+
+// dispatchResult is dispatch returning the command result as well.
+func (s *Session) dispatchResult(tokens []token) (CommandResult, error) {
+	release := s.queue.acquire(s.redactLine(words(tokens)), func(ahead int, running string) {
+		fmt.Fprintf(s.OutputWriter(), "queued: waiting for %q (%d ahead)\n", running, ahead)
+	})
+	defer release()
+	return s.process(tokens)
+}
+
+// QueueDepth reports how many command lines are running or waiting in the session.
+func (s *Session) QueueDepth() int { return s.queue.Pending() }
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// resultRef matches references to earlier results: $_ is the previous command's
+// payload and $_3 the payload of result 3, each optionally followed by a path
+// such as .items[0].id.
+var resultRef = regexp.MustCompile(`^_(\d*)((?:\.[A-Za-z0-9_-]+|\[-?\d+\])*)$`)
+
+// resultRefPath matches the path following an unbraced $_ reference.
+var resultRefPath = regexp.MustCompile(`^(?:\.[A-Za-z0-9_-]+|\[-?\d+\])*`)
+
+// isResultRef reports whether name, as parsed after a $, refers to a result.
+func isResultRef(name string) bool {
+	return resultRef.MatchString(name)
+}
+
+// resolveResultRef evaluates a result reference such as _.items[0].id against
+// the session's result history. Strings expand as-is, other scalars in their
+// JSON form, and objects and lists as compact JSON.
+func (s *Session) resolveResultRef(ref string) (string, error) {
+	m := resultRef.FindStringSubmatch(ref)
+	if m == nil {
+		return "", fmt.Errorf("invalid result reference $%s", ref)
+	}
+	var rec ResultRecord
+	var ok bool
+	if m[1] == "" {
+		rec, ok = s.results.Last()
+		if !ok {
+			return "", fmt.Errorf("$%s: no previous result", ref)
+		}
+	} else {
+		n, _ := strconv.Atoi(m[1])
+		if rec, ok = s.results.Get(n); !ok {
+			return "", fmt.Errorf("$%s: no result with index %d", ref, n)
+		}
+	}
+	if rec.Payload == nil {
+		return "", fmt.Errorf("$%s: result #%d (%s) has no payload", ref, rec.Index, rec.Line)
+	}
+	value, err := toGenericJSON(rec.Payload)
+	if err != nil {
+		return "", fmt.Errorf("$%s: %w", ref, err)
+	}
+	if value, err = walkPath(value, m[2]); err != nil {
+		return "", fmt.Errorf("$%s: %w", ref, err)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+// walkPath follows a path of .key and [index] steps into a value decoded from
+// JSON. Negative indices count from the end of a list.
+func walkPath(value any, path string) (any, error) {
+	walked := ""
+	for path != "" {
+		var step string
+		if path[0] == '[' {
+			end := strings.IndexByte(path, ']')
+			step, path = path[:end+1], path[end+1:]
+			list, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s is not a list", describePath(walked))
+			}
+			i, _ := strconv.Atoi(step[1 : len(step)-1])
+			if i < 0 {
+				i += len(list)
+			}
+			if i < 0 || i >= len(list) {
+				return nil, fmt.Errorf("index %s out of range: %s has %d item(s)", step, describePath(walked), len(list))
+			}
+			value = list[i]
+		} else {
+			end := strings.IndexAny(path[1:], ".[")
+			if end < 0 {
+				end = len(path) - 1
+			}
+			step, path = path[:end+1], path[end+1:]
+			obj, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s is not an object", describePath(walked))
+			}
+			if value, ok = obj[step[1:]]; !ok {
+				return nil, fmt.Errorf("%s has no field %q", describePath(walked), step[1:])
+			}
+		}
+		walked += step
+	}
+	return value, nil
+}
+
+func describePath(walked string) string {
+	if walked == "" {
+		return "the payload"
+	}
+	return walked
+}
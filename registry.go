@@ -7,12 +7,16 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // CommandEntry stores command factory and resolved names.
 type CommandEntry struct {
 	Factory CommandFactory
 	Spec    CommandSpec
+
+	compiled *compiledSpec
 }
 
 // CommandRegistry manages contexts and command registrations.
@@ -21,6 +25,25 @@ type CommandRegistry struct {
 	contexts map[string]ContextSpec
 	aliases  map[string]string
 	commands map[string]map[string]CommandEntry // context -> name -> entry
+	plugins  []string
+	// conflicts records names one command lost to another, for Lint.
+	conflicts []nameConflict
+
+	// deferred factories are registered on first lookup; see registerDeferred.
+	deferMu  sync.Mutex
+	deferred []CommandFactory
+	pending  atomic.Bool
+
+	registered   int
+	registerTime time.Duration
+	generation   atomic.Uint64
+}
+
+// RegistrationStats reports how many commands were registered and the time spent doing so.
+type RegistrationStats struct {
+	Commands int
+	Deferred int
+	Duration time.Duration
 }
 
 // NewCommandRegistry constructs a registry.
@@ -36,6 +59,7 @@ func NewCommandRegistry() *CommandRegistry {
 func (r *CommandRegistry) RegisterContext(spec ContextSpec) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	defer r.generation.Add(1)
 	r.contexts[spec.Name] = spec
 	for _, alias := range spec.Aliases {
 		r.aliases[alias] = spec.Name
@@ -55,29 +79,95 @@ func (r *CommandRegistry) Context(name string) (ContextSpec, bool) {
 
 // RegisterCommand registers a command factory.
 func (r *CommandRegistry) RegisterCommand(factory CommandFactory) {
+	r.add(factory, true)
+}
+
+// add registers factory. Without replace, names already taken are left alone.
+func (r *CommandRegistry) add(factory CommandFactory, replace bool) {
+	start := time.Now()
 	spec := factory.Spec()
 	if spec.Name == "" {
 		panic("command spec must define name")
 	}
 	ctx := spec.Context
+	if spec.Usage == "" {
+		spec.Usage = FormatUsage(spec)
+	}
+	compiled := compileSpec(spec)
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	defer r.generation.Add(1)
 
 	if _, ok := r.commands[ctx]; !ok {
 		r.commands[ctx] = map[string]CommandEntry{}
 	}
-	entry := CommandEntry{Factory: factory, Spec: spec}
-	r.commands[ctx][spec.Name] = entry
-	for _, alias := range spec.Aliases {
-		r.commands[ctx][alias] = entry
+	entry := CommandEntry{Factory: factory, Spec: spec, compiled: compiled}
+	added := false
+	for _, name := range append([]string{spec.Name}, spec.Aliases...) {
+		if prev, taken := r.commands[ctx][name]; taken && prev.Spec.Name != spec.Name {
+			if !replace {
+				r.conflicts = append(r.conflicts, nameConflict{context: ctx, name: name, owner: prev.Spec.Name, loser: spec.Name})
+				continue
+			}
+			r.conflicts = append(r.conflicts, nameConflict{context: ctx, name: name, owner: spec.Name, loser: prev.Spec.Name})
+		}
+		r.commands[ctx][name] = entry
+		added = true
+	}
+	if added {
+		r.registered++
+	}
+	r.registerTime += time.Since(start)
+}
+
+// registerDeferred queues factories to be registered on the first lookup, so
+// building their specs does not slow down startup. Commands registered under
+// the same name in the meantime take precedence.
+func (r *CommandRegistry) registerDeferred(factories ...CommandFactory) {
+	r.deferMu.Lock()
+	defer r.deferMu.Unlock()
+	r.deferred = append(r.deferred, factories...)
+	r.pending.Store(true)
+}
+
+// materialize registers deferred factories; every lookup calls it first.
+func (r *CommandRegistry) materialize() {
+	if !r.pending.Load() {
+		return
 	}
+	r.deferMu.Lock()
+	defer r.deferMu.Unlock()
+	for _, factory := range r.deferred {
+		r.add(factory, false)
+	}
+	r.deferred = nil
+	r.pending.Store(false)
+}
+
+// Generation changes whenever a context or command is registered or removed,
+// letting callers invalidate data derived from the registry.
+func (r *CommandRegistry) Generation() uint64 {
+	r.materialize()
+	return r.generation.Load()
+}
+
+// RegistrationStats reports registration counts and cumulative time.
+func (r *CommandRegistry) RegistrationStats() RegistrationStats {
+	r.deferMu.Lock()
+	deferred := len(r.deferred)
+	r.deferMu.Unlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return RegistrationStats{Commands: r.registered, Deferred: deferred, Duration: r.registerTime}
 }
 
 // UnregisterCommand removes a command by name.
 func (r *CommandRegistry) UnregisterCommand(ctx, name string) {
+	r.materialize()
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	defer r.generation.Add(1)
 	if commands, ok := r.commands[ctx]; ok {
 		delete(commands, name)
 	}
@@ -85,6 +175,7 @@ func (r *CommandRegistry) UnregisterCommand(ctx, name string) {
 
 // Resolve finds a command entry for a context.
 func (r *CommandRegistry) Resolve(ctx, name string) (CommandEntry, bool) {
+	r.materialize()
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	if commands, ok := r.commands[ctx]; ok {
@@ -94,8 +185,41 @@ func (r *CommandRegistry) Resolve(ctx, name string) (CommandEntry, bool) {
 	return CommandEntry{}, false
 }
 
+// ResolveCommand finds a command by exact name or alias, falling back to a unique
+// prefix. Failures are reported as *ResolutionError.
+func (r *CommandRegistry) ResolveCommand(ctx, name string) (CommandEntry, error) {
+	r.materialize()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	commands := r.commands[ctx]
+	if entry, ok := commands[name]; ok {
+		return entry, nil
+	}
+	var match CommandEntry
+	var candidates []string
+	seen := map[string]bool{}
+	for key, entry := range commands {
+		if !strings.HasPrefix(key, name) || entry.Spec.Hidden || seen[entry.Spec.Name] {
+			continue
+		}
+		seen[entry.Spec.Name] = true
+		match = entry
+		candidates = append(candidates, entry.Spec.Name)
+	}
+	switch len(candidates) {
+	case 0:
+		return CommandEntry{}, unknownCommandError(ctx, name)
+	case 1:
+		return match, nil
+	default:
+		sort.Strings(candidates)
+		return CommandEntry{}, &ResolutionError{Kind: ErrAmbiguousCommand, Name: name, Context: ctx, Candidates: candidates}
+	}
+}
+
 // Commands returns command names for a context.
 func (r *CommandRegistry) Commands(ctx string, includeHidden bool) []CommandSpec {
+	r.materialize()
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	entries := r.commands[ctx]
@@ -155,10 +279,20 @@ func (r *CommandRegistry) LoadPlugins(dir string) error {
 		if err := fn(r); err != nil {
 			return fmt.Errorf("plugin %s registration failed: %w", path, err)
 		}
+		r.mu.Lock()
+		r.plugins = append(r.plugins, path)
+		r.mu.Unlock()
 	}
 	return nil
 }
 
+// Plugins lists the paths of plugins loaded so far.
+func (r *CommandRegistry) Plugins() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.plugins...)
+}
+
 // CommandRegistryWriter exposes safe registration subset for plugins.
 type CommandRegistryWriter interface {
 	RegisterContext(spec ContextSpec)
@@ -184,6 +318,7 @@ func (r *CommandRegistry) ResolveContextName(name string) (string, bool) {
 
 // NamespaceCommands returns commands across contexts matching prefix.
 func (r *CommandRegistry) NamespaceCommands(namespace string) []CommandSpec {
+	r.materialize()
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	var specs []CommandSpec
@@ -0,0 +1,66 @@
+package tui
+
+import "strings"
+
+// HintProvider suggests remediation for failed commands. Providers are
+// consulted for every result that carries an error, so remediation advice such
+// as "try 'connections reconnect edge-1'" lives in one place rather than in
+// each command. Return nil when the failure is not one the provider knows.
+type HintProvider interface {
+	Hints(rt CommandRuntime, input CommandInput, entry CommandEntry, result CommandResult) []string
+}
+
+// HintProviderFunc adapts a function into a HintProvider.
+type HintProviderFunc func(rt CommandRuntime, input CommandInput, entry CommandEntry, result CommandResult) []string
+
+// Hints implements HintProvider.
+func (f HintProviderFunc) Hints(rt CommandRuntime, input CommandInput, entry CommandEntry, result CommandResult) []string {
+	return f(rt, input, entry, result)
+}
+
+// CodeHints is a HintProvider that maps error codes, see CommandError.ErrCode,
+// to hints.
+type CodeHints map[string][]string
+
+// Hints implements HintProvider.
+func (h CodeHints) Hints(_ CommandRuntime, _ CommandInput, _ CommandEntry, result CommandResult) []string {
+	return h[result.Error.Code()]
+}
+
+// WithHintProvider registers a provider whose hints are appended to failed
+// results, after the command's own. Providers run in registration order.
+func WithHintProvider(p HintProvider) Option {
+	return func(e *Engine) {
+		if p != nil {
+			e.hintProviders = append(e.hintProviders, p)
+		}
+	}
+}
+
+// withProvidedHints returns result with the providers' hints appended to its
+// error, skipping duplicates. The command's CommandError is copied, not changed.
+func (e *Engine) withProvidedHints(rt CommandRuntime, input CommandInput, entry CommandEntry, result CommandResult) CommandResult {
+	if result.Error == nil || len(e.hintProviders) == 0 {
+		return result
+	}
+	seen := map[string]bool{}
+	for _, hint := range result.Error.Hints {
+		seen[hint] = true
+	}
+	var extra []string
+	for _, p := range e.hintProviders {
+		for _, hint := range p.Hints(rt, input, entry, result) {
+			if hint = strings.TrimSpace(hint); hint != "" && !seen[hint] {
+				seen[hint] = true
+				extra = append(extra, hint)
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return result
+	}
+	failure := *result.Error
+	failure.Hints = append(append([]string(nil), failure.Hints...), extra...)
+	result.Error = &failure
+	return result
+}
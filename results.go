@@ -0,0 +1,252 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultResultHistorySize bounds the per-session result history unless overridden.
+const DefaultResultHistorySize = 100
+
+// ResultRecord captures a completed command's outcome for later inspection.
+type ResultRecord struct {
+	Index    int
+	Command  string
+	Line     string
+	Status   CommandStatus
+	Payload  any
+	Error    *CommandError
+	Summary  *ResultSummary
+	Duration time.Duration
+	Time     time.Time
+	// InvocationID correlates the record with the run's logs and tasks.
+	InvocationID string
+}
+
+// ResultHistory is a bounded, index-addressable log of command results.
+// Indices increase monotonically and stay stable as old entries are evicted.
+type ResultHistory struct {
+	mu      sync.RWMutex
+	limit   int
+	next    int
+	records []ResultRecord
+	// With a budget, sizes holds each record's estimated payload size.
+	budget int64
+	total  int64
+	sizes  []int64
+}
+
+// NewResultHistory constructs a history retaining at most limit records.
+func NewResultHistory(limit int) *ResultHistory {
+	if limit <= 0 {
+		limit = DefaultResultHistorySize
+	}
+	return &ResultHistory{limit: limit, next: 1}
+}
+
+// Record appends rec, assigning and returning its index.
+func (h *ResultHistory) Record(rec ResultRecord) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rec.Index = h.next
+	h.next++
+	h.records = append(h.records, rec)
+	if h.budget > 0 {
+		size := estimateSize(rec.Payload)
+		h.sizes = append(h.sizes, size)
+		h.total += size
+	}
+	h.evict(max(len(h.records)-h.limit, 0))
+	return rec.Index
+}
+
+// evict drops the n oldest records, and more while over budget, always
+// keeping the latest.
+func (h *ResultHistory) evict(n int) {
+	if h.budget > 0 {
+		for _, size := range h.sizes[:n] {
+			h.total -= size
+		}
+		for n < len(h.records)-1 && h.total > h.budget {
+			h.total -= h.sizes[n]
+			n++
+		}
+		if n > 0 {
+			h.sizes = append([]int64(nil), h.sizes[n:]...)
+		}
+	}
+	if n > 0 {
+		h.records = append([]ResultRecord(nil), h.records[n:]...)
+	}
+}
+
+// SetBudget bounds the estimated size of the retained payloads, evicting the
+// oldest records, but never the latest, when it is exceeded. Zero removes the
+// bound.
+func (h *ResultHistory) SetBudget(bytes int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.budget, h.total, h.sizes = bytes, 0, nil
+	if bytes <= 0 {
+		return
+	}
+	for _, rec := range h.records {
+		size := estimateSize(rec.Payload)
+		h.sizes = append(h.sizes, size)
+		h.total += size
+	}
+	h.evict(0)
+}
+
+// Size estimates the memory held by the retained payloads.
+func (h *ResultHistory) Size() int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.budget > 0 {
+		return h.total
+	}
+	var total int64
+	for _, rec := range h.records {
+		total += estimateSize(rec.Payload)
+	}
+	return total
+}
+
+// Get returns the record with the given index.
+func (h *ResultHistory) Get(index int) (ResultRecord, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, rec := range h.records {
+		if rec.Index == index {
+			return rec, true
+		}
+	}
+	return ResultRecord{}, false
+}
+
+// Last returns the most recent record.
+func (h *ResultHistory) Last() (ResultRecord, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.records) == 0 {
+		return ResultRecord{}, false
+	}
+	return h.records[len(h.records)-1], true
+}
+
+// Records lists retained records, oldest first.
+func (h *ResultHistory) Records() []ResultRecord {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]ResultRecord(nil), h.records...)
+}
+
+// WithResultHistory sets how many results each session retains.
+func WithResultHistory(limit int) Option {
+	return func(e *Engine) { e.resultLimit = limit }
+}
+
+// result command --------------------------------------------------------------
+
+type resultCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *resultCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "result",
+			Summary:     "Show a previous command's result",
+			Description: "Re-renders or exports the structured payload of an earlier command without re-executing it. Without an index, lists the retained results.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "n", Type: ArgTypeInt, Description: "Result index"},
+			},
+			Flags: []FlagSpec{
+				{Name: "output", Shorthand: "o", Type: ArgTypeEnum, EnumValues: []string{"text", "json", "yaml", "csv"}, Default: "text", Description: "Output format"},
+			},
+			Examples: []Example{
+				{Description: "List recent results", Command: "result"},
+				{Description: "Export result 3 as JSON", Command: "result 3 --output json"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *resultCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &resultCommand{spec: f.Spec()}, nil
+}
+
+type resultCommand struct {
+	spec CommandSpec
+}
+
+func (c *resultCommand) Spec() CommandSpec { return c.spec }
+
+func (c *resultCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "result requires an engine session", Severity: SeverityError}}
+	}
+	history := session.Results()
+	if _, ok := input.Args.Raw("n"); !ok {
+		records := history.Records()
+		if len(records) == 0 {
+			rt.Output().Info("No results recorded.")
+			return CommandResult{Status: StatusSuccess}
+		}
+		rows := make([][]string, 0, len(records))
+		for _, rec := range records {
+			rows = append(rows, []string{fmt.Sprint(rec.Index), rec.Line, string(rec.Status), rec.Duration.Round(time.Microsecond).String(), payloadKind(rec.Payload)})
+		}
+		rt.Output().WriteTable([]string{"#", "Command", "Status", "Duration", "Payload"}, rows)
+		return CommandResult{Status: StatusSuccess}
+	}
+
+	n := input.Args.Int("n")
+	rec, ok := history.Get(n)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("no result with index %d", n), Severity: SeverityError, Hints: []string{"run `result` to list retained results"}}}
+	}
+	if format := input.Flags.String("output"); rec.Payload == nil && rec.Error != nil && (format == "json" || format == "yaml") {
+		rt.Output().SetFormat(OutputFormat(format))
+		renderFormatted(rt.Output(), map[string]any{"error": rec.Error, "ref": rec.InvocationID})
+		return CommandResult{Status: StatusSuccess}
+	}
+	if rec.Payload == nil {
+		rt.Output().Info(fmt.Sprintf("#%d %s: %s (no payload)", rec.Index, rec.Line, rec.Status))
+		return CommandResult{Status: StatusSuccess}
+	}
+	if format := input.Flags.String("output"); format != "text" {
+		rt.Output().SetFormat(OutputFormat(format))
+		renderFormatted(rt.Output(), rec.Payload)
+	} else {
+		renderPayload(rt.Output(), rec.Payload)
+	}
+	return CommandResult{Status: StatusSuccess, Payload: rec.Payload}
+}
+
+// renderPayload writes a payload in its most natural human-readable form.
+func renderPayload(out OutputChannel, v any) {
+	switch p := v.(type) {
+	case PipelineTable:
+		out.WriteTable(p.Headers, p.Rows)
+	case *PipelineTable:
+		out.WriteTable(p.Headers, p.Rows)
+	case string:
+		out.Info(p)
+	case fmt.Stringer:
+		out.Info(p.String())
+	default:
+		out.WriteJSON(v)
+	}
+}
+
+func payloadKind(v any) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%T", v)
+}
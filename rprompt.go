@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/chzyer/readline/runes"
+)
+
+const (
+	// SessionKeyConnection is the session store key of a string describing the
+	// session's backend connection, shown by {{.Connection}} in the right prompt.
+	SessionKeyConnection = "prompt.connection"
+	// SessionKeyReadOnly is the session store key of a bool marking the session
+	// read-only, shown by {{.ReadOnly}} in the right prompt.
+	SessionKeyReadOnly = "prompt.read_only"
+)
+
+// RightPromptData is the data Theme.RightPrompt templates render, e.g.
+//
+//	{{if .ReadOnly}}[ro] {{end}}{{.Tasks}} tasks {{.Time.Format "15:04"}}
+type RightPromptData struct {
+	Time       time.Time
+	Context    string
+	User       string
+	Tasks      int
+	Connection string
+	ReadOnly   bool
+	DryRun     bool
+}
+
+// rightPromptCache holds the parsed Theme.RightPrompt template.
+type rightPromptCache struct {
+	mu   sync.Mutex
+	text string
+	tmpl *template.Template
+	err  error
+}
+
+func (c *rightPromptCache) parse(text string) (*template.Template, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.text != text || (c.tmpl == nil && c.err == nil) {
+		c.text = text
+		c.tmpl, c.err = template.New("rprompt").Parse(text)
+	}
+	return c.tmpl, c.err
+}
+
+// rightPromptData gathers the session state the right prompt can show.
+func (s *Session) rightPromptData() RightPromptData {
+	data := RightPromptData{Time: time.Now(), Context: s.contexts.Current().Spec.Name, DryRun: s.DryRun()}
+	if principal, ok := PrincipalFromSession(s.store); ok {
+		data.User = principal.Name
+	}
+	for _, task := range s.tasks.Tasks() {
+		if task.Status == TaskRunning {
+			data.Tasks++
+		}
+	}
+	if v, ok := s.store.Get(SessionKeyConnection); ok {
+		data.Connection = fmt.Sprint(v)
+	}
+	if v, ok := s.store.Get(SessionKeyReadOnly); ok {
+		data.ReadOnly, _ = v.(bool)
+	}
+	return data
+}
+
+// RightPrompt renders the theme's right prompt segment for the session, or ""
+// when none is configured.
+func (s *Session) RightPrompt() string {
+	theme := s.engine.Theme()
+	if theme.RightPrompt == "" {
+		return ""
+	}
+	tmpl, err := s.engine.rightPrompt.parse(theme.RightPrompt)
+	if err != nil {
+		return "[rprompt: " + err.Error() + "]"
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, s.rightPromptData()); err != nil {
+		return "[rprompt: " + err.Error() + "]"
+	}
+	return strings.TrimSpace(strings.ReplaceAll(buf.String(), "\n", " "))
+}
+
+// paintRightPrompt appends the right prompt to painted, drawn at the right edge
+// of the terminal between save/restore cursor sequences. inputWidth is the
+// width painted after the prompt; like zsh's RPROMPT, the segment is dropped
+// once the input would reach it.
+func (s *Session) paintRightPrompt(painted []rune, inputWidth int) []rune {
+	segment := s.RightPrompt()
+	if segment == "" {
+		return painted
+	}
+	width := readline.GetScreenWidth()
+	segWidth := runes.WidthAll(runes.ColorFilter([]rune(segment)))
+	used := runes.WidthAll(runes.ColorFilter([]rune(s.promptString()))) + inputWidth
+	if width <= 0 || used+segWidth+2 > width {
+		return painted
+	}
+	segment = Colorize(segment, s.engine.Theme().RightPromptColor)
+	out := make([]rune, 0, len(painted)+len(segment)+16)
+	out = append(out, painted...)
+	return append(out, []rune(fmt.Sprintf("\x1b7\x1b[%dG%s\x1b8", width-segWidth+1, segment))...)
+}
@@ -0,0 +1,28 @@
+package tui
+
+import "context"
+
+// Child implements CommandRuntime. The returned func also flushes a trailing
+// partial line of the child's output.
+func (r *executionRuntime) Child(name string) (CommandRuntime, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	echo := &prefixWriter{emit: echoLine(r.output), prefix: "[" + name + "] "}
+	owner := name
+	if r.owner != "" {
+		owner = r.owner + "/" + name
+	}
+	child := &executionRuntime{
+		session:  r.session,
+		ctx:      ctx,
+		cancel:   cancel,
+		output:   derivedOutput(echo, r.output),
+		pipeline: r.pipeline,
+		meta:     r.meta,
+		tasks:    r.TaskManager().scoped(owner, r.meta.ID),
+		owner:    owner,
+	}
+	return child, func() {
+		cancel()
+		echo.Flush()
+	}
+}
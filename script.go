@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	scriptAssignment = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+	scriptSetOptions = regexp.MustCompile(`^[-+][eu]+$`)
+)
+
+// scriptState holds the options and variables of a running script.
+type scriptState struct {
+	// errexit stops the script at the first failing line (set -e); nounset
+	// makes expanding an undefined variable an error (set -u).
+	errexit bool
+	nounset bool
+	vars    map[string]string
+	session *Session
+}
+
+// RunScript executes the command lines read from r without prompting, skipping
+// blank lines and lines starting with #. Errors are reported as in the
+// interactive loop, and the exit code of the last line is returned.
+//
+// Scripts may assign variables with NAME=value lines and use them as $NAME,
+// ${NAME}, or ${NAME:-default}; names not assigned are looked up in the session
+// variables, then the environment, and \$ keeps a dollar sign literal. A
+// `set -e` line stops the script at the first failing line and `set -u` makes
+// undefined variables an error that stops it; `set +e` and `set +u` turn them off again.
+func (s *Session) RunScript(r io.Reader) (int, error) {
+	codes := s.engine.exitCodes
+	s.scripting.Add(1)
+	defer s.scripting.Add(-1)
+	s.setExitCode(codes.Success)
+	state := &scriptState{vars: map[string]string{}, session: s}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if state.directive(line) {
+			continue
+		}
+		if m := scriptAssignment.FindStringSubmatch(line); m != nil {
+			value, err := state.split(m[2])
+			if err != nil {
+				s.reportError(fmt.Errorf("line %d: %w", n, err))
+				s.setExitCode(codes.Failure)
+				return codes.Failure, nil
+			}
+			state.vars[m[1]] = strings.Join(words(value), " ")
+			continue
+		}
+		tokens, err := state.split(line)
+		if err != nil {
+			s.reportError(fmt.Errorf("line %d: %w", n, err))
+			s.setExitCode(codes.Failure)
+			return codes.Failure, nil
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+		if exitRequested(tokens[0].text) {
+			break
+		}
+		if err := s.dispatch(tokens); err != nil {
+			s.reportError(err)
+		}
+		if state.errexit && s.ExitCode() != codes.Success {
+			return s.ExitCode(), nil
+		}
+	}
+	return s.ExitCode(), scanner.Err()
+}
+
+// RunScript runs a script on the default session; see Session.RunScript.
+func (e *Engine) RunScript(r io.Reader) (int, error) {
+	return e.defaultSession.RunScript(r)
+}
+
+// directive applies a `set -e`, `set -u`, `set -eu`, or `set +e` style line,
+// reporting whether line was one.
+func (st *scriptState) directive(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "set" {
+		return false
+	}
+	for _, opt := range fields[1:] {
+		if !scriptSetOptions.MatchString(opt) {
+			return false
+		}
+	}
+	for _, opt := range fields[1:] {
+		on := opt[0] == '-'
+		for _, flag := range opt[1:] {
+			switch flag {
+			case 'e':
+				st.errexit = on
+			case 'u':
+				st.nounset = on
+			}
+		}
+	}
+	return true
+}
+
+// split lexes line, expanding $NAME, ${NAME}, ${NAME:-default}, and result
+// references within the words they appear in.
+func (st *scriptState) split(line string) ([]token, error) {
+	tokens, _, err := lexExpanded(line, func(rest string) (string, int, error) {
+		return expandRef(rest, st.lookup, st.session.resolveResultRef, st.nounset)
+	})
+	return tokens, err
+}
+
+func (st *scriptState) lookup(name string) (string, bool) {
+	if v, ok := st.vars[name]; ok {
+		return v, true
+	}
+	return st.session.lookupVar(name)
+}
+
+// scriptVarName parses the name after a $, returning it and how many bytes it
+// spans (including braces), or zero width when no name follows.
+func scriptVarName(rest string) (string, int) {
+	if strings.HasPrefix(rest, "{") {
+		end := strings.IndexByte(rest, '}')
+		if end < 2 {
+			return "", 0
+		}
+		return rest[1:end], end + 1
+	}
+	n := 0
+	for n < len(rest) {
+		c := rest[n]
+		if c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || n > 0 && c >= '0' && c <= '9' {
+			n++
+			continue
+		}
+		break
+	}
+	return rest[:n], n
+}
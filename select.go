@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// SelectOption is one choice offered by Select.
+type SelectOption struct {
+	Label       string
+	Value       any
+	Description string
+}
+
+// Select lists options under title and asks the operator to pick one by
+// number, label, or unique label prefix, re-asking until the answer matches.
+// Under readline, Up/Down cycle through the labels and Tab completes them.
+// A single option is returned without asking.
+func (s *Session) Select(out OutputChannel, title string, options []SelectOption) (SelectOption, error) {
+	switch len(options) {
+	case 0:
+		return SelectOption{}, errors.New("no options to choose from")
+	case 1:
+		return options[0], nil
+	}
+	labels := make([]string, len(options))
+	for i, opt := range options {
+		labels[i] = opt.Label
+	}
+	if title != "" {
+		out.Info(title)
+	}
+	for i, opt := range options {
+		line := fmt.Sprintf("  %d) %s", i+1, opt.Label)
+		if opt.Description != "" {
+			line += "  - " + opt.Description
+		}
+		out.Info(line)
+	}
+	defer s.offerChoices(labels)()
+	for {
+		answer, err := s.Ask(fmt.Sprintf("choice [1-%d]: ", len(options)))
+		if err != nil {
+			return SelectOption{}, err
+		}
+		i, err := matchChoice(labels, answer)
+		if err != nil {
+			out.Error(err.Error())
+			continue
+		}
+		return options[i], nil
+	}
+}
+
+// matchChoice resolves an answer to an option index.
+func matchChoice(labels []string, answer string) (int, error) {
+	if answer == "" {
+		return 0, errors.New("a choice is required")
+	}
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n < 1 || n > len(labels) {
+			return 0, fmt.Errorf("choose a number from 1 to %d", len(labels))
+		}
+		return n - 1, nil
+	}
+	match := -1
+	for i, label := range labels {
+		if strings.EqualFold(label, answer) {
+			return i, nil
+		}
+		if strings.HasPrefix(strings.ToLower(label), strings.ToLower(answer)) {
+			if match >= 0 {
+				return 0, fmt.Errorf("%q matches more than one option", answer)
+			}
+			match = i
+		}
+	}
+	if match < 0 {
+		return 0, fmt.Errorf("%q matches no option", answer)
+	}
+	return match, nil
+}
+
+// offerChoices makes labels the target of cursor keys and completion while a
+// Select is asking, returning the func that withdraws them.
+func (s *Session) offerChoices(labels []string) func() {
+	s.mu.Lock()
+	s.choices, s.choice = labels, -1
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		s.choices = nil
+		s.mu.Unlock()
+	}
+}
+
+// cycleChoice steps the highlighted choice by delta, reporting false when no
+// Select is active.
+func (s *Session) cycleChoice(delta int) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.choices)
+	if n == 0 {
+		return "", false
+	}
+	if s.choice < 0 && delta < 0 {
+		s.choice = 0
+	}
+	s.choice = ((s.choice+delta)%n + n) % n
+	return s.choices[s.choice], true
+}
+
+// activeChoices returns the labels of the running Select, if any.
+func (s *Session) activeChoices() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.choices
+}
+
+// choiceFilter wraps rl's input filter so Up/Down walk the options of an
+// active Select instead of the history.
+func (s *Session) choiceFilter(rl *readline.Instance) func(rune) (rune, bool) {
+	next := rl.Config.FuncFilterInputRune
+	return func(r rune) (rune, bool) {
+		delta := 0
+		switch r {
+		case readline.CharPrev:
+			delta = -1
+		case readline.CharNext:
+			delta = 1
+		}
+		if delta != 0 {
+			if label, ok := s.cycleChoice(delta); ok {
+				rl.Operation.SetBuffer(label)
+				return r, false
+			}
+		}
+		if next != nil {
+			return next(r)
+		}
+		return r, true
+	}
+}
@@ -1,6 +1,253 @@
 package tui
 
-import "sync"
+import (
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Session holds per-operator state: context stack, session store, tasks, and output settings.
+// Many sessions can share one Engine, each driven by its own front-end connection.
+type Session struct {
+	id          string
+	engine      *Engine
+	contexts    *ContextManager
+	store       SessionStore
+	tasks       *TaskManager
+	output      *swapWriter
+	outputLevel OutputLevel
+	active      map[*DefaultOutputChannel]struct{}
+	queue       *commandQueue
+	results     *ResultHistory
+	exit        exitState
+	payloads    *PayloadStore
+	undo        *UndoStack
+	input       func(prompt string) (string, error)
+	secret      func(prompt string) (string, error)
+	notice      func(message string)
+	acked       map[string]time.Time
+	// completionCache holds dynamic completion results; see WithCompletionCacheTTL.
+	completionCache completionCache
+	running         runningInvocations
+	jobs            jobTable
+	source          InvocationSource
+	clientAddr      string
+	// scripting counts the RunScript calls in progress.
+	scripting atomic.Int32
+	dryRun    atomic.Bool
+	vars      sessionVars
+	changes   changeSets
+
+	// choices are the labels of the Select awaiting an answer; choice is the one last shown.
+	choices []string
+	choice  int
+
+	advisoryStarted bool
+	advisoryCtx     string
+	advisoryShown   map[string]bool
+
+	mu sync.RWMutex
+}
+
+// SessionOption configures a Session at creation.
+type SessionOption func(*Session)
+
+// WithSessionID overrides the generated session identifier.
+func WithSessionID(id string) SessionOption {
+	return func(s *Session) {
+		if id != "" {
+			s.id = id
+		}
+	}
+}
+
+// WithSessionOutput directs session output to w.
+func WithSessionOutput(w io.Writer) SessionOption {
+	return func(s *Session) {
+		if w != nil {
+			s.output.Swap(w)
+		}
+	}
+}
+
+// WithSessionOutputLevel sets the session's default verbosity.
+func WithSessionOutputLevel(level OutputLevel) SessionOption {
+	return func(s *Session) { s.outputLevel = level }
+}
+
+// WithSessionStore replaces the session's key/value store.
+func WithSessionStore(store SessionStore) SessionOption {
+	return func(s *Session) {
+		if store != nil {
+			s.store = store
+		}
+	}
+}
+
+// ID returns the session identifier.
+func (s *Session) ID() string { return s.id }
+
+// Engine returns the engine hosting the session.
+func (s *Session) Engine() *Engine { return s.engine }
+
+// Contexts returns the session's context manager.
+func (s *Session) Contexts() *ContextManager { return s.contexts }
+
+// Store returns the session's key/value store.
+func (s *Session) Store() SessionStore { return s.store }
+
+// Tasks returns the session's task manager.
+func (s *Session) Tasks() *TaskManager { return s.tasks }
+
+// Results returns the session's command result history.
+func (s *Session) Results() *ResultHistory { return s.results }
+
+// OutputWriter returns the writer used for command output. Writes always reach
+// the current destination, even across SetOutputWriter calls.
+func (s *Session) OutputWriter() io.Writer { return s.output }
+
+// SetOutputWriter atomically swaps the session's destination, returning the previous one.
+// In-flight commands and running tasks continue writing to the new destination.
+func (s *Session) SetOutputWriter(w io.Writer) io.Writer {
+	if w == nil {
+		w = os.Stdout
+	}
+	return s.output.Swap(w)
+}
+
+// OutputLevel returns the session's verbosity.
+func (s *Session) OutputLevel() OutputLevel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.outputLevel
+}
+
+// SetOutputLevel updates the session's verbosity, including for in-flight commands.
+func (s *Session) SetOutputLevel(level OutputLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outputLevel = level
+	for ch := range s.active {
+		ch.SetLevel(level)
+	}
+}
+
+// trackOutput registers an invocation's channel for live level updates, returning its release func.
+func (s *Session) trackOutput(ch *DefaultOutputChannel) func() {
+	s.mu.Lock()
+	ch.SetLevel(s.outputLevel)
+	s.active[ch] = struct{}{}
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		delete(s.active, ch)
+		s.mu.Unlock()
+	}
+}
+
+// ErrNoInteractiveInput is returned by Ask and Confirm when the session has no operator attached.
+var ErrNoInteractiveInput = errors.New("no interactive input available")
+
+// attachInput sets the line source used by Ask while a front-end loop runs, returning its detach func.
+func (s *Session) attachInput(fn func(prompt string) (string, error)) func() {
+	s.mu.Lock()
+	s.input = fn
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		s.input = nil
+		s.mu.Unlock()
+	}
+}
+
+// attachSecretInput sets the hidden-input source used by AskSecret, returning its detach func.
+func (s *Session) attachSecretInput(fn func(prompt string) (string, error)) func() {
+	s.mu.Lock()
+	s.secret = fn
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		s.secret = nil
+		s.mu.Unlock()
+	}
+}
+
+// AskSecret prompts for input without echoing it when the front end supports
+// hidden input, and falls back to Ask otherwise.
+func (s *Session) AskSecret(prompt string) (string, error) {
+	s.mu.RLock()
+	secret := s.secret
+	s.mu.RUnlock()
+	if secret == nil {
+		return s.Ask(prompt)
+	}
+	return secret(prompt)
+}
+
+// PromptSecret asks for a credential labelled label. The answer is read without
+// echo where the front end allows it and never enters the command history.
+func (s *Session) PromptSecret(label string) (string, error) {
+	prompt := strings.TrimSpace(label)
+	if !strings.HasSuffix(prompt, ":") {
+		prompt += ":"
+	}
+	return s.AskSecret(prompt + " ")
+}
+
+// Ask prompts the operator for a line of input from within a running command.
+func (s *Session) Ask(prompt string) (string, error) {
+	s.mu.RLock()
+	input := s.input
+	s.mu.RUnlock()
+	if input == nil {
+		return "", ErrNoInteractiveInput
+	}
+	line, err := input(prompt)
+	return strings.TrimSpace(line), err
+}
+
+// Confirm asks a yes/no question, returning true only for an explicit yes.
+func (s *Session) Confirm(question string) (bool, error) {
+	answer, err := s.Ask(question + " [y/N]: ")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// Execute processes a single input line as if typed at the prompt. Lines are
+// executed one at a time per session; concurrent callers queue in FIFO order.
+// Execute must not be called from within a command running on the same session.
+func (s *Session) Execute(line string) error {
+	tokens, _, err := lexTokens(line)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	return s.dispatch(tokens)
+}
+
+// Close cancels the session's tasks and detaches it from the engine.
+func (s *Session) Close() {
+	for _, task := range s.tasks.Tasks() {
+		if task.Status == TaskPending || task.Status == TaskRunning {
+			s.tasks.Cancel(task.ID)
+		}
+	}
+	s.engine.removeSession(s.id)
+}
 
 // SessionStore provides shared state across commands during a session.
 type SessionStore interface {
@@ -14,6 +261,11 @@ type SessionStore interface {
 type MemorySessionStore struct {
 	mu   sync.RWMutex
 	data map[string]any
+	// With a budget, sizes and order (oldest write first) track values for eviction.
+	budget int64
+	total  int64
+	sizes  map[string]int64
+	order  []string
 }
 
 // NewSessionStore constructs a MemorySessionStore.
@@ -34,6 +286,10 @@ func (s *MemorySessionStore) Set(key string, value any) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.data[key] = value
+	if s.budget > 0 {
+		s.track(key, value)
+		s.evict()
+	}
 }
 
 // Delete removes a key.
@@ -41,6 +297,77 @@ func (s *MemorySessionStore) Delete(key string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.data, key)
+	s.untrack(key)
+}
+
+// SetBudget bounds the estimated size of the stored values, evicting the
+// oldest written keys, except the engine's own, when it is exceeded. Zero
+// removes the bound.
+func (s *MemorySessionStore) SetBudget(bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budget, s.total, s.sizes, s.order = bytes, 0, nil, nil
+	if bytes <= 0 {
+		return
+	}
+	s.sizes = map[string]int64{}
+	for _, key := range sortedKeys(s.data) {
+		s.track(key, s.data[key])
+	}
+	s.evict()
+}
+
+// Size estimates the memory held by the stored values.
+func (s *MemorySessionStore) Size() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.budget > 0 {
+		return s.total
+	}
+	var total int64
+	for _, v := range s.data {
+		total += estimateSize(v)
+	}
+	return total
+}
+
+func (s *MemorySessionStore) track(key string, value any) {
+	s.untrack(key)
+	size := estimateSize(value)
+	s.sizes[key] = size
+	s.total += size
+	s.order = append(s.order, key)
+}
+
+func (s *MemorySessionStore) untrack(key string) {
+	size, ok := s.sizes[key]
+	if !ok {
+		return
+	}
+	delete(s.sizes, key)
+	s.total -= size
+	s.order = slices.DeleteFunc(s.order, func(k string) bool { return k == key })
+}
+
+func (s *MemorySessionStore) evict() {
+	for i := 0; s.total > s.budget && i < len(s.order); {
+		key := s.order[i]
+		if slices.Contains(pinnedSessionKeys, key) {
+			i++
+			continue
+		}
+		delete(s.data, key)
+		s.untrack(key)
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // Keys lists stored keys.
@@ -85,3 +412,15 @@ func (r *SimpleServiceRegistry) Get(name string) (any, bool) {
 	val, ok := r.data[name]
 	return val, ok
 }
+
+// Names lists registered service names in sorted order.
+func (r *SimpleServiceRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.data))
+	for name := range r.data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
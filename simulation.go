@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// TagMutating marks a command, through CommandSpec.Tags, that changes state.
+// In simulation mode its output is marked as simulated; commands tagged
+// TagRequiresApproval are treated the same way.
+const TagMutating = "mutating"
+
+// simulationRegistry resolves services to mocks, falling back to the real
+// registry only for the services named as passthrough, so a simulated shell
+// never reaches a real device by accident.
+type simulationRegistry struct {
+	real        ServiceRegistry
+	mocks       *SimpleServiceRegistry
+	passthrough []string
+}
+
+// Register stores a real service, so options applied after WithSimulation
+// behave as without it; only passthrough services are reachable.
+func (r *simulationRegistry) Register(name string, value any) {
+	r.real.Register(name, value)
+}
+
+// Get returns the mock registered under name, or the real service when name
+// is a passthrough.
+func (r *simulationRegistry) Get(name string) (any, bool) {
+	if mock, ok := r.mocks.Get(name); ok {
+		return mock, true
+	}
+	if slices.Contains(r.passthrough, name) {
+		return r.real.Get(name)
+	}
+	return nil, false
+}
+
+// Names lists the mocked and passthrough service names in sorted order.
+func (r *simulationRegistry) Names() []string {
+	names := r.mocks.Names()
+	for _, name := range r.passthrough {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithSimulation runs the engine in simulation mode for operator training:
+// register adds mock implementations, which service lookups resolve to in
+// place of the services from WithServices. Real services stay unreachable
+// except those named in passthrough, e.g. formatters with no side effects.
+// Mutating commands are marked as simulated and the prompt shows the mode.
+func WithSimulation(register func(ServiceRegistry), passthrough ...string) Option {
+	return func(e *Engine) {
+		sim := &simulationRegistry{real: e.services, mocks: NewServiceRegistry(), passthrough: passthrough}
+		if register != nil {
+			register(sim.mocks)
+		}
+		e.services = sim
+		e.middleware = append(e.middleware, SimulationMiddleware)
+	}
+}
+
+// Simulated reports whether the engine runs in simulation mode.
+func (e *Engine) Simulated() bool {
+	_, ok := e.services.(*simulationRegistry)
+	return ok
+}
+
+// SimulationMiddleware marks the output of mutating commands, those tagged
+// TagMutating or TagRequiresApproval, as simulated. WithSimulation installs it.
+func SimulationMiddleware(rt CommandRuntime, input CommandInput, entry CommandEntry, next NextFunc) CommandResult {
+	if !slices.Contains(entry.Spec.Tags, TagMutating) && !slices.Contains(entry.Spec.Tags, TagRequiresApproval) {
+		return next(rt, input)
+	}
+	rt.Output().Warn(fmt.Sprintf("simulation: %s runs against mock backends; nothing real is changed", entry.Spec.Name))
+	result := next(rt, input)
+	if result.Summary != nil {
+		summary := *result.Summary
+		if summary.Note == "" {
+			summary.Note = "simulated"
+		} else {
+			summary.Note = "simulated: " + summary.Note
+		}
+		result.Summary = &summary
+	}
+	return result
+}
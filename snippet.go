@@ -0,0 +1,287 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// snippetPlaceholder matches named placeholders such as `{device}`.
+var snippetPlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// Snippet is a named, parameterised sequence of command lines.
+type Snippet struct {
+	Name  string
+	Lines []string
+}
+
+// Params lists the snippet's placeholders in order of first appearance.
+func (s Snippet) Params() []string {
+	var params []string
+	seen := map[string]bool{}
+	for _, line := range s.Lines {
+		for _, m := range snippetPlaceholder.FindAllStringSubmatch(line, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				params = append(params, m[1])
+			}
+		}
+	}
+	return params
+}
+
+// Expand substitutes values for the snippet's placeholders, in Params order.
+func (s Snippet) Expand(values []string) ([]string, error) {
+	params := s.Params()
+	if len(values) != len(params) {
+		return nil, fmt.Errorf("snippet %s expects %d argument(s) (%s), got %d", s.Name, len(params), strings.Join(params, ", "), len(values))
+	}
+	bind := make(map[string]string, len(params))
+	for i, name := range params {
+		bind[name] = values[i]
+	}
+	lines := make([]string, len(s.Lines))
+	for i, line := range s.Lines {
+		lines[i] = snippetPlaceholder.ReplaceAllStringFunc(line, func(m string) string {
+			return bind[m[1:len(m)-1]]
+		})
+	}
+	return lines, nil
+}
+
+// SnippetLibrary stores snippets shared by every session of an engine.
+type SnippetLibrary struct {
+	mu       sync.RWMutex
+	snippets map[string]Snippet
+}
+
+// NewSnippetLibrary constructs an empty library.
+func NewSnippetLibrary() *SnippetLibrary {
+	return &SnippetLibrary{snippets: map[string]Snippet{}}
+}
+
+// Save adds or replaces a snippet.
+func (l *SnippetLibrary) Save(s Snippet) error {
+	if s.Name == "" {
+		return errors.New("snippet name is required")
+	}
+	if len(s.Lines) == 0 {
+		return fmt.Errorf("snippet %s has no commands", s.Name)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snippets[s.Name] = Snippet{Name: s.Name, Lines: append([]string(nil), s.Lines...)}
+	return nil
+}
+
+// Get returns a snippet by name.
+func (l *SnippetLibrary) Get(name string) (Snippet, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	s, ok := l.snippets[name]
+	return s, ok
+}
+
+// Delete removes a snippet, reporting whether it existed.
+func (l *SnippetLibrary) Delete(name string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.snippets[name]
+	delete(l.snippets, name)
+	return ok
+}
+
+// List returns snippets sorted by name.
+func (l *SnippetLibrary) List() []Snippet {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	list := make([]Snippet, 0, len(l.snippets))
+	for _, s := range l.snippets {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// WithSnippet seeds the snippet library, e.g. from configuration.
+func WithSnippet(name string, lines ...string) Option {
+	return func(e *Engine) { _ = e.snippets.Save(Snippet{Name: name, Lines: lines}) }
+}
+
+// Snippets exposes the engine's snippet library.
+func (e *Engine) Snippets() *SnippetLibrary { return e.snippets }
+
+// snippet command -------------------------------------------------------------
+
+type snippetCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *snippetCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "snippet",
+			Summary:     "Save and run named command sequences",
+			Description: "Stores parameterised command sequences. Use {name} placeholders in saved lines; `snippet run` binds arguments to them in order of first appearance and asks for confirmation before executing.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"list", "show", "save", "run", "delete"}, Default: "list", Description: "Action to perform"},
+				{Name: "name", Type: ArgTypeString, Description: "Snippet name"},
+				{Name: "args", Type: ArgTypeString, Repeatable: true, Description: "Placeholder values for run"},
+			},
+			Flags: []FlagSpec{
+				{Name: "last", Type: ArgTypeInt, Description: "Save the last N commands from the result history instead of prompting"},
+				{Name: "yes", Shorthand: "y", Type: ArgTypeBool, Description: "Run without confirmation"},
+			},
+			Examples: []Example{
+				{Description: "Record a sequence interactively", Command: "snippet save drain-device"},
+				{Description: "Run it against a device", Command: "snippet run drain-device edge-1"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *snippetCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &snippetCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type snippetCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *snippetCommand) Spec() CommandSpec { return c.spec }
+
+func (c *snippetCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	library := c.engine.snippets
+	action := input.Args.String("action")
+	name := input.Args.String("name")
+	if action != "list" && name == "" {
+		return snippetFailure(fmt.Errorf("snippet %s requires a name", action))
+	}
+
+	switch action {
+	case "list":
+		snippets := library.List()
+		if len(snippets) == 0 {
+			rt.Output().Info("No snippets saved.")
+			return CommandResult{Status: StatusSuccess}
+		}
+		rows := make([][]string, 0, len(snippets))
+		for _, s := range snippets {
+			rows = append(rows, []string{s.Name, strings.Join(s.Params(), " "), fmt.Sprint(len(s.Lines))})
+		}
+		rt.Output().WriteTable([]string{"Name", "Params", "Commands"}, rows)
+		return CommandResult{Status: StatusSuccess}
+	case "show":
+		s, ok := library.Get(name)
+		if !ok {
+			return snippetFailure(fmt.Errorf("unknown snippet: %s", name))
+		}
+		for i, line := range s.Lines {
+			rt.Output().Info(fmt.Sprintf("%d. %s", i+1, line))
+		}
+		return CommandResult{Status: StatusSuccess, Payload: s}
+	case "delete":
+		if !library.Delete(name) {
+			return snippetFailure(fmt.Errorf("unknown snippet: %s", name))
+		}
+		rt.Output().Info(fmt.Sprintf("Deleted snippet %s", name))
+		return CommandResult{Status: StatusSuccess}
+	}
+
+	session, ok := sessionOf(rt)
+	if !ok {
+		return snippetFailure(errors.New("snippet requires an engine session"))
+	}
+	if action == "save" {
+		return c.save(rt, session, name, input.Flags.Int("last"))
+	}
+	return c.run(rt, session, name, input.Args.Strings("args"), input.Flags.Bool("yes"))
+}
+
+func (c *snippetCommand) save(rt CommandRuntime, session *Session, name string, last int) CommandResult {
+	var lines []string
+	if last > 0 {
+		records := session.Results().Records()
+		if last > len(records) {
+			last = len(records)
+		}
+		for _, rec := range records[len(records)-last:] {
+			lines = append(lines, rec.Line)
+		}
+	} else {
+		rt.Output().Info("Enter commands, one per line; finish with an empty line.")
+		for {
+			line, err := session.Ask(fmt.Sprintf("%s> ", name))
+			if err != nil {
+				return snippetFailure(fmt.Errorf("recording snippet: %w", err))
+			}
+			if line == "" {
+				break
+			}
+			lines = append(lines, line)
+		}
+	}
+	for _, line := range lines {
+		if tokens := tokenize(line); len(tokens) > 0 && tokens[0] == c.spec.Name {
+			return snippetFailure(errors.New("snippets cannot invoke other snippets"))
+		}
+	}
+	s := Snippet{Name: name, Lines: lines}
+	if err := c.engine.snippets.Save(s); err != nil {
+		return snippetFailure(err)
+	}
+	rt.Output().Info(fmt.Sprintf("Saved snippet %s (%d commands)", name, len(lines)))
+	return CommandResult{Status: StatusSuccess, Payload: s}
+}
+
+func (c *snippetCommand) run(rt CommandRuntime, session *Session, name string, args []string, yes bool) CommandResult {
+	s, ok := c.engine.snippets.Get(name)
+	if !ok {
+		return snippetFailure(fmt.Errorf("unknown snippet: %s", name))
+	}
+	lines, err := s.Expand(args)
+	if err != nil {
+		return snippetFailure(err)
+	}
+	if !yes {
+		for i, line := range lines {
+			rt.Output().Info(fmt.Sprintf("%d. %s", i+1, line))
+		}
+		confirmed, err := session.Confirm(fmt.Sprintf("Run %d command(s)?", len(lines)))
+		if err != nil {
+			return snippetFailure(fmt.Errorf("confirmation unavailable (use --yes): %w", err))
+		}
+		if !confirmed {
+			rt.Output().Info("Aborted.")
+			return CommandResult{Status: StatusSuccess}
+		}
+	}
+	for i, line := range lines {
+		if err := rt.Cancellation().Err(); err != nil {
+			return snippetFailure(err)
+		}
+		tokens, _, _ := lexTokens(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		result, err := session.execute(rt.Cancellation(), tokens)
+		if err == nil && result.Status == StatusFailed {
+			err = errors.New("command failed")
+		}
+		if err != nil {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: fmt.Sprintf("snippet %s stopped at step %d (%s): %v", name, i+1, line, err), Severity: SeverityError}}
+		}
+	}
+	return CommandResult{Status: StatusSuccess}
+}
+
+func snippetFailure(err error) CommandResult {
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+}
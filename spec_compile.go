@@ -0,0 +1,83 @@
+package tui
+
+import "strings"
+
+// compiledSpec is the resolved form of a command spec, built once when the
+// command is registered and read-only afterwards.
+type compiledSpec struct {
+	// spec is the declared spec with Usage filled in and the applicable
+	// implicit flags appended; it is what invocations are parsed against.
+	spec  CommandSpec
+	flags flagIndex
+	// flagCandidates completes "--" prefixes with the visible flags.
+	flagCandidates []Candidate
+}
+
+// compileSpec resolves spec. Its Usage must already be set.
+func compileSpec(spec CommandSpec) *compiledSpec {
+	parsed := withImplicitFlags(spec)
+	c := &compiledSpec{spec: parsed, flags: buildFlagIndex(parsed.Flags)}
+	for _, flag := range spec.Flags {
+		if !flag.Hidden {
+			c.flagCandidates = append(c.flagCandidates, Candidate{Value: "--" + flag.Name, Description: flag.Description})
+		}
+	}
+	return c
+}
+
+// resolved returns the compiled spec of e, compiling it on the fly for entries
+// that did not come from a registry.
+func (e CommandEntry) resolved() *compiledSpec {
+	if e.compiled != nil {
+		return e.compiled
+	}
+	spec := e.Spec
+	if spec.Usage == "" {
+		spec.Usage = FormatUsage(spec)
+	}
+	return compileSpec(spec)
+}
+
+// flagIndex looks flags up by long name and by shorthand.
+type flagIndex struct {
+	byName  map[string]FlagSpec
+	byShort map[string]string
+}
+
+func buildFlagIndex(flags []FlagSpec) flagIndex {
+	index := flagIndex{byName: make(map[string]FlagSpec, len(flags)), byShort: map[string]string{}}
+	for _, flag := range flags {
+		index.byName[flag.Name] = flag
+		if _, taken := index.byShort[flag.Shorthand]; flag.Shorthand != "" && !taken {
+			index.byShort[flag.Shorthand] = flag.Name
+		}
+	}
+	return index
+}
+
+func (x flagIndex) lookup(name string) (FlagSpec, bool) {
+	flag, ok := x.byName[name]
+	return flag, ok
+}
+
+func (x flagIndex) resolveShorthand(alias string) (string, bool) {
+	name, ok := x.byShort[alias]
+	return name, ok
+}
+
+// declared reports whether token names one of the flags, in long or shorthand form.
+func (x flagIndex) declared(token string) bool {
+	if strings.HasPrefix(token, "--") {
+		name := strings.TrimPrefix(token, "--")
+		if idx := strings.Index(name, "="); idx >= 0 {
+			name = name[:idx]
+		}
+		_, ok := x.byName[name]
+		return ok && name != ""
+	}
+	if strings.HasPrefix(token, "-") && token != "-" {
+		_, ok := x.resolveShorthand(strings.TrimPrefix(token, "-"))
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// StartupPhase records how long one step of engine construction took.
+type StartupPhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// WithStartupProfile writes a timing breakdown of NewEngine to w, including
+// the time spent registering commands.
+func WithStartupProfile(w io.Writer) Option {
+	return func(e *Engine) { e.startupProfile = w }
+}
+
+// StartupProfile returns the timings recorded while the engine was built.
+func (e *Engine) StartupProfile() []StartupPhase {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]StartupPhase(nil), e.startup...)
+}
+
+func (e *Engine) startupPhase(name string, since time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.startup = append(e.startup, StartupPhase{Name: name, Duration: time.Since(since)})
+}
+
+func (e *Engine) writeStartupProfile() {
+	if e.startupProfile == nil {
+		return
+	}
+	for _, phase := range e.StartupProfile() {
+		fmt.Fprintf(e.startupProfile, "startup: %-16s %s\n", phase.Name, phase.Duration)
+	}
+	stats := e.registry.RegistrationStats()
+	fmt.Fprintf(e.startupProfile, "startup: %-16s %d commands in %s, %d built-ins deferred\n", "registration", stats.Commands, stats.Duration, stats.Deferred)
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HealthChecker is implemented by services that can report their health to
+// the status built-in.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// healthCheckTimeout bounds each service health check.
+const healthCheckTimeout = 2 * time.Second
+
+// ServiceStatus reports the health of one registered service.
+type ServiceStatus struct {
+	Name   string `json:"name"`
+	Health string `json:"health"`
+	Error  string `json:"error,omitempty"`
+}
+
+// EngineStatus is a snapshot of engine health.
+type EngineStatus struct {
+	Uptime       time.Duration   `json:"uptime"`
+	Plugins      []string        `json:"plugins"`
+	Contexts     int             `json:"contexts"`
+	Commands     int             `json:"commands"`
+	Sessions     int             `json:"sessions"`
+	RunningTasks int             `json:"running_tasks"`
+	SessionKeys  int             `json:"session_keys"`
+	Services     []ServiceStatus `json:"services"`
+	// Simulated is set in simulation mode; see WithSimulation.
+	Simulated bool `json:"simulated,omitempty"`
+}
+
+// Status reports engine health. Session store size is taken from s, or the
+// default session when s is nil.
+func (e *Engine) Status(ctx context.Context, s *Session) EngineStatus {
+	if s == nil {
+		s = e.defaultSession
+	}
+	st := EngineStatus{
+		Uptime:    time.Since(e.started),
+		Plugins:   e.registry.Plugins(),
+		Simulated: e.Simulated(),
+	}
+	contexts := []string{""}
+	for _, spec := range e.registry.Contexts(true) {
+		contexts = append(contexts, spec.Name)
+	}
+	st.Contexts = len(contexts) - 1
+	for _, name := range contexts {
+		st.Commands += len(e.registry.Commands(name, true))
+	}
+	sessions := e.Sessions()
+	st.Sessions = len(sessions)
+	for _, sess := range sessions {
+		for _, task := range sess.tasks.Tasks() {
+			if task.Status == TaskRunning {
+				st.RunningTasks++
+			}
+		}
+	}
+	if s != nil {
+		st.SessionKeys = len(s.store.Keys())
+	}
+	if named, ok := e.services.(interface{ Names() []string }); ok {
+		for _, name := range named.Names() {
+			svc, _ := e.services.Get(name)
+			st.Services = append(st.Services, checkService(ctx, name, svc))
+		}
+	}
+	return st
+}
+
+func checkService(ctx context.Context, name string, svc any) ServiceStatus {
+	checker, ok := svc.(HealthChecker)
+	if !ok {
+		return ServiceStatus{Name: name, Health: "unknown"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	if err := checker.Health(ctx); err != nil {
+		return ServiceStatus{Name: name, Health: "unhealthy", Error: err.Error()}
+	}
+	return ServiceStatus{Name: name, Health: "ok"}
+}
+
+// status command implementation ------------------------------------------------
+
+type statusCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *statusCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "status",
+			Summary:     "Show engine health",
+			Description: "Reports uptime, loaded plugins, registered contexts and commands, sessions, running tasks, session store size, and the health of registered services.",
+			Context:     "",
+		}
+	}
+	return f.spec
+}
+
+func (f *statusCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &statusCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type statusCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *statusCommand) Spec() CommandSpec { return c.spec }
+
+func (c *statusCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, _ := sessionOf(rt)
+	st := c.engine.Status(rt.Cancellation(), session)
+	plugins := "none"
+	if len(st.Plugins) > 0 {
+		plugins = strings.Join(st.Plugins, ", ")
+	}
+	rows := [][]string{
+		{"uptime", st.Uptime.Truncate(time.Second).String(), ""},
+		{"plugins", plugins, ""},
+		{"contexts", strconv.Itoa(st.Contexts), ""},
+		{"commands", strconv.Itoa(st.Commands), ""},
+		{"sessions", strconv.Itoa(st.Sessions), ""},
+		{"running tasks", strconv.Itoa(st.RunningTasks), ""},
+		{"session keys", strconv.Itoa(st.SessionKeys), ""},
+	}
+	if st.Simulated {
+		rows = append(rows, []string{"mode", "simulation", "services resolve to mocks"})
+	}
+	for _, svc := range st.Services {
+		rows = append(rows, []string{"service " + svc.Name, svc.Health, svc.Error})
+	}
+	rt.Output().WriteTable([]string{"Item", "Value", "Detail"}, rows)
+	return CommandResult{Status: StatusSuccess, Payload: st}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package tui
+
+import "os"
+
+// suspendSignals is empty where the terminal has no suspend key.
+var suspendSignals []os.Signal
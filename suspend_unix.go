@@ -0,0 +1,12 @@
+//go:build unix
+
+package tui
+
+import (
+	"os"
+	"syscall"
+)
+
+// suspendSignals are the signals the terminal sends for its suspend key,
+// usually Ctrl-Z.
+var suspendSignals = []os.Signal{syscall.SIGTSTP}
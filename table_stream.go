@@ -0,0 +1,197 @@
+package tui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultTableSample is how many rows StreamTable buffers to size columns.
+const DefaultTableSample = 100
+
+// TableStreamOptions tunes StreamTable.
+type TableStreamOptions struct {
+	// Widths fixes column widths; columns without a positive width are sized
+	// from the sample. Longer values are written in full and break alignment.
+	Widths []int
+	// Sample is how many rows are buffered before the header is written
+	// (DefaultTableSample when zero).
+	Sample int
+}
+
+// TableStream receives the rows of a streamed table.
+type TableStream interface {
+	WriteRow(row []string)
+	// Close flushes buffered rows and ends the table.
+	Close()
+}
+
+// StreamTable starts a table whose rows are written as they arrive instead of
+// being buffered to compute column widths. JSON, YAML, and CSV formats stream
+// one record per row.
+func (c *DefaultOutputChannel) StreamTable(headers []string, opts TableStreamOptions) TableStream {
+	streams := multiTableStream{c.streamTable(headers, opts)}
+	c.forward(func(m *DefaultOutputChannel) { streams = append(streams, m.streamTable(headers, opts)) })
+	if len(streams) == 1 {
+		return streams[0]
+	}
+	return streams
+}
+
+func (c *DefaultOutputChannel) streamTable(headers []string, opts TableStreamOptions) TableStream {
+	if len(headers) == 0 {
+		return nopTableStream{}
+	}
+	if opts.Sample <= 0 {
+		opts.Sample = DefaultTableSample
+	}
+	c.mu.Lock()
+	headerColor := c.theme.TableHeader
+	c.mu.Unlock()
+	t := &tableStream{c: c, headers: headers, format: c.Format(), sample: opts.Sample, headerColor: headerColor}
+	t.widths = make([]int, len(headers))
+	sized := true
+	for i := range headers {
+		if i < len(opts.Widths) && opts.Widths[i] > 0 {
+			t.widths[i] = opts.Widths[i]
+			continue
+		}
+		sized = false
+	}
+	switch {
+	case t.format == OutputFormatCSV:
+		t.emit(csvLine(headers))
+	case t.format == OutputFormatTable && sized:
+		t.writeHeader()
+	}
+	return t
+}
+
+type tableStream struct {
+	mu          sync.Mutex
+	c           *DefaultOutputChannel
+	headerColor string
+	headers     []string
+	format      OutputFormat
+	widths      []int
+	sample      int
+	pending     [][]string
+	started     bool
+	rows        int
+	closed      bool
+}
+
+func (t *tableStream) WriteRow(row []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	switch t.format {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(tableRecords(t.headers, [][]string{row})[0], "  ", "  ")
+		if err != nil {
+			t.c.writeError(fmt.Sprintf("failed to encode json: %v", err))
+			return
+		}
+		sep := ",\n  "
+		if t.rows == 0 {
+			sep = "[\n  "
+		}
+		t.emit(sep + string(data))
+	case OutputFormatYAML:
+		text, err := encodeYAML(tableRecords(t.headers, [][]string{row}))
+		if err != nil {
+			t.c.writeError(fmt.Sprintf("failed to encode yaml: %v", err))
+			return
+		}
+		t.emit(text)
+	case OutputFormatCSV:
+		t.emit(csvLine(row))
+	default:
+		if !t.started {
+			t.pending = append(t.pending, row)
+			if len(t.pending) >= t.sample {
+				t.flushSample()
+			}
+			break
+		}
+		t.emit(formatRow(row, t.widths) + "\n")
+	}
+	t.rows++
+}
+
+func (t *tableStream) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	switch t.format {
+	case OutputFormatJSON:
+		if t.rows == 0 {
+			t.emit("[]\n")
+		} else {
+			t.emit("\n]\n")
+		}
+	case OutputFormatYAML:
+		if t.rows == 0 {
+			t.emit("[]\n")
+		}
+	case OutputFormatTable:
+		if !t.started {
+			t.flushSample()
+		}
+	}
+}
+
+// flushSample sizes unset columns from the header and buffered rows, then
+// writes them.
+func (t *tableStream) flushSample() {
+	for i, h := range t.headers {
+		if t.widths[i] > 0 {
+			continue
+		}
+		t.widths[i] = len(strings.TrimSpace(h))
+		for _, row := range t.pending {
+			if i < len(row) && len(row[i]) > t.widths[i] {
+				t.widths[i] = len(row[i])
+			}
+		}
+	}
+	t.writeHeader()
+	var b strings.Builder
+	for _, row := range t.pending {
+		b.WriteString(formatRow(row, t.widths) + "\n")
+	}
+	t.pending = nil
+	if b.Len() > 0 {
+		t.emit(b.String())
+	}
+}
+
+func (t *tableStream) writeHeader() {
+	t.started = true
+	t.emit(Colorize(formatHeader(t.headers, t.widths), t.headerColor) + "\n")
+}
+
+func (t *tableStream) emit(text string) {
+	t.c.emitStructured(text)
+}
+
+// csvLine encodes one CSV record including the trailing newline.
+func csvLine(record []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	_ = w.Write(record)
+	w.Flush()
+	return b.String()
+}
+
+type nopTableStream struct{}
+
+func (nopTableStream) WriteRow([]string) {}
+func (nopTableStream) Close()            {}
@@ -0,0 +1,370 @@
+package tui
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// ServiceKeyTargets is the service registry key of the TargetService.
+	ServiceKeyTargets = "targets"
+	// SessionKeyTargets is the session store key holding the selected []Target.
+	SessionKeyTargets = "targets.selection"
+	// TargetsContext is the context hosting the targets commands.
+	TargetsContext = "targets"
+)
+
+// Target is an inventory entry such as a device or host.
+type Target struct {
+	Name    string            `json:"name"`
+	Address string            `json:"address,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+}
+
+// Field returns a target attribute by name: name, address, or a tag key.
+func (t Target) Field(key string) (string, bool) {
+	switch key {
+	case "name":
+		return t.Name, true
+	case "address":
+		return t.Address, t.Address != ""
+	}
+	v, ok := t.Tags[key]
+	return v, ok
+}
+
+// TargetProvider loads an inventory.
+type TargetProvider interface {
+	Load(ctx context.Context) ([]Target, error)
+}
+
+// TargetProviderFunc adapts a function into a TargetProvider.
+type TargetProviderFunc func(ctx context.Context) ([]Target, error)
+
+// Load implements TargetProvider.
+func (f TargetProviderFunc) Load(ctx context.Context) ([]Target, error) { return f(ctx) }
+
+// FileTargetProvider loads targets from a JSON file (an array of Target) or a
+// line-oriented file of `name [address] key=value...` entries.
+type FileTargetProvider struct {
+	Path string
+}
+
+// Load implements TargetProvider.
+func (p FileTargetProvider) Load(ctx context.Context) ([]Target, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(p.Path), ".json") {
+		var targets []Target
+		if err := json.NewDecoder(f).Decode(&targets); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Path, err)
+		}
+		return targets, nil
+	}
+	return parseTargetLines(f)
+}
+
+// HTTPTargetProvider loads a JSON array of targets from a URL.
+type HTTPTargetProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+// Load implements TargetProvider.
+func (p HTTPTargetProvider) Load(ctx context.Context) ([]Target, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", p.URL, resp.Status)
+	}
+	var targets []Target
+	if err := json.NewDecoder(resp.Body).Decode(&targets); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.URL, err)
+	}
+	return targets, nil
+}
+
+func parseTargetLines(r io.Reader) ([]Target, error) {
+	var targets []Target
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		t := Target{Name: fields[0], Tags: map[string]string{}}
+		for _, field := range fields[1:] {
+			if k, v, ok := strings.Cut(field, "="); ok {
+				t.Tags[k] = v
+			} else if t.Address == "" {
+				t.Address = field
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets, scanner.Err()
+}
+
+// TargetSelector reports whether a target is selected.
+type TargetSelector func(Target) bool
+
+// ParseTargetSelector builds a selector from terms, all of which must match.
+// A term is `key=glob`, `key!=glob`, or a bare glob matched against the name.
+func ParseTargetSelector(terms []string) (TargetSelector, error) {
+	var preds []TargetSelector
+	for _, term := range terms {
+		key, pattern, negate := "name", term, false
+		if k, v, ok := strings.Cut(term, "!="); ok {
+			key, pattern, negate = k, v, true
+		} else if k, v, ok := strings.Cut(term, "="); ok {
+			key, pattern = k, v
+		}
+		if key == "" {
+			return nil, fmt.Errorf("invalid selector %q", term)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", term, err)
+		}
+		preds = append(preds, func(t Target) bool {
+			v, ok := t.Field(key)
+			if !ok {
+				return negate
+			}
+			matched, _ := path.Match(pattern, v)
+			return matched != negate
+		})
+	}
+	return func(t Target) bool {
+		for _, p := range preds {
+			if !p(t) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// TargetService holds the inventory loaded from a provider.
+type TargetService struct {
+	provider TargetProvider
+	mu       sync.RWMutex
+	targets  []Target
+	loaded   bool
+}
+
+// NewTargetService constructs a service backed by provider.
+func NewTargetService(provider TargetProvider) *TargetService {
+	return &TargetService{provider: provider}
+}
+
+// Reload fetches the inventory from the provider.
+func (s *TargetService) Reload(ctx context.Context) error {
+	if s.provider == nil {
+		return errors.New("no target provider configured")
+	}
+	targets, err := s.provider.Load(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	s.mu.Lock()
+	s.targets = targets
+	s.loaded = true
+	s.mu.Unlock()
+	return nil
+}
+
+// Targets returns the inventory, loading it on first use.
+func (s *TargetService) Targets(ctx context.Context) ([]Target, error) {
+	s.mu.RLock()
+	loaded := s.loaded
+	s.mu.RUnlock()
+	if !loaded {
+		if err := s.Reload(ctx); err != nil {
+			return nil, err
+		}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Target(nil), s.targets...), nil
+}
+
+// Select returns the targets matching terms.
+func (s *TargetService) Select(ctx context.Context, terms []string) ([]Target, error) {
+	sel, err := ParseTargetSelector(terms)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.Targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []Target
+	for _, t := range all {
+		if sel(t) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// WithTargets enables the targets service and `targets` context backed by provider.
+func WithTargets(provider TargetProvider) Option {
+	return func(e *Engine) {
+		svc := NewTargetService(provider)
+		e.services.Register(ServiceKeyTargets, svc)
+		e.registry.RegisterContext(ContextSpec{Name: TargetsContext, Description: "Inventory and target selection"})
+		for _, spec := range targetCommandSpecs() {
+			e.registry.RegisterCommand(&targetsCommandFactory{service: svc, spec: spec})
+		}
+	}
+}
+
+// SelectedTargets returns the targets selected in the runtime's session.
+func SelectedTargets(rt CommandRuntime) []Target {
+	v, ok := rt.Session().Get(SessionKeyTargets)
+	if !ok {
+		return nil
+	}
+	targets, _ := v.([]Target)
+	return targets
+}
+
+// targets commands ------------------------------------------------------------
+
+func targetCommandSpecs() []CommandSpec {
+	return []CommandSpec{
+		{
+			Name:    "list",
+			Summary: "List inventory targets",
+			Context: TargetsContext,
+			Args:    []ArgSpec{{Name: "selector", Type: ArgTypeString, Repeatable: true, Description: "Optional key=glob or name glob filters"}},
+		},
+		{
+			Name:        "select",
+			Summary:     "Select targets by tag or name glob",
+			Description: "Replaces the session selection with targets matching every term. Terms are key=glob, key!=glob, or a glob on the name.",
+			Context:     TargetsContext,
+			Args:        []ArgSpec{{Name: "selector", Type: ArgTypeString, Required: true, Repeatable: true, Description: "key=glob, key!=glob, or name glob"}},
+			Examples:    []Example{{Description: "Select a site", Command: "targets select site=fra1"}},
+		},
+		{
+			Name:    "selection",
+			Summary: "Show the selected targets",
+			Context: TargetsContext,
+		},
+		{
+			Name:    "clear",
+			Summary: "Clear the target selection",
+			Context: TargetsContext,
+		},
+		{
+			Name:    "reload",
+			Summary: "Reload the inventory from its provider",
+			Context: TargetsContext,
+		},
+	}
+}
+
+type targetsCommandFactory struct {
+	service *TargetService
+	spec    CommandSpec
+}
+
+func (f *targetsCommandFactory) Spec() CommandSpec { return f.spec }
+
+func (f *targetsCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &targetsCommand{service: f.service, spec: f.spec}, nil
+}
+
+type targetsCommand struct {
+	service *TargetService
+	spec    CommandSpec
+}
+
+func (c *targetsCommand) Spec() CommandSpec { return c.spec }
+
+func (c *targetsCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	ctx := rt.Cancellation()
+	switch c.spec.Name {
+	case "list":
+		targets, err := c.service.Select(ctx, input.Args.Strings("selector"))
+		if err != nil {
+			return targetsFailure(err)
+		}
+		return renderTargets(rt, targets)
+	case "select":
+		targets, err := c.service.Select(ctx, input.Args.Strings("selector"))
+		if err != nil {
+			return targetsFailure(err)
+		}
+		rt.Session().Set(SessionKeyTargets, targets)
+		rt.Output().Info(fmt.Sprintf("Selected %d target(s)", len(targets)))
+		return CommandResult{Status: StatusSuccess, Payload: targets}
+	case "selection":
+		return renderTargets(rt, SelectedTargets(rt))
+	case "clear":
+		rt.Session().Delete(SessionKeyTargets)
+		rt.Output().Info("Selection cleared")
+		return CommandResult{Status: StatusSuccess}
+	case "reload":
+		if err := c.service.Reload(ctx); err != nil {
+			return targetsFailure(err)
+		}
+		targets, _ := c.service.Targets(ctx)
+		rt.Output().Info(fmt.Sprintf("Loaded %d target(s)", len(targets)))
+		return CommandResult{Status: StatusSuccess}
+	}
+	return targetsFailure(fmt.Errorf("unknown targets command %q", c.spec.Name))
+}
+
+func renderTargets(rt CommandRuntime, targets []Target) CommandResult {
+	if len(targets) == 0 {
+		rt.Output().Info("No targets.")
+		return CommandResult{Status: StatusSuccess}
+	}
+	table := PipelineTable{Headers: []string{"Name", "Address", "Tags"}}
+	for _, t := range targets {
+		keys := make([]string, 0, len(t.Tags))
+		for k := range t.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		tags := make([]string, len(keys))
+		for i, k := range keys {
+			tags[i] = k + "=" + t.Tags[k]
+		}
+		table.Rows = append(table.Rows, []string{t.Name, t.Address, strings.Join(tags, ",")})
+	}
+	rt.Output().WriteTable(table.Headers, table.Rows)
+	return CommandResult{Status: StatusSuccess, Payload: targets, Pipeline: table}
+}
+
+func targetsFailure(err error) CommandResult {
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+}
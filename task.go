@@ -0,0 +1,186 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+// task command implementation ------------------------------------------------
+
+type taskCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *taskCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "task",
+			Summary:     "Follow, show, pause, or cancel a background task",
+			Description: "watch shows a live progress bar and logs prints the task's status and progress events; both follow the task until it finishes or Ctrl-C is pressed. output prints what the task has written so far. pause and resume suspend and restart a periodic task. cancel stops the task.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"watch", "logs", "output", "pause", "resume", "cancel"}, Required: true, Description: "Action to perform"},
+				{Name: "id", Type: ArgTypeString, Required: true, Description: "Task ID", Complete: completeTaskIDs},
+			},
+			Examples: []Example{
+				{Description: "Watch a task", Command: "task watch task-1"},
+				{Description: "Show a task's output", Command: "task output task-1"},
+				{Description: "Suspend a periodic task", Command: "task pause task-2"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *taskCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &taskCommand{spec: f.Spec()}, nil
+}
+
+func completeTaskIDs(prefix string, rt CommandRuntime) []string {
+	var ids []string
+	for _, task := range rt.TaskManager().Tasks() {
+		if strings.HasPrefix(task.ID, prefix) {
+			ids = append(ids, task.ID)
+		}
+	}
+	return ids
+}
+
+type taskCommand struct {
+	spec CommandSpec
+}
+
+func (c *taskCommand) Spec() CommandSpec { return c.spec }
+
+func (c *taskCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	id := input.Args.String("id")
+	switch input.Args.String("action") {
+	case "cancel":
+		if !rt.TaskManager().Cancel(id) {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("unknown task: %s", id), Severity: SeverityError}}
+		}
+		return CommandResult{Status: StatusSuccess}
+	case "pause", "resume":
+		manager := rt.TaskManager()
+		set := manager.Pause
+		if input.Args.String("action") == "resume" {
+			set = manager.Resume
+		}
+		if !set(id) {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("%s is not a running periodic task", id), Severity: SeverityError}}
+		}
+		return CommandResult{Status: StatusSuccess}
+	case "output":
+		text, ok := rt.TaskManager().Output(id)
+		if !ok {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("unknown task: %s", id), Severity: SeverityError}}
+		}
+		if text == "" {
+			rt.Output().Info("No output.")
+		} else {
+			rt.Output().Info(strings.TrimRight(text, "\n"))
+		}
+		return CommandResult{Status: StatusSuccess, Payload: text}
+	}
+	past, events, stop, ok := rt.TaskManager().Follow(id)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("unknown task: %s", id), Severity: SeverityError}}
+	}
+	defer stop()
+	ctx, cancel := signal.NotifyContext(rt.Cancellation(), os.Interrupt)
+	defer cancel()
+
+	out := rt.Output()
+	if input.Args.String("action") == "logs" {
+		for _, ev := range past {
+			out.Info(formatTaskEvent(ev))
+		}
+		finished := followTask(ctx, events, func(ev TaskEvent) { out.Info(formatTaskEvent(ev)) })
+		return c.finish(rt, id, finished)
+	}
+
+	var bar ProgressReporter
+	show := func(p TaskProgress) {
+		if p == (TaskProgress{}) {
+			return
+		}
+		if bar == nil {
+			bar = out.Progress(id, p.Total)
+		}
+		bar.Set(p.Current)
+		bar.Message(p.Message)
+	}
+	if len(past) > 0 {
+		show(past[len(past)-1].Progress)
+	}
+	finished := followTask(ctx, events, func(ev TaskEvent) {
+		if !taskFinished(ev.Status) {
+			show(ev.Progress)
+		}
+	})
+	if bar != nil && finished {
+		bar.Done()
+	}
+	return c.finish(rt, id, finished)
+}
+
+// followTask calls fn for each event until the task finishes (true) or ctx is
+// cancelled (false).
+func followTask(ctx context.Context, events <-chan TaskEvent, fn func(TaskEvent)) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case ev, ok := <-events:
+			if !ok {
+				return true
+			}
+			fn(ev)
+		}
+	}
+}
+
+func (c *taskCommand) finish(rt CommandRuntime, id string, finished bool) CommandResult {
+	handle, _ := rt.TaskManager().DescribeTask(id)
+	if !finished {
+		rt.Output().Info(fmt.Sprintf("Stopped following %s; it is still %s.", id, handle.Status))
+		return CommandResult{Status: StatusSuccess, Payload: handle}
+	}
+	msg := fmt.Sprintf("%s %s", id, handle.Status)
+	if handle.Error != nil {
+		msg += ": " + handle.Error.Error()
+	}
+	rt.Output().Info(msg)
+	return CommandResult{Status: StatusSuccess, Payload: handle}
+}
+
+// formatTaskEvent renders one line of `task logs`.
+func formatTaskEvent(ev TaskEvent) string {
+	parts := []string{ev.Time.Format("15:04:05"), string(ev.Status)}
+	if p := formatTaskProgress(ev.Progress); p != "" {
+		parts = append(parts, p)
+	}
+	if ev.Error != nil {
+		parts = append(parts, ev.Error.Error())
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatTaskProgress renders progress as "3/10 message", or "" when none was reported.
+func formatTaskProgress(p TaskProgress) string {
+	var parts []string
+	switch {
+	case p.Total > 0:
+		parts = append(parts, fmt.Sprintf("%d/%d", p.Current, p.Total))
+	case p.Current > 0:
+		parts = append(parts, strconv.Itoa(p.Current))
+	}
+	if p.Message != "" {
+		parts = append(parts, p.Message)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDependencyFailed is the error of a group task skipped because a task it
+// depends on did not succeed.
+var ErrDependencyFailed = errors.New("dependency did not succeed")
+
+// TaskSpec describes one task of a group. ID names it within the group (the
+// Name is used when empty) and DependsOn lists the IDs it must wait for.
+type TaskSpec struct {
+	ID        string
+	Name      string
+	Func      TaskFunc
+	Options   TaskOptions
+	DependsOn []string
+}
+
+// TaskGroup is a set of tasks spawned together. Tasks holds their task IDs in
+// execution order.
+type TaskGroup struct {
+	ID     string
+	Name   string
+	Tasks  []string
+	Status TaskStatus
+	seq    int
+}
+
+// SpawnGroup validates the dependencies of specs, then spawns them in
+// dependency order. Each task starts once everything it depends on has
+// succeeded; when a dependency fails or is cancelled, its dependents are
+// cancelled with ErrDependencyFailed. Cancelling the group ID cancels every task.
+func (m *TaskManager) SpawnGroup(name string, specs []TaskSpec) (*TaskGroup, error) {
+	order, err := orderTaskSpecs(specs)
+	if err != nil {
+		return nil, err
+	}
+	type node struct {
+		taskID string
+		status TaskStatus
+		done   chan struct{}
+	}
+	nodes := make(map[string]*node, len(specs))
+	for _, spec := range specs {
+		nodes[taskSpecID(spec)] = &node{done: make(chan struct{})}
+	}
+
+	m.mu.Lock()
+	m.groupSeq++
+	group := &TaskGroup{ID: fmt.Sprintf("group-%d", m.groupSeq), Name: name, seq: m.groupSeq}
+	m.groups[group.ID] = group
+	m.mu.Unlock()
+
+	for _, i := range order {
+		spec := specs[i]
+		self := nodes[taskSpecID(spec)]
+		deps := make([]*node, len(spec.DependsOn))
+		depIDs := make([]string, len(spec.DependsOn))
+		for j, dep := range spec.DependsOn {
+			deps[j] = nodes[dep]
+			depIDs[j] = deps[j].taskID
+		}
+		gate := &taskGate{
+			group:     group.ID,
+			dependsOn: depIDs,
+			wait: func(ctx context.Context) error {
+				for _, dep := range deps {
+					select {
+					case <-dep.done:
+						if dep.status != TaskSucceeded {
+							return fmt.Errorf("%w: %s %s", ErrDependencyFailed, dep.taskID, dep.status)
+						}
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				return nil
+			},
+			done: func(status TaskStatus) {
+				self.status = status
+				close(self.done)
+			},
+		}
+		handle := m.spawn(spec.Name, spec.Func, spec.Options, gate)
+		self.taskID = handle.ID
+		m.mu.Lock()
+		group.Tasks = append(group.Tasks, handle.ID)
+		m.mu.Unlock()
+	}
+	g, _ := m.Group(group.ID)
+	return g, nil
+}
+
+// Group returns a snapshot of a task group with its current status.
+func (m *TaskManager) Group(id string) (*TaskGroup, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	group, ok := m.groups[id]
+	if !ok {
+		return nil, false
+	}
+	return m.groupSnapshot(group), true
+}
+
+// Groups lists task groups in the order they were spawned.
+func (m *TaskManager) Groups() []*TaskGroup {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]*TaskGroup, 0, len(m.groups))
+	for _, group := range m.groups {
+		list = append(list, m.groupSnapshot(group))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].seq < list[j].seq })
+	return list
+}
+
+// groupSnapshot must be called with m.mu held. A group runs while any task
+// does, and otherwise reports its worst final status once all have finished.
+func (m *TaskManager) groupSnapshot(group *TaskGroup) *TaskGroup {
+	snap := *group
+	snap.Tasks = append([]string(nil), group.Tasks...)
+	var running, pending, failed, cancelled bool
+	for _, id := range group.Tasks {
+		switch m.tasks[id].Status {
+		case TaskRunning:
+			running = true
+		case TaskPending:
+			pending = true
+		case TaskFailed:
+			failed = true
+		case TaskCancelled:
+			cancelled = true
+		}
+	}
+	switch {
+	case running:
+		snap.Status = TaskRunning
+	case pending:
+		snap.Status = TaskPending
+	case failed:
+		snap.Status = TaskFailed
+	case cancelled:
+		snap.Status = TaskCancelled
+	default:
+		snap.Status = TaskSucceeded
+	}
+	return &snap
+}
+
+func taskSpecID(spec TaskSpec) string {
+	if spec.ID != "" {
+		return spec.ID
+	}
+	return spec.Name
+}
+
+// orderTaskSpecs returns the indexes of specs in dependency order, keeping the
+// given order among independent tasks.
+func orderTaskSpecs(specs []TaskSpec) ([]int, error) {
+	index := make(map[string]int, len(specs))
+	for i, spec := range specs {
+		id := taskSpecID(spec)
+		if id == "" {
+			return nil, fmt.Errorf("task %d has no ID or name", i+1)
+		}
+		if spec.Func == nil {
+			return nil, fmt.Errorf("task %s has no function", id)
+		}
+		if _, dup := index[id]; dup {
+			return nil, fmt.Errorf("duplicate task ID %s", id)
+		}
+		index[id] = i
+	}
+	waiting := make([]int, len(specs))
+	dependents := make([][]int, len(specs))
+	for i, spec := range specs {
+		for _, dep := range spec.DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("task %s depends on unknown task %s", taskSpecID(spec), dep)
+			}
+			waiting[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+	order := make([]int, 0, len(specs))
+	placed := make([]bool, len(specs))
+	for len(order) < len(specs) {
+		next := -1
+		for i := range specs {
+			if !placed[i] && waiting[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var cycle []string
+			for i, spec := range specs {
+				if !placed[i] {
+					cycle = append(cycle, taskSpecID(spec))
+				}
+			}
+			return nil, fmt.Errorf("task dependency cycle among %s", strings.Join(cycle, ", "))
+		}
+		placed[next] = true
+		order = append(order, next)
+		for _, d := range dependents[next] {
+			waiting[d]--
+		}
+	}
+	return order, nil
+}
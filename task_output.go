@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// DefaultTaskOutputSize is how many bytes of output are kept per task.
+const DefaultTaskOutputSize = 64 << 10
+
+// ringBuffer keeps the most recent writes up to a fixed size.
+type ringBuffer struct {
+	mu   sync.Mutex
+	size int
+	data []byte
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{size: size}
+}
+
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data = append(r.data, p...)
+	if over := len(r.data) - r.size; over > 0 {
+		// Drop whole lines where possible so the retained output starts cleanly.
+		if i := bytes.IndexByte(r.data[over:], '\n'); i >= 0 && i < len(r.data)-over-1 {
+			over += i + 1
+		}
+		r.data = append(r.data[:0], r.data[over:]...)
+	}
+	return len(p), nil
+}
+
+// Len returns how many bytes are kept.
+func (r *ringBuffer) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.data)
+}
+
+// Reset drops the kept output.
+func (r *ringBuffer) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data = nil
+}
+
+func (r *ringBuffer) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return string(r.data)
+}
+
+// prefixWriter passes complete lines to emit with a prefix, so output from
+// concurrent tasks never interleaves mid-line.
+type prefixWriter struct {
+	mu      sync.Mutex
+	emit    func(line []byte)
+	prefix  string
+	pending []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = append(p.pending, b...)
+	for {
+		i := bytes.IndexByte(p.pending, '\n')
+		if i < 0 {
+			break
+		}
+		p.emit(append([]byte(p.prefix), p.pending[:i+1]...))
+		p.pending = p.pending[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes any trailing partial line.
+func (p *prefixWriter) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) == 0 {
+		return
+	}
+	p.emit(append(append([]byte(p.prefix), p.pending...), '\n'))
+	p.pending = nil
+}
+
+// newTaskOutput builds the channel handed to a task: output is captured in
+// capture and, unless silent, echoed to shared with each line prefixed by the
+// task ID.
+func newTaskOutput(id string, shared OutputChannel, capture *ringBuffer, silent bool) (*DefaultOutputChannel, func()) {
+	var w io.Writer = capture
+	flush := func() {}
+	if !silent && shared != nil {
+		echo := &prefixWriter{emit: echoLine(shared), prefix: "[" + id + "] "}
+		w = io.MultiWriter(capture, echo)
+		flush = echo.Flush
+	}
+	return derivedOutput(w, shared), flush
+}
+
+// derivedOutput builds a channel writing to w with the level and theme of parent.
+func derivedOutput(w io.Writer, parent OutputChannel) *DefaultOutputChannel {
+	c := &DefaultOutputChannel{writer: w, started: true, lineProgress: true}
+	c.level.Store(int32(OutputNormal))
+	if parent != nil {
+		c.level.Store(int32(parent.Level()))
+		if dc, ok := parent.(*DefaultOutputChannel); ok {
+			dc.mu.Lock()
+			c.theme = dc.theme
+			dc.mu.Unlock()
+		}
+	}
+	return c
+}
+
+// echoLine returns a func writing a line to shared. Lines to a
+// DefaultOutputChannel are serialised with its other output.
+func echoLine(shared OutputChannel) func([]byte) {
+	dc, ok := shared.(*DefaultOutputChannel)
+	if !ok {
+		w := shared.Writer()
+		return func(line []byte) { w.Write(line) }
+	}
+	return func(line []byte) {
+		dc.mu.Lock()
+		restore := dc.suspendProgress()
+		dc.writer.Write(line)
+		restore()
+		dc.mu.Unlock()
+		dc.forward(func(m *DefaultOutputChannel) { m.writeRaw(line) })
+	}
+}
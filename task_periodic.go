@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// periodicControl pauses and resumes a periodic task.
+type periodicControl struct {
+	mu     sync.Mutex
+	paused bool
+	wake   chan struct{}
+}
+
+func (c *periodicControl) setPaused(paused bool) {
+	c.mu.Lock()
+	c.paused = paused
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *periodicControl) isPaused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// SpawnPeriodic starts a task running fn every interval until cancelled. The
+// first run waits for opts.Delay and opts.At; opts.Timeout bounds each run.
+// A failed run is recorded on the handle and the schedule continues. Between
+// runs the task is pending with NextRun set; Pause and Resume suspend it.
+func (m *TaskManager) SpawnPeriodic(name string, interval time.Duration, fn TaskFunc, opts TaskOptions) (*TaskHandle, error) {
+	if interval <= 0 {
+		return nil, errors.New("periodic task interval must be positive")
+	}
+	ctl := &periodicControl{wake: make(chan struct{}, 1)}
+	handle, ctx, flush := m.register(name, opts, nil)
+	id, output := handle.ID, handle.Output
+	m.mu.Lock()
+	handle.Interval = interval
+	handle.control = ctl
+	m.mu.Unlock()
+
+	go func() {
+		var last error
+		next := time.Now().Add(firstRunDelay(opts))
+		for {
+			if ctl.isPaused() {
+				m.schedule(id, time.Time{})
+				m.updateStatus(id, TaskPaused, last)
+				select {
+				case <-ctx.Done():
+					m.updateStatus(id, TaskCancelled, ctx.Err())
+					return
+				case <-ctl.wake:
+				}
+				if !ctl.isPaused() {
+					m.updateStatus(id, TaskPending, last)
+				}
+				next = time.Now()
+				continue
+			}
+			if wait := time.Until(next); wait > 0 {
+				m.schedule(id, next)
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					m.updateStatus(id, TaskCancelled, ctx.Err())
+					return
+				case <-ctl.wake:
+					timer.Stop()
+					continue
+				case <-timer.C:
+				}
+			}
+			start := time.Now()
+			last = m.runAttempt(ctx, id, fn, output, opts.Timeout)
+			flush()
+			if ctx.Err() != nil {
+				m.updateStatus(id, TaskCancelled, ctx.Err())
+				return
+			}
+			m.updateStatus(id, TaskPending, last)
+			next = start.Add(interval)
+		}
+	}()
+
+	return handle, nil
+}
+
+// Pause suspends a periodic task after any run in progress, reporting false
+// for unknown, finished, or one-off tasks.
+func (m *TaskManager) Pause(id string) bool {
+	return m.setPaused(id, true)
+}
+
+// Resume restarts a paused periodic task with an immediate run.
+func (m *TaskManager) Resume(id string) bool {
+	return m.setPaused(id, false)
+}
+
+func (m *TaskManager) setPaused(id string, paused bool) bool {
+	m.mu.RLock()
+	handle, ok := m.tasks[id]
+	var ctl *periodicControl
+	if ok && !taskFinished(handle.Status) {
+		ctl = handle.control
+	}
+	m.mu.RUnlock()
+	if ctl == nil {
+		return false
+	}
+	ctl.setPaused(paused)
+	return true
+}
@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ANSI colours for Theme fields.
+const (
+	ColorRed     = ansiRed
+	ColorGreen   = ansiGreen
+	ColorYellow  = ansiYellow
+	ColorBlue    = ansiBlue
+	ColorMagenta = "\x1b[35m"
+	ColorCyan    = "\x1b[36m"
+	ColorBold    = "\x1b[1m"
+	ColorDim     = ansiDim
+)
+
+// Theme assigns ANSI colours to output elements. An empty field leaves that
+// element uncoloured. Colours are only emitted when ColorEnabled reports true,
+// so NO_COLOR and non-terminal output stay plain.
+type Theme struct {
+	Error       string
+	Warning     string
+	Info        string
+	Prompt      string
+	TableHeader string
+	// ContextPrompts overrides Prompt for the named contexts.
+	ContextPrompts map[string]string
+	// RightPrompt is a text/template over RightPromptData drawn at the right
+	// edge of the prompt line; empty disables it.
+	RightPrompt      string
+	RightPromptColor string
+}
+
+// DefaultTheme colours errors red, warnings yellow, and table headers bold.
+func DefaultTheme() Theme {
+	return Theme{Error: ColorRed, Warning: ColorYellow, TableHeader: ColorBold}
+}
+
+// WithTheme sets the colours used by output channels and prompts. Pass Theme{}
+// to disable colouring altogether.
+func WithTheme(theme Theme) Option {
+	return func(e *Engine) { e.theme = theme }
+}
+
+// SetTheme replaces the theme at runtime.
+func (e *Engine) SetTheme(theme Theme) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.theme = theme
+}
+
+// Theme returns the active theme.
+func (e *Engine) Theme() Theme {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.theme
+}
+
+// themeFile is the JSON form of a Theme read by LoadTheme.
+type themeFile struct {
+	Error            string            `json:"error"`
+	Warning          string            `json:"warning"`
+	Info             string            `json:"info"`
+	Prompt           string            `json:"prompt"`
+	TableHeader      string            `json:"table_header"`
+	ContextPrompts   map[string]string `json:"context_prompts"`
+	RightPrompt      string            `json:"right_prompt"`
+	RightPromptColor string            `json:"right_prompt_color"`
+}
+
+var colorNames = map[string]string{
+	"red": ColorRed, "green": ColorGreen, "yellow": ColorYellow, "blue": ColorBlue,
+	"magenta": ColorMagenta, "cyan": ColorCyan, "bold": ColorBold, "dim": ColorDim,
+}
+
+// LoadTheme reads a theme from a JSON file with the keys error, warning, info,
+// prompt, table_header, context_prompts, right_prompt, and right_prompt_color.
+// Colours are names (red, green, yellow, blue, magenta, cyan, bold, dim),
+// space-separated to combine, e.g. "bold red".
+func LoadTheme(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	var file themeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	var bad []string
+	color := func(spec string) string {
+		var seq string
+		for _, name := range strings.Fields(spec) {
+			c, ok := colorNames[strings.ToLower(name)]
+			if !ok {
+				bad = append(bad, name)
+			}
+			seq += c
+		}
+		return seq
+	}
+	theme := Theme{
+		Error:            color(file.Error),
+		Warning:          color(file.Warning),
+		Info:             color(file.Info),
+		Prompt:           color(file.Prompt),
+		TableHeader:      color(file.TableHeader),
+		RightPrompt:      file.RightPrompt,
+		RightPromptColor: color(file.RightPromptColor),
+	}
+	if len(file.ContextPrompts) > 0 {
+		theme.ContextPrompts = make(map[string]string, len(file.ContextPrompts))
+		for ctx, spec := range file.ContextPrompts {
+			theme.ContextPrompts[ctx] = color(spec)
+		}
+	}
+	if len(bad) > 0 {
+		return Theme{}, fmt.Errorf("theme %s: unknown colour(s) %s", path, strings.Join(bad, ", "))
+	}
+	return theme, nil
+}
+
+// PromptColor returns the prompt colour for a context.
+func (t Theme) PromptColor(context string) string {
+	if c, ok := t.ContextPrompts[context]; ok {
+		return c
+	}
+	return t.Prompt
+}
+
+// newOutputChannel builds an output channel using the engine theme.
+func (e *Engine) newOutputChannel(w io.Writer) *DefaultOutputChannel {
+	out := NewOutputChannel(w)
+	out.SetTheme(e.Theme())
+	out.router = e.router
+	return out
+}
+
+// promptString renders the current context prompt in its theme colour.
+func (s *Session) promptString() string {
+	prompt := s.contexts.Prompt(s.engine.prompt())
+	if s.engine.Simulated() {
+		prompt = "[sim] " + prompt
+	}
+	return Colorize(prompt, s.engine.Theme().PromptColor(s.contexts.Current().Spec.Name))
+}
+
+// reportError prints an error from the read loop in the theme's error colour.
+func (s *Session) reportError(err error) {
+	fmt.Fprintln(s.OutputWriter(), Colorize(fmt.Sprintf("Error: %v", err), s.engine.Theme().Error))
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// timeoutGrace is how long a command whose deadline passed may take to return
+// its own result before the engine abandons it.
+const timeoutGrace = 250 * time.Millisecond
+
+// WithDefaultCommandTimeout bounds every command without its own
+// CommandSpec.Timeout to d. Zero, the default, leaves commands unbounded.
+func WithDefaultCommandTimeout(d time.Duration) Option {
+	return func(e *Engine) { e.defaultTimeout = d }
+}
+
+// commandTimeout returns the time limit for spec, or zero for none.
+func (e *Engine) commandTimeout(spec CommandSpec) time.Duration {
+	if spec.Timeout > 0 {
+		return spec.Timeout
+	}
+	return e.defaultTimeout
+}
+
+// runBounded runs handler, failing the invocation once ctx's deadline passes.
+// A command that ignores its context is abandoned after a short grace period
+// so a hung backend call cannot hang the shell; it keeps running unobserved.
+func runBounded(ctx context.Context, timeout time.Duration, spec CommandSpec, handler func() CommandResult) CommandResult {
+	if timeout <= 0 {
+		return handler()
+	}
+	done := make(chan CommandResult, 1)
+	go func() { done <- handler() }()
+	var result CommandResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return <-done
+		}
+		select {
+		case result = <-done:
+		case <-time.After(timeoutGrace):
+		}
+	}
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) || (result.Status != "" && result.Status != StatusFailed && result.Error == nil) {
+		return result
+	}
+	hint := "the backend did not answer in time; check its health with status, then retry"
+	if result.Error != nil {
+		// Keep the command's own account of what it was waiting for.
+		failure := *result.Error
+		failure.Hints = append(append([]string(nil), failure.Hints...), hint)
+		if failure.ErrCode == "" {
+			failure.ErrCode = ErrCodeTimeout
+		}
+		result.Status, result.Error = StatusFailed, &failure
+		return result
+	}
+	err := fmt.Errorf("%s timed out after %s: %w", spec.Name, timeout, context.DeadlineExceeded)
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), ErrCode: ErrCodeTimeout, Severity: SeverityError, Hints: []string{hint}}}
+}
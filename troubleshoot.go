@@ -0,0 +1,324 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Troubleshooter is a decision tree walked by the troubleshoot command. The
+// walk starts at Start, or at the first step when Start is empty.
+type Troubleshooter struct {
+	Name    string
+	Summary string
+	Start   string
+	Steps   []TroubleshootStep
+}
+
+// TroubleshootStep is one node of a Troubleshooter. A step asks a Question,
+// runs a diagnostic Command, or both; a step with neither just records its
+// Finding. The next step comes from the chosen answer, or from OnSuccess and
+// OnFailure after a command; the walk ends at a step with nowhere to go.
+type TroubleshootStep struct {
+	ID string
+	// Question is asked before the command runs. With Answers the operator
+	// picks one; without, the free-form reply is stored in the session variable
+	// Var so later commands can use it, e.g. `ping $device`.
+	Question string
+	Answers  []TroubleshootAnswer
+	Var      string
+	// Command is a command line run like one typed by the operator, with
+	// variables expanded.
+	Command   string
+	OnSuccess string
+	OnFailure string
+	// Next is the step after a question without answers or a bare finding.
+	Next string
+	// Finding is added to the report when the step is reached, or only when
+	// its command fails if FindingOnFailure is set.
+	Finding          string
+	FindingOnFailure bool
+	Severity         SeverityLevel
+}
+
+// TroubleshootAnswer is one choice of a TroubleshootStep question.
+type TroubleshootAnswer struct {
+	Label string
+	Next  string
+	// Finding, when set, is added to the report if this answer is chosen.
+	Finding  string
+	Severity SeverityLevel
+}
+
+// TroubleshootFinding is one conclusion in a TroubleshootReport.
+type TroubleshootFinding struct {
+	Step     string        `json:"step"`
+	Severity SeverityLevel `json:"severity"`
+	Message  string        `json:"message"`
+}
+
+// TroubleshootReport is the payload of a troubleshoot run.
+type TroubleshootReport struct {
+	Troubleshooter string                `json:"troubleshooter"`
+	Steps          []string              `json:"steps"`
+	Commands       []string              `json:"commands,omitempty"`
+	Findings       []TroubleshootFinding `json:"findings"`
+}
+
+// troubleshooters holds the engine's decision trees by name.
+type troubleshooters struct {
+	mu    sync.RWMutex
+	trees map[string]Troubleshooter
+}
+
+func (t *troubleshooters) get(name string) (Troubleshooter, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	tree, ok := t.trees[name]
+	return tree, ok
+}
+
+func (t *troubleshooters) list() []Troubleshooter {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	list := make([]Troubleshooter, 0, len(t.trees))
+	for _, tree := range t.trees {
+		list = append(list, tree)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// validate checks that every step reference resolves.
+func (tree Troubleshooter) validate() error {
+	if tree.Name == "" {
+		return errors.New("troubleshooter name is required")
+	}
+	if len(tree.Steps) == 0 {
+		return fmt.Errorf("troubleshooter %s has no steps", tree.Name)
+	}
+	ids := map[string]bool{}
+	for _, step := range tree.Steps {
+		if step.ID == "" || ids[step.ID] {
+			return fmt.Errorf("troubleshooter %s: step IDs must be unique and non-empty (%q)", tree.Name, step.ID)
+		}
+		ids[step.ID] = true
+	}
+	check := func(from, to string) error {
+		if to != "" && !ids[to] {
+			return fmt.Errorf("troubleshooter %s: step %s leads to unknown step %s", tree.Name, from, to)
+		}
+		return nil
+	}
+	if err := check("start", tree.Start); err != nil {
+		return err
+	}
+	for _, step := range tree.Steps {
+		refs := []string{step.OnSuccess, step.OnFailure, step.Next}
+		for _, a := range step.Answers {
+			refs = append(refs, a.Next)
+		}
+		for _, to := range refs {
+			if err := check(step.ID, to); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WithTroubleshooter registers a decision tree for the troubleshoot command.
+// Invalid trees are reported and skipped.
+func WithTroubleshooter(tree Troubleshooter) Option {
+	return func(e *Engine) {
+		if err := tree.validate(); err != nil {
+			fmt.Fprintf(e.outputWriter, "Error adding troubleshooter: %v\n", err)
+			return
+		}
+		e.troubleshooters.mu.Lock()
+		defer e.troubleshooters.mu.Unlock()
+		if e.troubleshooters.trees == nil {
+			e.troubleshooters.trees = map[string]Troubleshooter{}
+		}
+		e.troubleshooters.trees[tree.Name] = tree
+	}
+}
+
+// troubleshoot command ---------------------------------------------------------
+
+type troubleshootCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *troubleshootCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "troubleshoot",
+			Summary:     "Diagnose a problem step by step",
+			Description: "Walks a decision tree provided by the application: answers questions, runs diagnostic commands, and ends with a report of the findings. Without a name, lists the available troubleshooters.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "name", Type: ArgTypeString, Description: "Troubleshooter to run", Complete: f.completeNames},
+			},
+			Examples: []Example{
+				{Description: "List troubleshooters", Command: "troubleshoot"},
+				{Description: "Diagnose connectivity", Command: "troubleshoot connectivity"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *troubleshootCommandFactory) completeNames(prefix string, rt CommandRuntime) []string {
+	var names []string
+	for _, tree := range f.engine.troubleshooters.list() {
+		if strings.HasPrefix(tree.Name, prefix) {
+			names = append(names, tree.Name)
+		}
+	}
+	return names
+}
+
+func (f *troubleshootCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &troubleshootCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type troubleshootCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *troubleshootCommand) Spec() CommandSpec { return c.spec }
+
+func (c *troubleshootCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	name := input.Args.String("name")
+	if name == "" {
+		trees := c.engine.troubleshooters.list()
+		if len(trees) == 0 {
+			rt.Output().Info("No troubleshooters available.")
+			return CommandResult{Status: StatusSuccess}
+		}
+		rows := make([][]string, 0, len(trees))
+		for _, tree := range trees {
+			rows = append(rows, []string{tree.Name, tree.Summary})
+		}
+		rt.Output().WriteTable([]string{"Name", "Summary"}, rows)
+		return CommandResult{Status: StatusSuccess}
+	}
+	tree, ok := c.engine.troubleshooters.get(name)
+	if !ok {
+		return troubleshootFailure(fmt.Errorf("unknown troubleshooter: %s", name))
+	}
+	session, ok := sessionOf(rt)
+	if !ok {
+		return troubleshootFailure(errors.New("troubleshoot requires an engine session"))
+	}
+	report, err := c.walk(rt, session, tree)
+	c.render(rt, report)
+	if err != nil {
+		return CommandResult{Status: StatusFailed, Payload: report, Error: &CommandError{Err: err, Message: fmt.Sprintf("troubleshoot %s stopped: %v", name, err), Severity: SeverityError}}
+	}
+	return CommandResult{Status: StatusSuccess, Payload: report}
+}
+
+// walk follows tree from its start step, visiting each step at most once.
+func (c *troubleshootCommand) walk(rt CommandRuntime, session *Session, tree Troubleshooter) (TroubleshootReport, error) {
+	report := TroubleshootReport{Troubleshooter: tree.Name}
+	steps := make(map[string]TroubleshootStep, len(tree.Steps))
+	for _, step := range tree.Steps {
+		steps[step.ID] = step
+	}
+	addFinding := func(step, message string, severity SeverityLevel) {
+		if severity == "" {
+			severity = SeverityWarning
+		}
+		report.Findings = append(report.Findings, TroubleshootFinding{Step: step, Severity: severity, Message: message})
+	}
+	id := tree.Start
+	if id == "" {
+		id = tree.Steps[0].ID
+	}
+	visited := map[string]bool{}
+	for id != "" {
+		if err := rt.Cancellation().Err(); err != nil {
+			return report, err
+		}
+		if visited[id] {
+			return report, fmt.Errorf("step %s was reached twice", id)
+		}
+		visited[id] = true
+		step := steps[id]
+		report.Steps = append(report.Steps, id)
+		next := step.Next
+
+		if step.Question != "" {
+			if len(step.Answers) > 0 {
+				options := make([]SelectOption, len(step.Answers))
+				for i, a := range step.Answers {
+					options[i] = SelectOption{Label: a.Label, Value: a}
+				}
+				choice, err := session.Select(rt.Output(), step.Question, options)
+				if err != nil {
+					return report, err
+				}
+				answer := choice.Value.(TroubleshootAnswer)
+				if answer.Finding != "" {
+					addFinding(id, answer.Finding, answer.Severity)
+				}
+				next = answer.Next
+			} else {
+				reply, err := session.Ask(step.Question + " ")
+				if err != nil {
+					return report, err
+				}
+				if step.Var != "" {
+					if err := session.SetVar(step.Var, strings.TrimSpace(reply)); err != nil {
+						return report, err
+					}
+				}
+			}
+		}
+
+		failed := false
+		if step.Command != "" {
+			tokens, err := session.splitLine(step.Command)
+			if err != nil {
+				return report, err
+			}
+			line := joinTokens(tokens)
+			report.Commands = append(report.Commands, line)
+			rt.Output().Info("running: " + line)
+			result, err := session.execute(rt.Cancellation(), tokens)
+			failed = err != nil || result.Status == StatusFailed
+			if failed {
+				next = step.OnFailure
+			} else {
+				next = step.OnSuccess
+			}
+		}
+		if step.Finding != "" && (!step.FindingOnFailure || failed) {
+			addFinding(id, step.Finding, step.Severity)
+		}
+		id = next
+	}
+	return report, nil
+}
+
+func (c *troubleshootCommand) render(rt CommandRuntime, report TroubleshootReport) {
+	if len(report.Findings) == 0 {
+		rt.Output().Info(fmt.Sprintf("%s: no findings after %d step(s).", report.Troubleshooter, len(report.Steps)))
+		return
+	}
+	rows := make([][]string, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		rows = append(rows, []string{string(f.Severity), f.Step, f.Message})
+	}
+	rt.Output().WriteTable([]string{"Severity", "Step", "Finding"}, rows)
+}
+
+func troubleshootFailure(err error) CommandResult {
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+}
@@ -0,0 +1,214 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultUndoDepth bounds the per-session undo stack.
+const DefaultUndoDepth = 50
+
+// UndoFunc reverts a previously applied change.
+type UndoFunc func(rt CommandRuntime) error
+
+// UndoOperation describes the inverse of a mutating command.
+type UndoOperation struct {
+	Description string
+	Revert      UndoFunc
+}
+
+// Undoer is implemented by mutating commands that can describe their inverse.
+// Inverse is called after a successful Execute; returning nil records nothing.
+// Commands may instead set CommandResult.Undo directly.
+type Undoer interface {
+	Inverse(input CommandInput, result CommandResult) *UndoOperation
+}
+
+// UndoEntry is an UndoOperation recorded on a session's undo stack.
+type UndoEntry struct {
+	Command   string
+	Line      string
+	Operation UndoOperation
+	Time      time.Time
+	// InvocationID identifies the invocation that made the change.
+	InvocationID string
+}
+
+// UndoStack is a bounded LIFO of undo entries.
+type UndoStack struct {
+	mu      sync.Mutex
+	limit   int
+	entries []UndoEntry
+}
+
+// NewUndoStack constructs a stack retaining at most limit entries.
+func NewUndoStack(limit int) *UndoStack {
+	if limit <= 0 {
+		limit = DefaultUndoDepth
+	}
+	return &UndoStack{limit: limit}
+}
+
+// Push records an entry, evicting the oldest when full.
+func (s *UndoStack) Push(entry UndoEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	if over := len(s.entries) - s.limit; over > 0 {
+		s.entries = append([]UndoEntry(nil), s.entries[over:]...)
+	}
+}
+
+// Pop removes and returns the most recent entry.
+func (s *UndoStack) Pop() (UndoEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) == 0 {
+		return UndoEntry{}, false
+	}
+	entry := s.entries[len(s.entries)-1]
+	s.entries = s.entries[:len(s.entries)-1]
+	return entry, true
+}
+
+// Entries lists recorded entries, most recent first.
+func (s *UndoStack) Entries() []UndoEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]UndoEntry, 0, len(s.entries))
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		list = append(list, s.entries[i])
+	}
+	return list
+}
+
+// Len reports the number of recorded entries.
+func (s *UndoStack) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// UndoEventType enumerates undo events.
+type UndoEventType string
+
+const (
+	UndoRecorded UndoEventType = "recorded"
+	UndoApplied  UndoEventType = "applied"
+	UndoFailed   UndoEventType = "failed"
+)
+
+// UndoEvent describes an undo stack change, for audit consumers.
+type UndoEvent struct {
+	Type      UndoEventType
+	SessionID string
+	Entry     UndoEntry
+	Principal *Principal
+	Err       error
+	Time      time.Time
+}
+
+// UndoListener observes undo events.
+type UndoListener func(UndoEvent)
+
+// WithUndoListener registers a listener for undo events.
+func WithUndoListener(fn UndoListener) Option {
+	return func(e *Engine) {
+		if fn != nil {
+			e.undoListeners = append(e.undoListeners, fn)
+		}
+	}
+}
+
+func (e *Engine) emitUndoEvent(evt UndoEvent) {
+	if evt.Time.IsZero() {
+		evt.Time = time.Now()
+	}
+	e.mu.RLock()
+	listeners := append([]UndoListener(nil), e.undoListeners...)
+	e.mu.RUnlock()
+	for _, fn := range listeners {
+		fn(evt)
+	}
+}
+
+// recordUndo pushes the inverse of a completed command onto the session stack.
+func (s *Session) recordUndo(entry UndoEntry) {
+	s.undo.Push(entry)
+	principal, _ := PrincipalFromSession(s.store)
+	s.engine.emitUndoEvent(UndoEvent{Type: UndoRecorded, SessionID: s.id, Entry: entry, Principal: principal})
+}
+
+// UndoStack returns the session's undo stack.
+func (s *Session) UndoStack() *UndoStack { return s.undo }
+
+// undo command ----------------------------------------------------------------
+
+type undoCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *undoCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "undo",
+			Summary:     "Revert the last mutating command",
+			Description: "Replays the inverse of the most recent command that recorded an undo operation.",
+			Context:     "",
+			Flags: []FlagSpec{
+				{Name: "list", Shorthand: "l", Type: ArgTypeBool, Description: "List the undo stack without reverting"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *undoCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &undoCommand{spec: f.Spec()}, nil
+}
+
+type undoCommand struct {
+	spec CommandSpec
+}
+
+func (c *undoCommand) Spec() CommandSpec { return c.spec }
+
+func (c *undoCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "undo requires an engine session", Severity: SeverityError}}
+	}
+	if input.Flags.Bool("list") {
+		entries := session.undo.Entries()
+		if len(entries) == 0 {
+			rt.Output().Info("Nothing to undo.")
+			return CommandResult{Status: StatusSuccess}
+		}
+		rows := make([][]string, 0, len(entries))
+		for _, entry := range entries {
+			rows = append(rows, []string{entry.Line, entry.Operation.Description, entry.Time.Format(time.TimeOnly)})
+		}
+		rt.Output().WriteTable([]string{"Command", "Undo", "At"}, rows)
+		return CommandResult{Status: StatusSuccess}
+	}
+
+	entry, ok := session.undo.Pop()
+	if !ok {
+		rt.Output().Info("Nothing to undo.")
+		return CommandResult{Status: StatusSuccess}
+	}
+	principal, _ := PrincipalFromSession(rt.Session())
+	if err := entry.Operation.Revert(rt); err != nil {
+		session.undo.Push(entry)
+		session.engine.emitUndoEvent(UndoEvent{Type: UndoFailed, SessionID: session.id, Entry: entry, Principal: principal, Err: err})
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: fmt.Sprintf("undo %q failed: %v", entry.Line, err), Severity: SeverityError}}
+	}
+	session.engine.emitUndoEvent(UndoEvent{Type: UndoApplied, SessionID: session.id, Entry: entry, Principal: principal})
+	desc := entry.Operation.Description
+	if desc == "" {
+		desc = entry.Line
+	}
+	rt.Output().Info(fmt.Sprintf("Undone: %s", desc))
+	return CommandResult{Status: StatusSuccess}
+}
@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UsageEvent describes one command execution. Arguments are never included.
+type UsageEvent struct {
+	Command  string        `json:"command"`
+	Context  string        `json:"context"`
+	Status   CommandStatus `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Time     time.Time     `json:"time"`
+	// InvocationID correlates the event with the run's logs and tasks.
+	InvocationID string `json:"invocation_id,omitempty"`
+}
+
+// UsageRecorder receives an event after each command an operator runs.
+type UsageRecorder interface {
+	RecordUsage(UsageEvent)
+}
+
+// UsageRecorderFunc adapts a function into a UsageRecorder.
+type UsageRecorderFunc func(UsageEvent)
+
+// RecordUsage implements UsageRecorder.
+func (f UsageRecorderFunc) RecordUsage(ev UsageEvent) { f(ev) }
+
+// WithUsageRecorder adds a recorder for command usage analytics.
+func WithUsageRecorder(r UsageRecorder) Option {
+	return func(e *Engine) {
+		if r != nil {
+			e.usageRecorders = append(e.usageRecorders, r)
+		}
+	}
+}
+
+func (e *Engine) recordUsage(ev UsageEvent) {
+	e.mu.RLock()
+	recorders := e.usageRecorders
+	e.mu.RUnlock()
+	for _, r := range recorders {
+		r.RecordUsage(ev)
+	}
+}
+
+// CommandUsage aggregates usage of one command.
+type CommandUsage struct {
+	Command       string        `json:"command"`
+	Context       string        `json:"context"`
+	Count         int           `json:"count"`
+	Failures      int           `json:"failures"`
+	TotalDuration time.Duration `json:"total_duration"`
+	LastUsed      time.Time     `json:"last_used,omitempty"`
+}
+
+// AverageDuration returns the mean execution time.
+func (u CommandUsage) AverageDuration() time.Duration {
+	if u.Count == 0 {
+		return 0
+	}
+	return u.TotalDuration / time.Duration(u.Count)
+}
+
+// UsageStats is an in-memory UsageRecorder aggregating events per command.
+type UsageStats struct {
+	mu    sync.Mutex
+	usage map[string]*CommandUsage
+}
+
+// NewUsageStats constructs an empty aggregator.
+func NewUsageStats() *UsageStats {
+	return &UsageStats{usage: map[string]*CommandUsage{}}
+}
+
+// RecordUsage implements UsageRecorder.
+func (u *UsageStats) RecordUsage(ev UsageEvent) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	key := ev.Context + "\x00" + ev.Command
+	agg, ok := u.usage[key]
+	if !ok {
+		agg = &CommandUsage{Command: ev.Command, Context: ev.Context}
+		u.usage[key] = agg
+	}
+	agg.Count++
+	if ev.Status == StatusFailed {
+		agg.Failures++
+	}
+	agg.TotalDuration += ev.Duration
+	if ev.Time.After(agg.LastUsed) {
+		agg.LastUsed = ev.Time
+	}
+}
+
+// Export returns the aggregates, most used first.
+func (u *UsageStats) Export() []CommandUsage {
+	u.mu.Lock()
+	list := make([]CommandUsage, 0, len(u.usage))
+	for _, agg := range u.usage {
+		list = append(list, *agg)
+	}
+	u.mu.Unlock()
+	sortUsage(list)
+	return list
+}
+
+// Report is Export plus a zero-count row for every registered command never run,
+// so unused commands stand out.
+func (u *UsageStats) Report(registry *CommandRegistry) []CommandUsage {
+	list := u.Export()
+	seen := map[string]bool{}
+	for _, agg := range list {
+		seen[agg.Context+"\x00"+agg.Command] = true
+	}
+	contexts := []string{""}
+	for _, ctx := range registry.Contexts(true) {
+		contexts = append(contexts, ctx.Name)
+	}
+	for _, ctx := range contexts {
+		for _, spec := range registry.Commands(ctx, true) {
+			if seen[ctx+"\x00"+spec.Name] {
+				continue
+			}
+			seen[ctx+"\x00"+spec.Name] = true
+			list = append(list, CommandUsage{Command: spec.Name, Context: ctx})
+		}
+	}
+	sortUsage(list)
+	return list
+}
+
+func sortUsage(list []CommandUsage) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		if list[i].Context != list[j].Context {
+			return list[i].Context < list[j].Context
+		}
+		return list[i].Command < list[j].Command
+	})
+}
+
+// WriteUsageJSON writes usage aggregates as a JSON array.
+func WriteUsageJSON(w io.Writer, usage []CommandUsage) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(usage)
+}
+
+// WriteUsageCSV writes usage aggregates as CSV with a header row.
+func WriteUsageCSV(w io.Writer, usage []CommandUsage) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"context", "command", "count", "failures", "avg_ms", "last_used"}); err != nil {
+		return err
+	}
+	for _, u := range usage {
+		last := ""
+		if !u.LastUsed.IsZero() {
+			last = u.LastUsed.UTC().Format(time.RFC3339)
+		}
+		avg := strconv.FormatFloat(float64(u.AverageDuration())/float64(time.Millisecond), 'f', 3, 64)
+		if err := cw.Write([]string{u.Context, u.Command, strconv.Itoa(u.Count), strconv.Itoa(u.Failures), avg, last}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
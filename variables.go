@@ -0,0 +1,198 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// errUnboundVariable reports a variable expanded without a value or default.
+var errUnboundVariable = errors.New("unbound variable")
+
+// variableName matches the names accepted by SetVar.
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sessionVars holds the variables set with `set NAME=value`.
+type sessionVars struct {
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// SetVar sets a session variable, expanded in command lines as $name or
+// ${name}. An empty value removes it.
+func (s *Session) SetVar(name, value string) error {
+	if !variableName.MatchString(name) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	s.vars.mu.Lock()
+	defer s.vars.mu.Unlock()
+	if value == "" {
+		delete(s.vars.values, name)
+		return nil
+	}
+	if s.vars.values == nil {
+		s.vars.values = map[string]string{}
+	}
+	s.vars.values[name] = value
+	return nil
+}
+
+// Var returns a session variable.
+func (s *Session) Var(name string) (string, bool) {
+	s.vars.mu.RLock()
+	defer s.vars.mu.RUnlock()
+	v, ok := s.vars.values[name]
+	return v, ok
+}
+
+// Vars returns a copy of the session variables.
+func (s *Session) Vars() map[string]string {
+	s.vars.mu.RLock()
+	defer s.vars.mu.RUnlock()
+	vars := make(map[string]string, len(s.vars.values))
+	for k, v := range s.vars.values {
+		vars[k] = v
+	}
+	return vars
+}
+
+// lookupVar resolves a name from the session variables, then the environment.
+func (s *Session) lookupVar(name string) (string, bool) {
+	if v, ok := s.Var(name); ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
+
+// splitLine lexes an interactive command line, expanding variables and result
+// references within the words they appear in, so that a value such as
+// `core router` or a JSON object stays one argument. Unlike scripts, which
+// follow `set -u`, an undefined variable is an error so a typo does not
+// silently drop an argument.
+func (s *Session) splitLine(line string) ([]token, error) {
+	tokens, _, err := lexExpanded(line, func(rest string) (string, int, error) {
+		return expandRef(rest, s.lookupVar, s.resolveResultRef, true)
+	})
+	if errors.Is(err, errUnboundVariable) {
+		return nil, fmt.Errorf("%w (assign it with set NAME=value, or write \\$ for a literal dollar sign)", err)
+	}
+	return tokens, err
+}
+
+// expandRef resolves the $NAME, ${NAME}, ${NAME:-default}, or, when refs is
+// set, result reference at the start of rest, which follows a $. It returns
+// the value and the bytes of rest the reference spans, or zero width when no
+// name follows.
+func expandRef(rest string, lookup func(string) (string, bool), refs func(string) (string, error), nounset bool) (string, int, error) {
+	name, width := scriptVarName(rest)
+	if width == 0 {
+		return "", 0, nil
+	}
+	if refs != nil && isResultRef(name) {
+		if rest[0] != '{' {
+			path := resultRefPath.FindString(rest[width:])
+			name, width = name+path, width+len(path)
+		}
+		value, err := refs(name)
+		return value, width, err
+	}
+	name, fallback, hasDefault := strings.Cut(name, ":-")
+	value, ok := lookup(name)
+	switch {
+	case hasDefault && value == "":
+		value = fallback
+	case !ok && nounset:
+		return "", 0, fmt.Errorf("%s: %w", name, errUnboundVariable)
+	}
+	return value, width, nil
+}
+
+// env command -----------------------------------------------------------------
+
+type envCommandFactory struct {
+	spec CommandSpec
+}
+
+func (f *envCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "env",
+			Summary:     "Show session variables",
+			Description: "Lists the variables set with `set NAME=value`. With a name, shows the value $NAME expands to, which falls back to the process environment.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "name", Type: ArgTypeString, Description: "Variable to show", Complete: completeVarNames},
+			},
+			Examples: []Example{
+				{Description: "List variables", Command: "env"},
+				{Description: "Show what $region expands to", Command: "env region"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *envCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &envCommand{spec: f.Spec()}, nil
+}
+
+type envCommand struct {
+	spec CommandSpec
+}
+
+func (c *envCommand) Spec() CommandSpec { return c.spec }
+
+func (c *envCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return CommandResult{Status: StatusFailed, Error: &CommandError{Message: "env requires an engine session", Severity: SeverityError}}
+	}
+	if name := input.Args.String("name"); name != "" {
+		source := "session"
+		value, ok := session.Var(name)
+		if !ok {
+			source = "environment"
+			value, ok = os.LookupEnv(name)
+		}
+		if !ok {
+			return CommandResult{Status: StatusFailed, Error: &CommandError{Message: fmt.Sprintf("undefined variable: %s", name), ErrCode: ErrCodeUsage, Severity: SeverityError}}
+		}
+		rt.Output().WriteTable([]string{"Name", "Value", "Source"}, [][]string{{name, value, source}})
+		return CommandResult{Status: StatusSuccess, Payload: map[string]string{name: value}}
+	}
+	vars := session.Vars()
+	if len(vars) == 0 {
+		rt.Output().Info("No variables set.")
+		return CommandResult{Status: StatusSuccess, Payload: vars}
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		rows = append(rows, []string{name, vars[name]})
+	}
+	rt.Output().WriteTable([]string{"Name", "Value"}, rows)
+	return CommandResult{Status: StatusSuccess, Payload: vars}
+}
+
+func completeVarNames(prefix string, rt CommandRuntime) []string {
+	session, ok := sessionOf(rt)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for name := range session.Vars() {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chzyer/readline"
+)
+
+// Control sequences accepted by VirtualPTY.Send.
+const (
+	KeyEnter = "\r"
+	KeyTab   = "\t"
+	KeyCtrlC = "\x03"
+	KeyCtrlD = "\x04"
+	KeyCtrlR = "\x12"
+	KeyUp    = "\x1b[A"
+	KeyDown  = "\x1b[B"
+)
+
+// VirtualPTYOptions configure a VirtualPTY.
+type VirtualPTYOptions struct {
+	Width       int
+	Prompt      string
+	HistoryFile string
+}
+
+// VirtualPTY drives a readline.Instance over in-memory pipes with a fixed width,
+// allowing end-to-end tests of prompts, completion, and Ctrl-C handling.
+type VirtualPTY struct {
+	rl     *readline.Instance
+	input  *io.PipeWriter
+	output *syncBuffer
+}
+
+// NewVirtualPTY constructs a readline instance bound to an in-memory terminal.
+func NewVirtualPTY(opts VirtualPTYOptions) (*VirtualPTY, error) {
+	width := opts.Width
+	if width <= 0 {
+		width = 80
+	}
+	pr, pw := io.Pipe()
+	out := &syncBuffer{}
+	cfg := &readline.Config{
+		Prompt:              opts.Prompt,
+		HistoryFile:         opts.HistoryFile,
+		Stdin:               pr,
+		Stdout:              out,
+		Stderr:              out,
+		FuncGetWidth:        func() int { return width },
+		FuncIsTerminal:      func() bool { return true },
+		FuncMakeRaw:         func() error { return nil },
+		FuncExitRaw:         func() error { return nil },
+		FuncOnWidthChanged:  func(func()) {},
+		ForceUseInteractive: true,
+	}
+	rl, err := readline.NewEx(cfg)
+	if err != nil {
+		pw.Close()
+		return nil, err
+	}
+	return &VirtualPTY{rl: rl, input: pw, output: out}, nil
+}
+
+// Instance returns the readline instance to pass to Engine.Run.
+func (p *VirtualPTY) Instance() *readline.Instance { return p.rl }
+
+// Writer returns a writer that interleaves correctly with the prompt, for WithOutputWriter.
+func (p *VirtualPTY) Writer() io.Writer { return p.rl.Stdout() }
+
+// Send writes raw keystrokes to the terminal.
+func (p *VirtualPTY) Send(keys string) error {
+	_, err := io.WriteString(p.input, keys)
+	return err
+}
+
+// SendLine types line followed by Enter.
+func (p *VirtualPTY) SendLine(line string) error {
+	return p.Send(line + KeyEnter)
+}
+
+// Output returns everything written to the terminal so far.
+func (p *VirtualPTY) Output() string { return p.output.String() }
+
+// ResetOutput discards captured terminal output.
+func (p *VirtualPTY) ResetOutput() { p.output.Reset() }
+
+// WaitFor blocks until the output contains substr or the timeout elapses.
+func (p *VirtualPTY) WaitFor(substr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if strings.Contains(p.output.String(), substr) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %q", substr)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Close closes the input pipe and the readline instance.
+func (p *VirtualPTY) Close() error {
+	p.input.Close()
+	return p.rl.Close()
+}
+
+// syncBuffer is a goroutine-safe bytes.Buffer.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
@@ -0,0 +1,474 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Workflow is a named sequence of steps run by `workflow run`. A run executes
+// as a task, so it shows in `tasks`; when a step fails the run stops and can
+// be resumed with `workflow resume` or undone with `workflow rollback`.
+type Workflow struct {
+	Name    string
+	Summary string
+	Steps   []WorkflowStep
+}
+
+// WorkflowStep is one step of a Workflow: either a command line, run as if
+// typed by the operator with variables expanded, or a TaskFunc.
+type WorkflowStep struct {
+	Name    string
+	Command string
+	Run     TaskFunc
+	// Confirm, when set, is asked before the step runs; declining stops the
+	// run so it can be resumed later.
+	Confirm string
+	// Rollback or RollbackCommand undoes the step. They run in reverse order
+	// for the completed steps of a failed run.
+	Rollback        TaskFunc
+	RollbackCommand string
+	// Checkpoint marks a point a failed run resumes after. Without any
+	// checkpoints in the workflow, a run resumes at the step that failed.
+	Checkpoint bool
+}
+
+// WorkflowRun is the state of a workflow's latest run.
+type WorkflowRun struct {
+	Workflow string `json:"workflow"`
+	Status   string `json:"status"`
+	TaskID   string `json:"task_id,omitempty"`
+	// Start is the step the run began at; Resume is where `workflow resume`
+	// continues; Completed lists the steps finished, including those of the
+	// run a resumed run continues.
+	Start     int    `json:"start"`
+	Resume    int    `json:"resume"`
+	Completed []int  `json:"completed"`
+	Failed    string `json:"failed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Workflow run statuses.
+const (
+	WorkflowRunning    = "running"
+	WorkflowSucceeded  = "succeeded"
+	WorkflowFailed     = "failed"
+	WorkflowStopped    = "stopped"
+	WorkflowRolledBack = "rolled back"
+)
+
+// errWorkflowDeclined stops a run whose confirmation the operator declined.
+var errWorkflowDeclined = errors.New("declined by operator")
+
+// workflows holds the engine's workflow definitions and latest runs.
+type workflows struct {
+	mu   sync.Mutex
+	defs map[string]Workflow
+	runs map[string]*WorkflowRun
+}
+
+func (w *workflows) get(name string) (Workflow, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	wf, ok := w.defs[name]
+	return wf, ok
+}
+
+func (w *workflows) list() []Workflow {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	list := make([]Workflow, 0, len(w.defs))
+	for _, wf := range w.defs {
+		list = append(list, wf)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// lastRun returns a copy of the workflow's latest run.
+func (w *workflows) lastRun(name string) (WorkflowRun, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	run, ok := w.runs[name]
+	if !ok {
+		return WorkflowRun{}, false
+	}
+	snapshot := *run
+	snapshot.Completed = append([]int(nil), run.Completed...)
+	return snapshot, true
+}
+
+// update applies fn to the run under the lock.
+func (w *workflows) update(run *WorkflowRun, fn func(*WorkflowRun)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(run)
+}
+
+// begin records a new run of wf starting at step start, refusing while one is
+// in progress.
+func (w *workflows) begin(wf Workflow, start int) (*WorkflowRun, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if run, ok := w.runs[wf.Name]; ok && run.Status == WorkflowRunning {
+		return nil, fmt.Errorf("workflow %s is already running as %s", wf.Name, run.TaskID)
+	}
+	if w.runs == nil {
+		w.runs = map[string]*WorkflowRun{}
+	}
+	run := &WorkflowRun{Workflow: wf.Name, Status: WorkflowRunning, Start: start, Resume: start}
+	w.runs[wf.Name] = run
+	return run, nil
+}
+
+// WithWorkflow registers a workflow for the workflow command. Workflows
+// without a name or with a step that has neither Command nor Run are reported
+// and skipped.
+func WithWorkflow(wf Workflow) Option {
+	return func(e *Engine) {
+		if err := wf.validate(); err != nil {
+			fmt.Fprintf(e.outputWriter, "Error adding workflow: %v\n", err)
+			return
+		}
+		e.workflows.mu.Lock()
+		defer e.workflows.mu.Unlock()
+		if e.workflows.defs == nil {
+			e.workflows.defs = map[string]Workflow{}
+		}
+		e.workflows.defs[wf.Name] = wf
+	}
+}
+
+func (wf Workflow) validate() error {
+	if wf.Name == "" {
+		return errors.New("workflow name is required")
+	}
+	if len(wf.Steps) == 0 {
+		return fmt.Errorf("workflow %s has no steps", wf.Name)
+	}
+	for i, step := range wf.Steps {
+		if (step.Command == "") == (step.Run == nil) {
+			return fmt.Errorf("workflow %s: step %d needs exactly one of Command and Run", wf.Name, i+1)
+		}
+	}
+	return nil
+}
+
+func (wf Workflow) hasCheckpoints() bool {
+	for _, step := range wf.Steps {
+		if step.Checkpoint {
+			return true
+		}
+	}
+	return false
+}
+
+func (step WorkflowStep) label(i int) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	if step.Command != "" {
+		return step.Command
+	}
+	return fmt.Sprintf("step %d", i+1)
+}
+
+// workflow command ------------------------------------------------------------
+
+type workflowCommandFactory struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (f *workflowCommandFactory) Spec() CommandSpec {
+	if f.spec.Name == "" {
+		f.spec = CommandSpec{
+			Name:        "workflow",
+			Summary:     "Run multi-step workflows",
+			Description: "Runs the workflows provided by the application step by step as a task. A failed or stopped run can be resumed from its last checkpoint, or rolled back by undoing its completed steps in reverse order.",
+			Context:     "",
+			Args: []ArgSpec{
+				{Name: "action", Type: ArgTypeEnum, EnumValues: []string{"list", "show", "run", "resume", "rollback"}, Default: "list", Description: "Action to perform"},
+				{Name: "name", Type: ArgTypeString, Description: "Workflow name", Complete: f.completeNames},
+			},
+			Flags: []FlagSpec{
+				{Name: "rollback", Type: ArgTypeBool, Description: "Roll back the completed steps if a step fails"},
+				{Name: "yes", Shorthand: "y", Type: ArgTypeBool, Description: "Skip step confirmations"},
+			},
+			Examples: []Example{
+				{Description: "Run a workflow", Command: "workflow run drain-device"},
+				{Description: "Continue after fixing a failure", Command: "workflow resume drain-device"},
+			},
+		}
+	}
+	return f.spec
+}
+
+func (f *workflowCommandFactory) completeNames(prefix string, rt CommandRuntime) []string {
+	var names []string
+	for _, wf := range f.engine.workflows.list() {
+		if strings.HasPrefix(wf.Name, prefix) {
+			names = append(names, wf.Name)
+		}
+	}
+	return names
+}
+
+func (f *workflowCommandFactory) New(rt CommandRuntime) (Command, error) {
+	return &workflowCommand{engine: f.engine, spec: f.Spec()}, nil
+}
+
+type workflowCommand struct {
+	engine *Engine
+	spec   CommandSpec
+}
+
+func (c *workflowCommand) Spec() CommandSpec { return c.spec }
+
+func (c *workflowCommand) Execute(rt CommandRuntime, input CommandInput) CommandResult {
+	action := input.Args.String("action")
+	if action == "list" {
+		return c.list(rt)
+	}
+	name := input.Args.String("name")
+	if name == "" {
+		return workflowFailure(fmt.Errorf("workflow %s requires a name", action))
+	}
+	wf, ok := c.engine.workflows.get(name)
+	if !ok {
+		return workflowFailure(fmt.Errorf("unknown workflow: %s", name))
+	}
+	if action == "show" {
+		return c.show(rt, wf)
+	}
+	session, ok := sessionOf(rt)
+	if !ok {
+		return workflowFailure(errors.New("workflow requires an engine session"))
+	}
+	last, hasRun := c.engine.workflows.lastRun(name)
+	switch action {
+	case "resume":
+		if !hasRun || (last.Status != WorkflowFailed && last.Status != WorkflowStopped) {
+			return workflowFailure(fmt.Errorf("workflow %s has no failed run to resume", name))
+		}
+		return c.run(rt, session, wf, last.Resume, last.Completed, input)
+	case "rollback":
+		if !hasRun || (last.Status != WorkflowFailed && last.Status != WorkflowStopped) {
+			return workflowFailure(fmt.Errorf("workflow %s has no failed run to roll back", name))
+		}
+		run, err := c.engine.workflows.begin(wf, last.Start)
+		if err != nil {
+			return workflowFailure(err)
+		}
+		c.engine.workflows.update(run, func(r *WorkflowRun) { r.Completed = last.Completed })
+		if err := c.rollback(rt.Cancellation(), rt, session, wf, run); err != nil {
+			return workflowFailure(err)
+		}
+		return CommandResult{Status: StatusSuccess, Payload: c.snapshot(run)}
+	}
+	return c.run(rt, session, wf, 0, nil, input)
+}
+
+func (c *workflowCommand) list(rt CommandRuntime) CommandResult {
+	list := c.engine.workflows.list()
+	if len(list) == 0 {
+		rt.Output().Info("No workflows available.")
+		return CommandResult{Status: StatusSuccess}
+	}
+	rows := make([][]string, 0, len(list))
+	for _, wf := range list {
+		status := "-"
+		if run, ok := c.engine.workflows.lastRun(wf.Name); ok {
+			status = run.Status
+		}
+		rows = append(rows, []string{wf.Name, fmt.Sprint(len(wf.Steps)), status, wf.Summary})
+	}
+	rt.Output().WriteTable([]string{"Name", "Steps", "Last run", "Summary"}, rows)
+	return CommandResult{Status: StatusSuccess}
+}
+
+func (c *workflowCommand) show(rt CommandRuntime, wf Workflow) CommandResult {
+	run, hasRun := c.engine.workflows.lastRun(wf.Name)
+	done := map[int]bool{}
+	for _, i := range run.Completed {
+		done[i] = true
+	}
+	rows := make([][]string, 0, len(wf.Steps))
+	for i, step := range wf.Steps {
+		var marks []string
+		if step.Confirm != "" {
+			marks = append(marks, "confirm")
+		}
+		if step.Checkpoint {
+			marks = append(marks, "checkpoint")
+		}
+		if step.Rollback != nil || step.RollbackCommand != "" {
+			marks = append(marks, "rollback")
+		}
+		state := ""
+		switch {
+		case !hasRun:
+		case done[i]:
+			state = "done"
+		case step.label(i) == run.Failed:
+			state = run.Status
+		}
+		rows = append(rows, []string{fmt.Sprint(i + 1), step.label(i), strings.Join(marks, ","), state})
+	}
+	rt.Output().WriteTable([]string{"#", "Step", "Options", "Last run"}, rows)
+	if hasRun {
+		return CommandResult{Status: StatusSuccess, Payload: run}
+	}
+	return CommandResult{Status: StatusSuccess}
+}
+
+// run executes wf from step start as a task and waits for it; interrupting
+// the command cancels the task. completed are the steps an earlier run
+// finished, kept so that a rollback after a resume undoes them too.
+func (c *workflowCommand) run(rt CommandRuntime, session *Session, wf Workflow, start int, completed []int, input CommandInput) CommandResult {
+	run, err := c.engine.workflows.begin(wf, start)
+	if err != nil {
+		return workflowFailure(err)
+	}
+	c.engine.workflows.update(run, func(r *WorkflowRun) {
+		for _, i := range completed {
+			if i < start {
+				r.Completed = append(r.Completed, i)
+			}
+		}
+	})
+	yes := input.Flags.Bool("yes")
+	done := make(chan error, 1)
+	handle := rt.TaskManager().Spawn("workflow "+wf.Name, func(ctx context.Context, output OutputChannel) error {
+		err := c.steps(ctx, rt, output, session, wf, run, yes)
+		done <- err
+		return err
+	}, TaskOptions{NoNotify: true, Metadata: map[string]any{"workflow": wf.Name}})
+	c.engine.workflows.update(run, func(r *WorkflowRun) { r.TaskID = handle.ID })
+
+	select {
+	case err = <-done:
+	case <-rt.Cancellation().Done():
+		rt.TaskManager().Cancel(handle.ID)
+		err = <-done
+	}
+	if err == nil {
+		c.engine.workflows.update(run, func(r *WorkflowRun) { r.Status, r.Resume = WorkflowSucceeded, len(wf.Steps) })
+		rt.Output().Info(fmt.Sprintf("Workflow %s completed (%d step(s)).", wf.Name, len(run.Completed)))
+		return CommandResult{Status: StatusSuccess, Payload: c.snapshot(run)}
+	}
+
+	status := WorkflowFailed
+	if errors.Is(err, errWorkflowDeclined) || errors.Is(err, context.Canceled) {
+		status = WorkflowStopped
+	}
+	c.engine.workflows.update(run, func(r *WorkflowRun) { r.Status, r.Error = status, err.Error() })
+	hints := []string{fmt.Sprintf("workflow resume %s continues from step %d", wf.Name, run.Resume+1)}
+	if input.Flags.Bool("rollback") {
+		if rerr := c.rollback(context.Background(), rt, session, wf, run); rerr != nil {
+			err = fmt.Errorf("%w; rollback failed: %v", err, rerr)
+		} else {
+			hints = nil
+		}
+	} else if len(run.Completed) > 0 {
+		hints = append(hints, fmt.Sprintf("workflow rollback %s undoes the %d completed step(s)", wf.Name, len(run.Completed)))
+	}
+	return CommandResult{Status: StatusFailed, Payload: c.snapshot(run), Error: &CommandError{Err: err, Message: fmt.Sprintf("workflow %s %s: %v", wf.Name, status, err), Severity: SeverityError, Hints: hints}}
+}
+
+// steps runs wf's steps from run.Start, recording progress in run.
+func (c *workflowCommand) steps(ctx context.Context, rt CommandRuntime, output OutputChannel, session *Session, wf Workflow, run *WorkflowRun, yes bool) error {
+	progress := ReportProgress(ctx)
+	checkpoints := wf.hasCheckpoints()
+	for i := run.Start; i < len(wf.Steps); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		step := wf.Steps[i]
+		progress(i, len(wf.Steps), step.label(i))
+		rt.Output().Info(fmt.Sprintf("[%d/%d] %s", i+1, len(wf.Steps), step.label(i)))
+		if step.Confirm != "" && !yes {
+			ok, err := session.Confirm(step.Confirm)
+			if err != nil {
+				return fmt.Errorf("step %s: %w", step.label(i), err)
+			}
+			if !ok {
+				c.engine.workflows.update(run, func(r *WorkflowRun) { r.Failed = step.label(i) })
+				return fmt.Errorf("step %s: %w", step.label(i), errWorkflowDeclined)
+			}
+		}
+		if err := runWorkflowAction(ctx, output, session, step.Command, step.Run); err != nil {
+			c.engine.workflows.update(run, func(r *WorkflowRun) { r.Failed = step.label(i) })
+			return fmt.Errorf("step %s: %w", step.label(i), err)
+		}
+		c.engine.workflows.update(run, func(r *WorkflowRun) {
+			r.Completed = append(r.Completed, i)
+			if !checkpoints || step.Checkpoint {
+				r.Resume = i + 1
+			}
+		})
+	}
+	progress(len(wf.Steps), len(wf.Steps), "done")
+	return nil
+}
+
+// rollback undoes run's completed steps in reverse order, stopping at the
+// first rollback that fails.
+func (c *workflowCommand) rollback(ctx context.Context, rt CommandRuntime, session *Session, wf Workflow, run *WorkflowRun) error {
+	completed := append([]int(nil), run.Completed...)
+	for n := len(completed) - 1; n >= 0; n-- {
+		i := completed[n]
+		step := wf.Steps[i]
+		if step.Rollback == nil && step.RollbackCommand == "" {
+			continue
+		}
+		rt.Output().Info(fmt.Sprintf("rolling back %s", step.label(i)))
+		if err := runWorkflowAction(ctx, rt.Output(), session, step.RollbackCommand, step.Rollback); err != nil {
+			c.engine.workflows.update(run, func(r *WorkflowRun) { r.Status, r.Completed = WorkflowFailed, completed[:n+1] })
+			return fmt.Errorf("rolling back %s: %w", step.label(i), err)
+		}
+	}
+	c.engine.workflows.update(run, func(r *WorkflowRun) {
+		r.Status, r.Completed, r.Resume, r.Failed = WorkflowRolledBack, nil, r.Start, ""
+	})
+	rt.Output().Info(fmt.Sprintf("Workflow %s rolled back.", wf.Name))
+	return nil
+}
+
+// runWorkflowAction runs a step or rollback: the command line when set,
+// otherwise fn.
+func runWorkflowAction(ctx context.Context, output OutputChannel, session *Session, command string, fn TaskFunc) error {
+	if command == "" {
+		return fn(ctx, output)
+	}
+	tokens, err := session.splitLine(command)
+	if err != nil {
+		return err
+	}
+	result, err := session.execute(ctx, tokens)
+	if err != nil {
+		return err
+	}
+	if result.Status == StatusFailed {
+		if result.Error != nil && result.Error.Err != nil {
+			return result.Error.Err
+		}
+		if result.Error != nil && result.Error.Message != "" {
+			return errors.New(result.Error.Message)
+		}
+		return errors.New("command failed")
+	}
+	return nil
+}
+
+func (c *workflowCommand) snapshot(run *WorkflowRun) WorkflowRun {
+	snapshot, _ := c.engine.workflows.lastRun(run.Workflow)
+	return snapshot
+}
+
+func workflowFailure(err error) CommandResult {
+	return CommandResult{Status: StatusFailed, Error: &CommandError{Err: err, Message: err.Error(), Severity: SeverityError}}
+}